@@ -0,0 +1,580 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// backupTimeLayout is the UTC timestamp embedded in snapshot file names. It
+// sorts lexically and keeps milliseconds so back-to-back snapshots differ.
+const backupTimeLayout = "20060102T150405.000Z"
+
+// backupNamePattern matches lcm-<timestamp>-<label>.db snapshot names.
+var backupNamePattern = regexp.MustCompile(`^lcm-(\d{8}T\d{6}\.\d{3}Z)-(.+)\.db$`)
+
+// backupSourceSuffix names the sidecar file next to each snapshot that
+// records which database file it was taken from.
+const backupSourceSuffix = ".source"
+
+// backupEntry describes one snapshot in the backups directory. source is the
+// database file the snapshot was taken from, or empty for snapshots written
+// before sidecars existed.
+type backupEntry struct {
+	name      string
+	path      string
+	label     string
+	source    string
+	createdAt time.Time
+	size      int64
+}
+
+type backupsOptions struct {
+	action    string
+	name      string
+	dbPath    string
+	apply     bool
+	keep      int
+	olderThan time.Duration
+}
+
+// pathsForDB returns paths retargeted at another lcm.db file. Snapshots of a
+// file other than the default go to their own subdirectory of the backups
+// dir, named after the file, so listing, restore and prune never mix them up
+// with the default database's.
+func pathsForDB(paths appDataPaths, dbPath string) (appDataPaths, error) {
+	if dbPath == "" {
+		return paths, nil
+	}
+	abs, err := filepath.Abs(dbPath)
+	if err != nil {
+		return appDataPaths{}, fmt.Errorf("resolve %s: %w", dbPath, err)
+	}
+	if isDefaultDB(paths, abs) {
+		return paths, nil
+	}
+	sum := sha256.Sum256([]byte(abs))
+	base := strings.TrimSuffix(filepath.Base(abs), filepath.Ext(abs))
+	paths.lcmDBPath = abs
+	paths.backupsDir = filepath.Join(paths.backupsDir, fmt.Sprintf("db-%s-%s", sanitizeBackupLabel(base), hex.EncodeToString(sum[:4])))
+	return paths, nil
+}
+
+// isDefaultDB reports whether dbPath is the default lcm.db, following
+// symlinks when both files exist.
+func isDefaultDB(paths appDataPaths, dbPath string) bool {
+	if same, err := sameDBFile(paths.lcmDBPath, dbPath); err == nil {
+		return same
+	}
+	return filepath.Clean(paths.lcmDBPath) == filepath.Clean(dbPath)
+}
+
+// undoCommandFor is the undo command line to print after journaling op opID
+// in the database at dbPath.
+func undoCommandFor(defaults appDataPaths, dbPath string, opID int64) string {
+	if isDefaultDB(defaults, dbPath) {
+		return fmt.Sprintf("lcm-tui undo %d --apply", opID)
+	}
+	return fmt.Sprintf("lcm-tui undo %d --apply --db %s", opID, dbPath)
+}
+
+// snapshotLCMDB writes a consistent copy of the database to the backups
+// directory with VACUUM INTO. The copy reflects the last committed state even
+// while the gateway is writing, and is a standalone DB file (no -wal).
+func snapshotLCMDB(ctx context.Context, db *sql.DB, backupsDir, label string) (backupEntry, error) {
+	if err := os.MkdirAll(backupsDir, 0o700); err != nil {
+		return backupEntry{}, fmt.Errorf("create backups dir %q: %w", backupsDir, err)
+	}
+	now := time.Now().UTC()
+	name := fmt.Sprintf("lcm-%s-%s.db", now.Format(backupTimeLayout), sanitizeBackupLabel(label))
+	path := filepath.Join(backupsDir, name)
+
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		_ = os.Remove(path)
+		return backupEntry{}, fmt.Errorf("snapshot lcm.db to %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return backupEntry{}, fmt.Errorf("stat snapshot %s: %w", path, err)
+	}
+	source, err := mainDBFile(ctx, db)
+	if err != nil {
+		return backupEntry{}, err
+	}
+	if err := os.WriteFile(path+backupSourceSuffix, []byte(source+"\n"), 0o600); err != nil {
+		return backupEntry{}, fmt.Errorf("record source of snapshot %s: %w", path, err)
+	}
+	return backupEntry{
+		name:      name,
+		path:      path,
+		label:     sanitizeBackupLabel(label),
+		source:    source,
+		createdAt: now,
+		size:      info.Size(),
+	}, nil
+}
+
+// mainDBFile returns the file behind db's main schema.
+func mainDBFile(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, `PRAGMA database_list`)
+	if err != nil {
+		return "", fmt.Errorf("list attached databases: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var seq int64
+		var name, file string
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return "", fmt.Errorf("scan attached database: %w", err)
+		}
+		if name == "main" {
+			return file, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("iterate attached databases: %w", err)
+	}
+	return "", errors.New("database has no main schema")
+}
+
+// sanitizeBackupLabel keeps operation labels safe for use in file names.
+func sanitizeBackupLabel(label string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(label)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	cleaned := strings.Trim(b.String(), "-")
+	if cleaned == "" {
+		return "manual"
+	}
+	return cleaned
+}
+
+// backupBeforeApply snapshots the DB ahead of a CLI --apply and reports where
+// the snapshot went.
+func backupBeforeApply(ctx context.Context, db *sql.DB, paths appDataPaths, label string) error {
+	entry, err := snapshotLCMDB(ctx, db, paths.backupsDir, label)
+	if err != nil {
+		return fmt.Errorf("backup before apply: %w", err)
+	}
+	fmt.Printf("Backed up %s to %s (%s)\n", filepath.Base(paths.lcmDBPath), entry.path, formatByteSize(entry.size))
+	return nil
+}
+
+// listBackups returns snapshots in the backups directory, newest first. A
+// missing directory means no backups yet.
+func listBackups(backupsDir string) ([]backupEntry, error) {
+	entries, err := os.ReadDir(backupsDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read backups dir %q: %w", backupsDir, err)
+	}
+
+	backups := make([]backupEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := backupNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		createdAt, err := time.Parse(backupTimeLayout, match[1])
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("stat backup %q: %w", entry.Name(), err)
+		}
+		path := filepath.Join(backupsDir, entry.Name())
+		var source string
+		if data, err := os.ReadFile(path + backupSourceSuffix); err == nil {
+			source = strings.TrimSpace(string(data))
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("read source of backup %q: %w", entry.Name(), err)
+		}
+		backups = append(backups, backupEntry{
+			name:      entry.Name(),
+			path:      path,
+			label:     match[2],
+			source:    source,
+			createdAt: createdAt,
+			size:      info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].createdAt.After(backups[j].createdAt)
+	})
+	return backups, nil
+}
+
+// findBackup resolves a snapshot by file name (with or without .db), by a
+// unique name prefix, by label (newest wins), or by "latest". backups must be
+// sorted newest first.
+func findBackup(backups []backupEntry, name string) (backupEntry, error) {
+	name = filepath.Base(strings.TrimSpace(name))
+	if name == "latest" && len(backups) > 0 {
+		return backups[0], nil
+	}
+	for _, backup := range backups {
+		if backup.label == name {
+			return backup, nil
+		}
+	}
+	var matches []backupEntry
+	for _, backup := range backups {
+		if backup.name == name || backup.name == name+".db" {
+			return backup, nil
+		}
+		if strings.HasPrefix(backup.name, name) {
+			matches = append(matches, backup)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return backupEntry{}, fmt.Errorf("backup %q not found", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return backupEntry{}, fmt.Errorf("backup %q is ambiguous (%d matches)", name, len(matches))
+	}
+}
+
+// restoreLCMDB copies a snapshot over the live DB with SQLite's online backup
+// API. Unlike a file copy this goes through SQLite's locking, so a running
+// gateway sees either the old or the restored database, never a torn file.
+func restoreLCMDB(ctx context.Context, db *sql.DB, snapshotPath string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection for restore: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		restorer, ok := driverConn.(interface {
+			NewRestore(string) (*sqlite.Backup, error)
+		})
+		if !ok {
+			return errors.New("sqlite driver does not support online restore")
+		}
+		uri := "file:" + (&url.URL{Path: snapshotPath}).EscapedPath() + "?mode=ro"
+		backup, err := restorer.NewRestore(uri)
+		if err != nil {
+			return fmt.Errorf("start restore from %s: %w", snapshotPath, err)
+		}
+		for {
+			more, err := backup.Step(-1)
+			if err != nil {
+				_ = backup.Finish()
+				return fmt.Errorf("restore from %s: %w", snapshotPath, err)
+			}
+			if !more {
+				break
+			}
+		}
+		if err := backup.Finish(); err != nil {
+			return fmt.Errorf("finish restore from %s: %w", snapshotPath, err)
+		}
+		return nil
+	})
+}
+
+// selectBackupsToPrune returns the snapshots outside the retention policy:
+// everything beyond the newest keep entries, and (when olderThan is set)
+// everything older than that.
+func selectBackupsToPrune(backups []backupEntry, keep int, olderThan time.Duration, now time.Time) []backupEntry {
+	var prune []backupEntry
+	for i, backup := range backups {
+		switch {
+		case keep > 0 && i >= keep:
+			prune = append(prune, backup)
+		case olderThan > 0 && now.Sub(backup.createdAt) > olderThan:
+			prune = append(prune, backup)
+		}
+	}
+	return prune
+}
+
+func runBackupsCommand(args []string) error {
+	opts, err := parseBackupsArgs(args)
+	if err != nil {
+		return err
+	}
+
+	defaults, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+	paths, err := pathsForDB(defaults, opts.dbPath)
+	if err != nil {
+		return err
+	}
+	backups, err := listBackups(paths.backupsDir)
+	if err != nil {
+		return err
+	}
+
+	switch opts.action {
+	case "list":
+		printBackupList(paths, backups)
+		return nil
+	case "restore":
+		return runBackupRestore(paths, defaults, backups, opts)
+	case "prune":
+		return runBackupPrune(backups, opts)
+	}
+	return fmt.Errorf("unknown backups action %q\n%s", opts.action, backupsUsageText())
+}
+
+func runBackupRestore(paths, defaults appDataPaths, backups []backupEntry, opts backupsOptions) error {
+	backup, err := findBackup(backups, opts.name)
+	if err != nil {
+		return err
+	}
+	if err := checkBackupSource(backup, paths, defaults); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restore %s\n", backup.path)
+	fmt.Printf("  taken %s, label %s, %s\n", backup.createdAt.Local().Format("2006-01-02 15:04:05"), backup.label, formatByteSize(backup.size))
+	fmt.Printf("  over  %s\n", paths.lcmDBPath)
+	if !opts.apply {
+		fmt.Println("\nDry run. Use --apply to execute. The current DB is snapshotted first.")
+		return nil
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, dbReadWrite)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if err := backupBeforeApply(ctx, db, paths, "pre-restore"); err != nil {
+		return err
+	}
+	if err := restoreLCMDB(ctx, db, backup.path); err != nil {
+		return err
+	}
+	fmt.Printf("\nDone. Restored %s.\n", backup.name)
+	return nil
+}
+
+// checkBackupSource refuses to restore a snapshot over a database file other
+// than the one it was taken from. Snapshots without a recorded source predate
+// --db and were always taken from the default lcm.db.
+func checkBackupSource(backup backupEntry, paths, defaults appDataPaths) error {
+	source := backup.source
+	if source == "" {
+		source = defaults.lcmDBPath
+	}
+	same, err := sameDBFile(source, paths.lcmDBPath)
+	if err != nil {
+		same = filepath.Clean(source) == filepath.Clean(paths.lcmDBPath)
+	}
+	if !same {
+		return fmt.Errorf("backup %s was taken from %s, not %s", backup.name, source, paths.lcmDBPath)
+	}
+	return nil
+}
+
+func runBackupPrune(backups []backupEntry, opts backupsOptions) error {
+	prune := selectBackupsToPrune(backups, opts.keep, opts.olderThan, time.Now())
+	if len(prune) == 0 {
+		fmt.Println("Nothing to prune.")
+		return nil
+	}
+
+	var total int64
+	for _, backup := range prune {
+		total += backup.size
+	}
+	verb := "Would delete"
+	if opts.apply {
+		verb = "Deleting"
+	}
+	fmt.Printf("%s %d of %d backups (%s):\n", verb, len(prune), len(backups), formatByteSize(total))
+	for _, backup := range prune {
+		fmt.Printf("  %s\n", backup.name)
+	}
+	if !opts.apply {
+		fmt.Println("\nDry run. Use --apply to execute.")
+		return nil
+	}
+
+	for _, backup := range prune {
+		if err := os.Remove(backup.path); err != nil {
+			return fmt.Errorf("delete backup %s: %w", backup.name, err)
+		}
+		if err := os.Remove(backup.path + backupSourceSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("delete source of backup %s: %w", backup.name, err)
+		}
+	}
+	fmt.Printf("\nDone. %d backups deleted.\n", len(prune))
+	return nil
+}
+
+func printBackupList(paths appDataPaths, backups []backupEntry) {
+	if len(backups) == 0 {
+		fmt.Printf("No backups of %s in %s\n", paths.lcmDBPath, paths.backupsDir)
+		return
+	}
+	fmt.Printf("%d backups of %s in %s:\n", len(backups), paths.lcmDBPath, paths.backupsDir)
+	for _, backup := range backups {
+		fmt.Printf("  %-52s  %s  %-28s %9s\n",
+			backup.name,
+			backup.createdAt.Local().Format("2006-01-02 15:04:05"),
+			backup.label,
+			formatByteSize(backup.size))
+	}
+}
+
+func formatByteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func parseBackupsArgs(args []string) (backupsOptions, error) {
+	fs := flag.NewFlagSet("backups", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	apply := fs.Bool("apply", false, "apply restore or prune")
+	keep := fs.Int("keep", 0, "prune: keep the newest N backups")
+	olderThan := fs.String("older-than", "", "prune: delete backups older than this (e.g. 72h, 14d)")
+	dbPath := fs.String("db", "", "lcm.db whose backups to manage (default: ~/.openclaw/lcm.db)")
+
+	normalizedArgs, err := normalizeBackupsArgs(args)
+	if err != nil {
+		return backupsOptions{}, fmt.Errorf("%w\n%s", err, backupsUsageText())
+	}
+	if err := fs.Parse(normalizedArgs); err != nil {
+		return backupsOptions{}, fmt.Errorf("%w\n%s", err, backupsUsageText())
+	}
+
+	opts := backupsOptions{apply: *apply, keep: *keep, dbPath: *dbPath}
+	if fs.NArg() == 0 {
+		opts.action = "list"
+	} else {
+		opts.action = fs.Arg(0)
+	}
+	if *olderThan != "" {
+		opts.olderThan, err = parseRetentionDuration(*olderThan)
+		if err != nil {
+			return backupsOptions{}, fmt.Errorf("%w\n%s", err, backupsUsageText())
+		}
+	}
+
+	switch opts.action {
+	case "list":
+		if fs.NArg() > 1 {
+			return backupsOptions{}, fmt.Errorf("backups list takes no arguments\n%s", backupsUsageText())
+		}
+	case "restore":
+		if fs.NArg() != 2 {
+			return backupsOptions{}, fmt.Errorf("backups restore requires a backup name\n%s", backupsUsageText())
+		}
+		opts.name = fs.Arg(1)
+	case "prune":
+		if fs.NArg() != 1 {
+			return backupsOptions{}, fmt.Errorf("backups prune takes no positional arguments\n%s", backupsUsageText())
+		}
+		if opts.keep <= 0 && opts.olderThan <= 0 {
+			return backupsOptions{}, fmt.Errorf("backups prune requires --keep or --older-than\n%s", backupsUsageText())
+		}
+	default:
+		return backupsOptions{}, fmt.Errorf("unknown backups action %q\n%s", opts.action, backupsUsageText())
+	}
+	return opts, nil
+}
+
+// parseRetentionDuration accepts time.ParseDuration values plus a whole-day
+// "Nd" form.
+func parseRetentionDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid --older-than %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid --older-than %q", value)
+	}
+	return d, nil
+}
+
+func normalizeBackupsArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 2)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--apply":
+			flags = append(flags, arg)
+		case strings.HasPrefix(arg, "--keep=") || strings.HasPrefix(arg, "--older-than=") || strings.HasPrefix(arg, "--db="):
+			flags = append(flags, arg)
+		case arg == "--keep" || arg == "--older-than" || arg == "--db":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"):
+			flags = append(flags, arg)
+		default:
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func backupsUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui backups [list] [--db PATH]
+  lcm-tui backups restore <name|label|latest> [--db PATH] [--apply]
+  lcm-tui backups prune (--keep <n> | --older-than <72h|14d>) [--db PATH] [--apply]
+
+Snapshots live in ~/.openclaw/lcm-backups/ and are taken automatically before
+every --apply and before a dissolve confirmed in the TUI. Restore snapshots the
+current DB first, then copies the backup in with SQLite's online backup API.
+
+--db manages the snapshots of another lcm.db file (a transplant's
+--target-db, say). Those live in their own db-<name>-<hash> subdirectory,
+and restore refuses a snapshot taken from a different file.
+`)
+}
@@ -0,0 +1,626 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type compactOptions struct {
+	apply        bool
+	startOrdinal int64
+	endOrdinal   int64
+	summarizer   summarizerConfig
+	maxRetries   int
+	promptDir    string
+	instructions string
+	tokenizer    string
+}
+
+// compactMessage is one raw message item in the range being compacted.
+type compactMessage struct {
+	messageID  int64
+	ordinal    int64
+	role       string
+	tokenCount int
+	content    string
+}
+
+// compactPlan replaces a contiguous run of message context items with one
+// new leaf summary linked to those messages through summary_messages.
+type compactPlan struct {
+	conversationID int64
+	startOrdinal   int64
+	endOrdinal     int64
+	messages       []compactMessage
+	source         repairSource
+	inputTokens    int
+	targetTokens   int
+	itemsToShift   int
+}
+
+// estimatedSavings is the token reduction if the summarizer hits its target.
+func (p compactPlan) estimatedSavings() int {
+	return p.inputTokens - p.targetTokens
+}
+
+// compactReview is the TUI's pending compaction, mirroring condenseReview.
+type compactReview struct {
+	plan      compactPlan
+	content   string
+	tokens    int
+	generated bool
+	err       string
+}
+
+// compactCandidateMsg carries a generated leaf summary.
+type compactCandidateMsg struct {
+	seq     int
+	content string
+	tokens  int
+	err     error
+}
+
+// runCompactCommand builds a leaf summary from raw messages in the context
+// window and swaps it in for them.
+func runCompactCommand(args []string) error {
+	opts, conversationID, err := parseCompactArgs(args)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	plan, err := buildCompactPlan(ctx, db, conversationID, opts.startOrdinal, opts.endOrdinal)
+	if err != nil {
+		return err
+	}
+	printCompactPlan(plan)
+
+	if !opts.apply {
+		fmt.Println("\nDry run. Use --apply to execute.")
+		return nil
+	}
+
+	client, err := newSummarizer(opts.summarizer, paths)
+	if err != nil {
+		return err
+	}
+	promptDir, explicit := resolvePromptDir(opts.promptDir, paths)
+	prompts, err := loadRepairPrompts(promptDir, explicit, opts.instructions)
+	if err != nil {
+		return err
+	}
+	tokens, err := newTokenCounter(opts.tokenizer, paths)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nSummarizer: %s | Prompts: %s | Tokenizer: %s\n", client.describe(), prompts.describe(), tokens.describe())
+
+	content, tokenCount, err := generateCompactedLeaf(ctx, db, plan, client, prompts, tokens, opts.maxRetries)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generated leaf: %d chars / %d tokens\n\n", len(content), tokenCount)
+
+	label := fmt.Sprintf("compact-conv%d-%d-%d", conversationID, plan.startOrdinal, plan.endOrdinal)
+	if err := backupBeforeApply(ctx, db, paths, label); err != nil {
+		return err
+	}
+	summaryID, opID, err := applyCompact(ctx, db, plan, content, tokenCount)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Done. %d messages compacted into %s (%dt -> %dt, %+dt). Changes take effect on next conversation turn.\n",
+		len(plan.messages), summaryID, plan.inputTokens, tokenCount, tokenCount-plan.inputTokens)
+	fmt.Printf("Undo with: lcm-tui undo %d --apply\n", opID)
+	return nil
+}
+
+func parseCompactArgs(args []string) (compactOptions, int64, error) {
+	fs := flag.NewFlagSet("compact", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	apply := fs.Bool("apply", false, "write the leaf summary and rewrite context_items")
+	_ = fs.Bool("dry-run", true, "show what would be compacted")
+	ordinalRange := fs.String("ordinal-range", "", "context ordinals to compact, a:b inclusive (required)")
+	maxRetries := fs.Int("max-retries", defaultRepairRetries, "retries on 429/5xx/timeouts")
+	provider := fs.String("provider", providerAnthropic, "summarizer provider: anthropic, openai, or extractive")
+	model := fs.String("model", "", "model name (default depends on provider)")
+	baseURL := fs.String("base-url", "", "API base URL (default depends on provider)")
+	promptDir := fs.String("prompt-dir", "", "directory with leaf.tmpl/condensed.tmpl prompt overrides")
+	instructions := fs.String("instructions", "", "operator instructions added to the prompt")
+	tokenizer := fs.String("tokenizer", defaultTokenizer, "tokenizer for token_count: heuristic, an encoding, or a model name")
+
+	normalizedArgs, err := normalizeCompactArgs(args)
+	if err != nil {
+		return compactOptions{}, 0, fmt.Errorf("%w\n%s", err, compactUsageText())
+	}
+	if err := fs.Parse(normalizedArgs); err != nil {
+		return compactOptions{}, 0, fmt.Errorf("%w\n%s", err, compactUsageText())
+	}
+	if fs.NArg() != 1 {
+		return compactOptions{}, 0, fmt.Errorf("conversation ID is required\n%s", compactUsageText())
+	}
+	conversationID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return compactOptions{}, 0, fmt.Errorf("parse conversation ID %q: %w", fs.Arg(0), err)
+	}
+	if strings.TrimSpace(*ordinalRange) == "" {
+		return compactOptions{}, 0, fmt.Errorf("--ordinal-range is required\n%s", compactUsageText())
+	}
+	start, end, err := parseOrdinalRange(*ordinalRange)
+	if err != nil {
+		return compactOptions{}, 0, fmt.Errorf("%w\n%s", err, compactUsageText())
+	}
+	if *maxRetries < 0 {
+		return compactOptions{}, 0, fmt.Errorf("--max-retries must not be negative\n%s", compactUsageText())
+	}
+	summarizerConfig, err := parseSummarizerConfig(*provider, *model, *baseURL)
+	if err != nil {
+		return compactOptions{}, 0, fmt.Errorf("%w\n%s", err, compactUsageText())
+	}
+	promptDirValue, err := absPromptDir(*promptDir)
+	if err != nil {
+		return compactOptions{}, 0, err
+	}
+
+	return compactOptions{
+		apply:        *apply,
+		startOrdinal: start,
+		endOrdinal:   end,
+		summarizer:   summarizerConfig,
+		maxRetries:   *maxRetries,
+		promptDir:    promptDirValue,
+		instructions: strings.TrimSpace(*instructions),
+		tokenizer:    strings.TrimSpace(*tokenizer),
+	}, conversationID, nil
+}
+
+func normalizeCompactArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--ordinal-range" || arg == "--provider" || arg == "--model" || arg == "--base-url" ||
+			arg == "--max-retries" || arg == "--prompt-dir" || arg == "--instructions" || arg == "--tokenizer":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"):
+			flags = append(flags, arg)
+		default:
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func compactUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui compact <conversation_id> --ordinal-range <a:b> [--apply]
+
+Builds a leaf summary from the raw message items at context ordinals a..b
+(inclusive), links it to those messages through summary_messages, and
+replaces them in context_items with the new leaf. Every item in the range
+must be a message. Without --apply only the plan and estimated savings are
+printed.
+
+Accepts the repair summarizer and prompt flags: --provider, --model,
+--base-url, --max-retries, --prompt-dir, --instructions, --tokenizer.
+`)
+}
+
+// parseOrdinalRange parses "a:b" (or a single "a") into inclusive bounds.
+func parseOrdinalRange(value string) (int64, int64, error) {
+	startText, endText, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		endText = startText
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(startText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ordinal range %q: want a:b", value)
+	}
+	end, err := strconv.ParseInt(strings.TrimSpace(endText), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid ordinal range %q: want a:b", value)
+	}
+	if start < 0 || end < start {
+		return 0, 0, fmt.Errorf("invalid ordinal range %q: want 0 <= a <= b", value)
+	}
+	return start, end, nil
+}
+
+// buildCompactPlan loads the message items between two ordinals (inclusive)
+// and renders them the way leaf repair renders a summary's sources.
+func buildCompactPlan(ctx context.Context, q sqlQueryer, conversationID, startOrdinal, endOrdinal int64) (compactPlan, error) {
+	if startOrdinal > endOrdinal {
+		startOrdinal, endOrdinal = endOrdinal, startOrdinal
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT ci.ordinal, ci.item_type, COALESCE(ci.message_id, 0),
+			COALESCE(m.role, ''), COALESCE(m.token_count, 0), COALESCE(m.content, ''),
+			m.message_id IS NOT NULL
+		FROM context_items ci
+		LEFT JOIN messages m ON m.message_id = ci.message_id
+		WHERE ci.conversation_id = ? AND ci.ordinal BETWEEN ? AND ?
+		ORDER BY ci.ordinal ASC
+	`, conversationID, startOrdinal, endOrdinal)
+	if err != nil {
+		return compactPlan{}, fmt.Errorf("query context items %d-%d: %w", startOrdinal, endOrdinal, err)
+	}
+	defer rows.Close()
+
+	plan := compactPlan{conversationID: conversationID, startOrdinal: startOrdinal, endOrdinal: endOrdinal}
+	for rows.Next() {
+		var (
+			msg      compactMessage
+			itemType string
+			found    bool
+		)
+		if err := rows.Scan(&msg.ordinal, &itemType, &msg.messageID, &msg.role, &msg.tokenCount, &msg.content, &found); err != nil {
+			return compactPlan{}, fmt.Errorf("scan context item: %w", err)
+		}
+		if itemType != "message" {
+			return compactPlan{}, fmt.Errorf("context ordinal %d is a summary; only messages can be compacted", msg.ordinal)
+		}
+		if !found {
+			return compactPlan{}, fmt.Errorf("context ordinal %d points at missing message %d", msg.ordinal, msg.messageID)
+		}
+		plan.messages = append(plan.messages, msg)
+		plan.inputTokens += msg.tokenCount
+	}
+	if err := rows.Err(); err != nil {
+		return compactPlan{}, fmt.Errorf("iterate context items: %w", err)
+	}
+	if len(plan.messages) == 0 {
+		return compactPlan{}, fmt.Errorf("no context items between ordinals %d and %d in conversation %d", startOrdinal, endOrdinal, conversationID)
+	}
+
+	plan.source, err = buildContextMessageSource(ctx, q, conversationID, startOrdinal, endOrdinal)
+	if err != nil {
+		return compactPlan{}, err
+	}
+	plan.targetTokens = min(calculateLeafTargetTokens(plan.source.estimatedTokens), plan.inputTokens)
+
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM context_items
+		WHERE conversation_id = ? AND ordinal > ?
+	`, conversationID, endOrdinal).Scan(&plan.itemsToShift); err != nil {
+		return compactPlan{}, fmt.Errorf("count items to shift: %w", err)
+	}
+	return plan, nil
+}
+
+// buildContextMessageSource renders the messages in a context range with
+// their parts, like buildLeafRepairSource does for a summary's messages.
+func buildContextMessageSource(ctx context.Context, q sqlQueryer, conversationID, startOrdinal, endOrdinal int64) (repairSource, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT
+			m.message_id,
+			m.role,
+			m.content,
+			mp.ordinal,
+			mp.part_type,
+			mp.text_content
+		FROM context_items ci
+		JOIN messages m ON m.message_id = ci.message_id
+		LEFT JOIN message_parts mp
+			ON mp.message_id = m.message_id
+			AND COALESCE(mp.is_ignored, 0) = 0
+		WHERE ci.conversation_id = ? AND ci.ordinal BETWEEN ? AND ?
+		ORDER BY ci.ordinal ASC, mp.ordinal ASC
+	`, conversationID, startOrdinal, endOrdinal)
+	if err != nil {
+		return repairSource{}, fmt.Errorf("query messages at ordinals %d-%d: %w", startOrdinal, endOrdinal, err)
+	}
+	defer rows.Close()
+
+	lines, err := renderSourceMessages(rows)
+	if err != nil {
+		return repairSource{}, err
+	}
+	if len(lines) == 0 {
+		return repairSource{}, fmt.Errorf("no messages at context ordinals %d-%d", startOrdinal, endOrdinal)
+	}
+	text := strings.Join(lines, "\n")
+	return repairSource{
+		text:            text,
+		itemCount:       len(lines),
+		estimatedTokens: estimateTokenCount(text),
+		label:           "messages",
+	}, nil
+}
+
+func printCompactPlan(plan compactPlan) {
+	fmt.Printf("Compact %d messages at context ordinals %d-%d of conversation %d into a new leaf summary\n",
+		len(plan.messages), plan.startOrdinal, plan.endOrdinal, plan.conversationID)
+	for _, msg := range plan.messages {
+		fmt.Printf("  %4d  msg %-6d %-9s %5dt  %s\n", msg.ordinal, msg.messageID, msg.role, msg.tokenCount, previewForLog(msg.content, 60))
+	}
+	fmt.Printf("\nToken impact: %dt of messages -> ~%dt leaf target (saves ~%dt)\n", plan.inputTokens, plan.targetTokens, plan.estimatedSavings())
+	fmt.Printf("Ordinal shift: %d items after ordinal %d will shift by -%d\n", plan.itemsToShift, plan.endOrdinal, plan.endOrdinal-plan.startOrdinal)
+}
+
+// generateCompactedLeaf runs the leaf prompt over the plan's messages.
+// Nothing is written.
+func generateCompactedLeaf(ctx context.Context, q sqlQueryer, plan compactPlan, client summarizer, prompts *repairPrompts, tokens tokenCounter, maxRetries int) (string, int, error) {
+	previousContext, err := resolveLeafPreviousContext(ctx, q, repairSummary{
+		conversationID:    plan.conversationID,
+		contextOrdinal:    plan.startOrdinal,
+		hasContextOrdinal: true,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	req, err := prompts.build("leaf", plan.source.text, previousContext, plan.source.estimatedTokens)
+	if err != nil {
+		return "", 0, err
+	}
+	content, err := summarizeWithRetry(ctx, client, req, maxRetries, func(string, ...any) {})
+	if err != nil {
+		return "", 0, fmt.Errorf("summarize: %w", err)
+	}
+	return content, tokens.countTokens(content), nil
+}
+
+// applyCompact inserts the leaf summary and its summary_messages links and
+// swaps it in for the message range in one journaled transaction. It
+// refuses to run if the range changed since the plan was built.
+func applyCompact(ctx context.Context, db *sql.DB, plan compactPlan, content string, tokenCount int) (string, int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("begin compact transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := buildCompactPlan(ctx, tx, plan.conversationID, plan.startOrdinal, plan.endOrdinal)
+	if err != nil {
+		return "", 0, fmt.Errorf("context changed since preview: %w", err)
+	}
+	if !sameCompactMessages(current.messages, plan.messages) {
+		return "", 0, fmt.Errorf("context ordinals %d-%d changed since preview; reload and try again", plan.startOrdinal, plan.endOrdinal)
+	}
+
+	newSummaryID, err := generateSummaryID(ctx, tx)
+	if err != nil {
+		return "", 0, err
+	}
+	description := fmt.Sprintf("compact %d messages at ordinals %d-%d into %s", len(plan.messages), plan.startOrdinal, plan.endOrdinal, newSummaryID)
+	j, err := startJournalOp(ctx, tx, "compact", plan.conversationID, description)
+	if err != nil {
+		return "", 0, err
+	}
+	contextBefore, err := captureContext(ctx, tx, plan.conversationID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO summaries (summary_id, conversation_id, kind, depth, content, token_count, created_at, file_ids)
+		VALUES (?, ?, 'leaf', 0, ?, ?, datetime('now'), '[]')
+	`, newSummaryID, plan.conversationID, content, tokenCount); err != nil {
+		return "", 0, fmt.Errorf("insert leaf summary %s: %w", newSummaryID, err)
+	}
+	for i, msg := range plan.messages {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO summary_messages (summary_id, message_id, ordinal)
+			VALUES (?, ?, ?)
+		`, newSummaryID, msg.messageID, i); err != nil {
+			return "", 0, fmt.Errorf("link %s to message %d: %w", newSummaryID, msg.messageID, err)
+		}
+	}
+	for _, table := range []string{"summaries", "summary_messages"} {
+		if err := j.recordInsert(ctx, table, "summary_id = ?", newSummaryID); err != nil {
+			return "", 0, err
+		}
+	}
+
+	if err := replaceContextRange(ctx, tx, plan.conversationID, plan.startOrdinal, plan.endOrdinal, newSummaryID); err != nil {
+		return "", 0, err
+	}
+	if err := j.recordContext(ctx, plan.conversationID, contextBefore); err != nil {
+		return "", 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", 0, fmt.Errorf("commit compact: %w", err)
+	}
+	rollback = false
+	return newSummaryID, j.opID, nil
+}
+
+func sameCompactMessages(a, b []compactMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].messageID != b[i].messageID || a[i].ordinal != b[i].ordinal {
+			return false
+		}
+	}
+	return true
+}
+
+func generateCompactCmd(ctx context.Context, seq int, db *sql.DB, plan compactPlan, client summarizer, prompts *repairPrompts, tokens tokenCounter) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return compactCandidateMsg{seq: seq, err: errLCMDBUnavailable}
+		}
+		content, count, err := generateCompactedLeaf(ctx, db, plan, client, prompts, tokens, defaultRepairRetries)
+		return compactCandidateMsg{seq: seq, content: content, tokens: count, err: err}
+	}
+}
+
+// startPendingCompact builds the dry-run compaction preview for the marked
+// range of messages.
+func (m *model) startPendingCompact() {
+	conversationID, ok := m.currentConversationID()
+	if !ok {
+		m.status = "Missing conversation ID for current session"
+		return
+	}
+	if m.db == nil {
+		m.status = "Error: " + errLCMDBUnavailable.Error()
+		return
+	}
+	lo, hi := m.contextMarkRange()
+	plan, err := buildCompactPlan(context.Background(), m.db, conversationID, int64(m.contextItems[lo].ordinal), int64(m.contextItems[hi].ordinal))
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return
+	}
+	m.pendingCompact = &compactReview{plan: plan}
+	m.status = fmt.Sprintf("Compact %d messages: ~%dt saved if the leaf hits its %dt target", len(plan.messages), plan.estimatedSavings(), plan.targetTokens)
+}
+
+func (m model) handleCompactKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	review := m.pendingCompact
+	switch msg.String() {
+	case "g", "enter":
+		if err := m.ensureSummarizer(); err != nil {
+			m.status = "Error: " + err.Error()
+			return m, nil
+		}
+		ctx, seq := m.beginLoad(fmt.Sprintf("Compacting %d messages with %s...", len(review.plan.messages), m.repairClient.describe()))
+		return m, m.startLoad(generateCompactCmd(ctx, seq, m.db, review.plan, m.repairClient, m.repairPrompts, m.repairTokens))
+	case "a", "y":
+		if !review.generated {
+			m.status = "Generate the leaf with g before writing"
+			return m, nil
+		}
+		return m, m.confirmPendingCompact()
+	case "n", "esc", "b", "backspace":
+		m.cancelLoad()
+		m.pendingCompact = nil
+		m.status = "Compaction canceled; nothing written"
+	}
+	return m, nil
+}
+
+func (m model) handleCompactCandidate(msg compactCandidateMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) || m.pendingCompact == nil {
+		return m, nil
+	}
+	review := m.pendingCompact
+	if msg.err != nil {
+		review.err = msg.err.Error()
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+	review.content = msg.content
+	review.tokens = msg.tokens
+	review.generated = true
+	review.err = ""
+	m.status = fmt.Sprintf("Generated %dt leaf (%+dt). Press a to write it", msg.tokens, msg.tokens-review.plan.inputTokens)
+	return m, nil
+}
+
+// confirmPendingCompact snapshots lcm.db and writes the generated leaf in
+// the background, then reloads the context with the cursor on it.
+func (m *model) confirmPendingCompact() tea.Cmd {
+	review := *m.pendingCompact
+	m.pendingCompact = nil
+
+	reload := ""
+	if session, ok := m.currentSession(); ok {
+		reload = session.id
+	}
+	lo, _ := m.contextMarkRange()
+	dbPath, backupsDir := m.paths.lcmDBPath, m.paths.backupsDir
+
+	_, seq := m.beginLoad("Writing compacted leaf...")
+	return m.startLoad(lcmWriteCmd(seq, reload, lo, func(ctx context.Context) (string, error) {
+		db, err := openLCMDB(dbPath, dbReadWrite)
+		if err != nil {
+			return "", err
+		}
+		defer db.Close()
+
+		plan := review.plan
+		label := fmt.Sprintf("tui-compact-conv%d-%d-%d", plan.conversationID, plan.startOrdinal, plan.endOrdinal)
+		backup, err := snapshotLCMDB(ctx, db, backupsDir, label)
+		if err != nil {
+			return "", fmt.Errorf("backup before compact: %w", err)
+		}
+		summaryID, opID, err := applyCompact(ctx, db, plan, review.content, review.tokens)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Compacted %d messages into %s (%dt -> %dt, %+dt). Backup: %s. Undo with: lcm-tui undo %d",
+			len(plan.messages), summaryID, plan.inputTokens, review.tokens, review.tokens-plan.inputTokens, backup.name, opID), nil
+	}))
+}
+
+// renderCompactConfirmation draws the dry-run preview and, once generated,
+// the new leaf.
+func (m model) renderCompactConfirmation() string {
+	review := m.pendingCompact
+	plan := review.plan
+	savingsPct := 0.0
+	if plan.inputTokens > 0 {
+		savingsPct = float64(plan.estimatedSavings()) / float64(plan.inputTokens) * 100
+	}
+	lines := []string{
+		fmt.Sprintf("Compact %d messages at context ordinals %d-%d into a new leaf summary", len(plan.messages), plan.startOrdinal, plan.endOrdinal),
+		fmt.Sprintf("Dry run: %dt -> ~%dt target, saving ~%dt (%.0f%%)", plan.inputTokens, plan.targetTokens, plan.estimatedSavings(), savingsPct),
+		fmt.Sprintf("Ordinal shift: %d item(s) after ordinal %d will shift by -%d", plan.itemsToShift, plan.endOrdinal, plan.endOrdinal-plan.startOrdinal),
+	}
+	if pinned := m.pinnedInRange(m.contextMarkRange()); pinned > 0 {
+		lines = append(lines, fmt.Sprintf("Pinned: %d item(s) in the range; doctor will report their pins afterwards", pinned))
+	}
+	lines = append(lines, "", "Messages to compact:")
+
+	available := max(10, m.height-4)
+	maxItemLines := max(1, min(len(plan.messages), available/3))
+	for _, msg := range plan.messages[:maxItemLines] {
+		preview := truncateString(oneLine(msg.content), max(8, m.width-36))
+		lines = append(lines, fmt.Sprintf("  %3d  msg %d (%s, %dt) %s", msg.ordinal, msg.messageID, msg.role, msg.tokenCount, preview))
+	}
+	if hidden := len(plan.messages) - maxItemLines; hidden > 0 {
+		lines = append(lines, fmt.Sprintf("  ... and %d more", hidden))
+	}
+	lines = append(lines, "")
+
+	switch {
+	case review.err != "":
+		lines = append(lines, "Error: "+review.err)
+	case !review.generated:
+		lines = append(lines, "Press g or Enter to generate the leaf. Nothing is written until you press a.")
+	default:
+		lines = append(lines, fmt.Sprintf("Generated leaf (%dt, %+dt):", review.tokens, review.tokens-plan.inputTokens))
+		for _, line := range strings.Split(wrapText(review.content, max(20, m.width-4)), "\n") {
+			if len(lines) >= available {
+				break
+			}
+			lines = append(lines, "  "+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// condenseItem is one summary in the context range being condensed.
+type condenseItem struct {
+	summaryID  string
+	ordinal    int64
+	kind       string
+	depth      int
+	tokenCount int
+	content    string
+	fileIDs    string
+}
+
+// condensePlan replaces a contiguous run of summary context items with one
+// new condensed summary whose summary_parents are those items. It is the
+// inverse of a dissolve.
+type condensePlan struct {
+	conversationID int64
+	startOrdinal   int64
+	endOrdinal     int64
+	items          []condenseItem
+	depth          int // max item depth + 1
+	inputTokens    int
+	targetTokens   int
+	itemsToShift   int
+}
+
+// estimatedSavings is the token reduction if the summarizer hits its target.
+func (p condensePlan) estimatedSavings() int {
+	return p.inputTokens - p.targetTokens
+}
+
+// condenseReview is the TUI's pending condense: the dry-run plan, then the
+// generated summary once the summarizer has run.
+type condenseReview struct {
+	plan      condensePlan
+	content   string
+	tokens    int
+	generated bool
+	err       string
+}
+
+// condenseCandidateMsg carries a generated condensed summary.
+type condenseCandidateMsg struct {
+	seq     int
+	content string
+	tokens  int
+	err     error
+}
+
+// buildCondensePlan loads the context items between two ordinals (inclusive)
+// and checks that they are at least two summaries with no messages between.
+func buildCondensePlan(ctx context.Context, q sqlQueryer, conversationID, startOrdinal, endOrdinal int64) (condensePlan, error) {
+	if startOrdinal > endOrdinal {
+		startOrdinal, endOrdinal = endOrdinal, startOrdinal
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT ci.ordinal, ci.item_type, COALESCE(ci.summary_id, ''),
+			COALESCE(s.kind, ''), COALESCE(s.depth, 0), COALESCE(s.token_count, 0),
+			COALESCE(s.content, ''), COALESCE(s.file_ids, '[]'), s.summary_id IS NOT NULL
+		FROM context_items ci
+		LEFT JOIN summaries s ON s.summary_id = ci.summary_id
+		WHERE ci.conversation_id = ? AND ci.ordinal BETWEEN ? AND ?
+		ORDER BY ci.ordinal ASC
+	`, conversationID, startOrdinal, endOrdinal)
+	if err != nil {
+		return condensePlan{}, fmt.Errorf("query context items %d-%d: %w", startOrdinal, endOrdinal, err)
+	}
+	defer rows.Close()
+
+	plan := condensePlan{conversationID: conversationID, startOrdinal: startOrdinal, endOrdinal: endOrdinal}
+	for rows.Next() {
+		var (
+			item     condenseItem
+			itemType string
+			found    bool
+		)
+		if err := rows.Scan(&item.ordinal, &itemType, &item.summaryID, &item.kind, &item.depth, &item.tokenCount, &item.content, &item.fileIDs, &found); err != nil {
+			return condensePlan{}, fmt.Errorf("scan context item: %w", err)
+		}
+		if itemType != "summary" {
+			return condensePlan{}, fmt.Errorf("context ordinal %d is a message; only summaries can be condensed", item.ordinal)
+		}
+		if !found {
+			return condensePlan{}, fmt.Errorf("context ordinal %d points at missing summary %s", item.ordinal, item.summaryID)
+		}
+		plan.items = append(plan.items, item)
+		plan.inputTokens += item.tokenCount
+		plan.depth = max(plan.depth, item.depth+1)
+	}
+	if err := rows.Err(); err != nil {
+		return condensePlan{}, fmt.Errorf("iterate context items: %w", err)
+	}
+	if len(plan.items) < 2 {
+		return condensePlan{}, fmt.Errorf("select at least two summaries to condense (found %d between ordinals %d and %d)", len(plan.items), startOrdinal, endOrdinal)
+	}
+	plan.targetTokens = min(condensedTargetTokens, plan.inputTokens)
+
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM context_items
+		WHERE conversation_id = ? AND ordinal > ?
+	`, conversationID, endOrdinal).Scan(&plan.itemsToShift); err != nil {
+		return condensePlan{}, fmt.Errorf("count items to shift: %w", err)
+	}
+	return plan, nil
+}
+
+// generateCondensedSummary renders the condensed prompt over the selected
+// summaries and runs the summarizer. Nothing is written.
+func generateCondensedSummary(ctx context.Context, q sqlQueryer, plan condensePlan, client summarizer, prompts *repairPrompts, tokens tokenCounter) (string, int, error) {
+	parts := make([]string, 0, len(plan.items))
+	for _, item := range plan.items {
+		if content := strings.TrimSpace(item.content); content != "" {
+			parts = append(parts, content)
+		}
+	}
+	if len(parts) == 0 {
+		return "", 0, fmt.Errorf("selected summaries are all empty")
+	}
+	text := strings.Join(parts, "\n\n")
+
+	// The new node sorts after every existing summary at its depth, so the
+	// newest of those is its previous context.
+	previousContext, err := resolveCondensedPreviousContext(ctx, q, repairSummary{
+		conversationID: plan.conversationID,
+		depth:          plan.depth,
+		createdAt:      time.Now().UTC().Format("2006-01-02 15:04:05"),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	req, err := prompts.build("condensed", text, previousContext, estimateTokenCount(text))
+	if err != nil {
+		return "", 0, err
+	}
+	content, err := summarizeWithRetry(ctx, client, req, defaultRepairRetries, func(string, ...any) {})
+	if err != nil {
+		return "", 0, fmt.Errorf("summarize: %w", err)
+	}
+	return content, tokens.countTokens(content), nil
+}
+
+// applyCondense inserts the condensed summary and its summary_parents edges
+// and swaps it in for the range, all in one journaled transaction. It
+// refuses to run if the range changed since the plan was built.
+func applyCondense(ctx context.Context, db *sql.DB, plan condensePlan, content string, tokenCount int) (string, int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("begin condense transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	current, err := buildCondensePlan(ctx, tx, plan.conversationID, plan.startOrdinal, plan.endOrdinal)
+	if err != nil {
+		return "", 0, fmt.Errorf("context changed since preview: %w", err)
+	}
+	if !sameCondenseItems(current.items, plan.items) {
+		return "", 0, fmt.Errorf("context ordinals %d-%d changed since preview; reload and try again", plan.startOrdinal, plan.endOrdinal)
+	}
+
+	newSummaryID, err := generateSummaryID(ctx, tx)
+	if err != nil {
+		return "", 0, err
+	}
+	description := fmt.Sprintf("condense %d summaries at ordinals %d-%d into %s", len(plan.items), plan.startOrdinal, plan.endOrdinal, newSummaryID)
+	j, err := startJournalOp(ctx, tx, "condense", plan.conversationID, description)
+	if err != nil {
+		return "", 0, err
+	}
+	contextBefore, err := captureContext(ctx, tx, plan.conversationID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	fileIDs, err := mergeSummaryFileIDs(plan.items)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO summaries (summary_id, conversation_id, kind, depth, content, token_count, created_at, file_ids)
+		VALUES (?, ?, 'condensed', ?, ?, ?, datetime('now'), ?)
+	`, newSummaryID, plan.conversationID, plan.depth, content, tokenCount, fileIDs); err != nil {
+		return "", 0, fmt.Errorf("insert condensed summary %s: %w", newSummaryID, err)
+	}
+	for i, item := range plan.items {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO summary_parents (summary_id, parent_summary_id, ordinal)
+			VALUES (?, ?, ?)
+		`, newSummaryID, item.summaryID, i); err != nil {
+			return "", 0, fmt.Errorf("insert summary_parents edge %s -> %s: %w", newSummaryID, item.summaryID, err)
+		}
+	}
+	for _, table := range []string{"summaries", "summary_parents"} {
+		if err := j.recordInsert(ctx, table, "summary_id = ?", newSummaryID); err != nil {
+			return "", 0, err
+		}
+	}
+
+	if err := replaceContextRange(ctx, tx, plan.conversationID, plan.startOrdinal, plan.endOrdinal, newSummaryID); err != nil {
+		return "", 0, err
+	}
+	if err := j.recordContext(ctx, plan.conversationID, contextBefore); err != nil {
+		return "", 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", 0, fmt.Errorf("commit condense: %w", err)
+	}
+	rollback = false
+	return newSummaryID, j.opID, nil
+}
+
+// replaceContextRange deletes the context items between two ordinals and
+// puts one summary item at the first ordinal, closing the gap with the same
+// temp-offset shift applyDissolvePlan uses to avoid ordinal collisions.
+func replaceContextRange(ctx context.Context, q sqlQueryer, conversationID, startOrdinal, endOrdinal int64, summaryID string) error {
+	if _, err := q.ExecContext(ctx, `
+		DELETE FROM context_items
+		WHERE conversation_id = ? AND ordinal BETWEEN ? AND ?
+	`, conversationID, startOrdinal, endOrdinal); err != nil {
+		return fmt.Errorf("delete context items %d-%d: %w", startOrdinal, endOrdinal, err)
+	}
+
+	if shift := endOrdinal - startOrdinal; shift > 0 {
+		if err := shiftContextOrdinals(ctx, q, conversationID, endOrdinal, shift); err != nil {
+			return err
+		}
+	}
+
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO context_items (conversation_id, ordinal, item_type, summary_id, created_at)
+		VALUES (?, ?, 'summary', ?, datetime('now'))
+	`, conversationID, startOrdinal, summaryID); err != nil {
+		return fmt.Errorf("insert %s at ordinal %d: %w", summaryID, startOrdinal, err)
+	}
+	return nil
+}
+
+func sameCondenseItems(a, b []condenseItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].summaryID != b[i].summaryID || a[i].ordinal != b[i].ordinal {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeSummaryFileIDs unions the children's file_ids JSON arrays in order.
+func mergeSummaryFileIDs(items []condenseItem) (string, error) {
+	merged := []string{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		raw := strings.TrimSpace(item.fileIDs)
+		if raw == "" {
+			continue
+		}
+		var ids []string
+		if err := json.Unmarshal([]byte(raw), &ids); err != nil {
+			return "", fmt.Errorf("parse file_ids of %s: %w", item.summaryID, err)
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				merged = append(merged, id)
+			}
+		}
+	}
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("encode file_ids: %w", err)
+	}
+	return string(encoded), nil
+}
+
+func generateCondenseCmd(ctx context.Context, seq int, db *sql.DB, plan condensePlan, client summarizer, prompts *repairPrompts, tokens tokenCounter) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return condenseCandidateMsg{seq: seq, err: errLCMDBUnavailable}
+		}
+		content, count, err := generateCondensedSummary(ctx, db, plan, client, prompts, tokens)
+		return condenseCandidateMsg{seq: seq, content: content, tokens: count, err: err}
+	}
+}
+
+// contextMarkRange returns the item index range between the mark and the
+// cursor, or just the cursor when nothing is marked.
+func (m model) contextMarkRange() (int, int) {
+	if m.contextMark < 0 || m.contextMark >= len(m.contextItems) {
+		return m.contextCursor, m.contextCursor
+	}
+	return min(m.contextMark, m.contextCursor), max(m.contextMark, m.contextCursor)
+}
+
+// startPendingCondense builds the dry-run condense preview for the marked
+// range.
+func (m *model) startPendingCondense() {
+	conversationID, ok := m.currentConversationID()
+	if !ok {
+		m.status = "Missing conversation ID for current session"
+		return
+	}
+	if m.db == nil {
+		m.status = "Error: " + errLCMDBUnavailable.Error()
+		return
+	}
+	lo, hi := m.contextMarkRange()
+	plan, err := buildCondensePlan(context.Background(), m.db, conversationID, int64(m.contextItems[lo].ordinal), int64(m.contextItems[hi].ordinal))
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return
+	}
+	m.pendingCondense = &condenseReview{plan: plan}
+	m.status = fmt.Sprintf("Condense %d summaries: ~%dt saved if the summary hits its %dt target", len(plan.items), plan.estimatedSavings(), plan.targetTokens)
+}
+
+func (m model) handleCondenseKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	review := m.pendingCondense
+	switch msg.String() {
+	case "g", "enter":
+		if err := m.ensureSummarizer(); err != nil {
+			m.status = "Error: " + err.Error()
+			return m, nil
+		}
+		ctx, seq := m.beginLoad(fmt.Sprintf("Condensing %d summaries with %s...", len(review.plan.items), m.repairClient.describe()))
+		return m, m.startLoad(generateCondenseCmd(ctx, seq, m.db, review.plan, m.repairClient, m.repairPrompts, m.repairTokens))
+	case "a", "y":
+		if !review.generated {
+			m.status = "Generate the summary with g before writing"
+			return m, nil
+		}
+		return m, m.confirmPendingCondense()
+	case "n", "esc", "b", "backspace":
+		m.cancelLoad()
+		m.pendingCondense = nil
+		m.status = "Condense canceled; nothing written"
+	}
+	return m, nil
+}
+
+func (m model) handleCondenseCandidate(msg condenseCandidateMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) || m.pendingCondense == nil {
+		return m, nil
+	}
+	review := m.pendingCondense
+	if msg.err != nil {
+		review.err = msg.err.Error()
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+	review.content = msg.content
+	review.tokens = msg.tokens
+	review.generated = true
+	review.err = ""
+	m.status = fmt.Sprintf("Generated %dt summary (%+dt). Press a to write it", msg.tokens, msg.tokens-review.plan.inputTokens)
+	return m, nil
+}
+
+// confirmPendingCondense snapshots lcm.db and writes the generated summary
+// in the background, then reloads the context with the cursor on the new
+// item.
+func (m *model) confirmPendingCondense() tea.Cmd {
+	review := *m.pendingCondense
+	m.pendingCondense = nil
+
+	reload := ""
+	if session, ok := m.currentSession(); ok {
+		reload = session.id
+	}
+	lo, _ := m.contextMarkRange()
+	dbPath, backupsDir := m.paths.lcmDBPath, m.paths.backupsDir
+
+	_, seq := m.beginLoad("Writing condensed summary...")
+	return m.startLoad(lcmWriteCmd(seq, reload, lo, func(ctx context.Context) (string, error) {
+		db, err := openLCMDB(dbPath, dbReadWrite)
+		if err != nil {
+			return "", err
+		}
+		defer db.Close()
+
+		plan := review.plan
+		label := fmt.Sprintf("tui-condense-conv%d-%d-%d", plan.conversationID, plan.startOrdinal, plan.endOrdinal)
+		backup, err := snapshotLCMDB(ctx, db, backupsDir, label)
+		if err != nil {
+			return "", fmt.Errorf("backup before condense: %w", err)
+		}
+		summaryID, opID, err := applyCondense(ctx, db, plan, review.content, review.tokens)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Condensed %d summaries into %s (%dt -> %dt, %+dt). Backup: %s. Undo with: lcm-tui undo %d",
+			len(plan.items), summaryID, plan.inputTokens, review.tokens, review.tokens-plan.inputTokens, backup.name, opID), nil
+	}))
+}
+
+// renderCondenseConfirmation draws the dry-run preview and, once generated,
+// the new summary.
+func (m model) renderCondenseConfirmation() string {
+	review := m.pendingCondense
+	plan := review.plan
+	savingsPct := 0.0
+	if plan.inputTokens > 0 {
+		savingsPct = float64(plan.estimatedSavings()) / float64(plan.inputTokens) * 100
+	}
+	lines := []string{
+		fmt.Sprintf("Condense %d summaries at context ordinals %d-%d into a new d%d summary", len(plan.items), plan.startOrdinal, plan.endOrdinal, plan.depth),
+		fmt.Sprintf("Dry run: %dt -> ~%dt target, saving ~%dt (%.0f%%)", plan.inputTokens, plan.targetTokens, plan.estimatedSavings(), savingsPct),
+		fmt.Sprintf("Ordinal shift: %d item(s) after ordinal %d will shift by -%d", plan.itemsToShift, plan.endOrdinal, plan.endOrdinal-plan.startOrdinal),
+	}
+	if pinned := m.pinnedInRange(m.contextMarkRange()); pinned > 0 {
+		lines = append(lines, fmt.Sprintf("Pinned: %d item(s) in the range; doctor will report their pins afterwards", pinned))
+	}
+	lines = append(lines, "", "Summaries to condense:")
+
+	available := max(10, m.height-4)
+	maxItemLines := max(1, min(len(plan.items), available/3))
+	for _, item := range plan.items[:maxItemLines] {
+		kindLabel := item.kind
+		if item.kind == "condensed" {
+			kindLabel = fmt.Sprintf("d%d", item.depth)
+		}
+		preview := truncateString(oneLine(item.content), max(8, m.width-44))
+		lines = append(lines, fmt.Sprintf("  %3d  %s (%s, %dt) %s", item.ordinal, item.summaryID, kindLabel, item.tokenCount, preview))
+	}
+	if hidden := len(plan.items) - maxItemLines; hidden > 0 {
+		lines = append(lines, fmt.Sprintf("  ... and %d more", hidden))
+	}
+	lines = append(lines, "")
+
+	switch {
+	case review.err != "":
+		lines = append(lines, "Error: "+review.err)
+	case !review.generated:
+		lines = append(lines, "Press g or Enter to generate the summary. Nothing is written until you press a.")
+	default:
+		lines = append(lines, fmt.Sprintf("Generated summary (%dt, %+dt):", review.tokens, review.tokens-plan.inputTokens))
+		for _, line := range strings.Split(wrapText(review.content, max(20, m.width-4)), "\n") {
+			if len(lines) >= available {
+				break
+			}
+			lines = append(lines, "  "+line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultContextLimit = 200000
+	// contextBudgetBarRows is how many terminal rows the budget bar wraps
+	// over; more rows give small items a visible cell.
+	contextBudgetBarRows = 3
+	// contextBudgetFlagShare flags items using at least this share of the
+	// context in use.
+	contextBudgetFlagShare = 0.10
+)
+
+// contextLimitPresets are the limits +/- step through in the budget view.
+var contextLimitPresets = []int{32000, 128000, 200000, 400000, 1000000}
+
+// contextBudgetCategory groups context items for the bar and its legend.
+type contextBudgetCategory struct {
+	key   string
+	label string
+	style lipgloss.Style
+}
+
+var contextBudgetCategories = []contextBudgetCategory{
+	{key: "leaf", label: "leaf", style: lipgloss.NewStyle().Foreground(lipgloss.Color("141"))},
+	{key: "d1", label: "d1", style: lipgloss.NewStyle().Foreground(lipgloss.Color("170"))},
+	{key: "d2", label: "d2", style: lipgloss.NewStyle().Foreground(lipgloss.Color("205"))},
+	{key: "d3+", label: "d3+", style: lipgloss.NewStyle().Foreground(lipgloss.Color("211"))},
+	{key: "user", label: "user", style: roleUserStyle},
+	{key: "assistant", label: "assistant", style: roleAssistantStyle},
+	{key: "system", label: "system", style: roleSystemStyle},
+	{key: "tool", label: "tool", style: roleToolStyle},
+}
+
+var (
+	budgetHeadroomStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	budgetSelectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("230"))
+	budgetOverStyle     = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196"))
+)
+
+// budgetCategoryKey maps an item to its contextBudgetCategories key.
+func budgetCategoryKey(item contextItemEntry) string {
+	if item.itemType != "summary" {
+		switch item.kind {
+		case "assistant", "system", "tool":
+			return item.kind
+		}
+		return "user"
+	}
+	switch {
+	case item.kind != "condensed" || item.depth <= 0:
+		return "leaf"
+	case item.depth == 1:
+		return "d1"
+	case item.depth == 2:
+		return "d2"
+	default:
+		return "d3+"
+	}
+}
+
+func budgetCategoryStyle(key string) lipgloss.Style {
+	for _, category := range contextBudgetCategories {
+		if category.key == key {
+			return category.style
+		}
+	}
+	return roleToolStyle
+}
+
+// contextLimitFromEnv reads LCM_TUI_CONTEXT_LIMIT, defaulting to 200k.
+func contextLimitFromEnv() (int, error) {
+	value := strings.TrimSpace(os.Getenv("LCM_TUI_CONTEXT_LIMIT"))
+	if value == "" {
+		return defaultContextLimit, nil
+	}
+	limit, err := parseTokenLimit(value)
+	if err != nil {
+		return defaultContextLimit, fmt.Errorf("LCM_TUI_CONTEXT_LIMIT: %w", err)
+	}
+	return limit, nil
+}
+
+// parseTokenLimit accepts plain token counts and k/m suffixes ("200k", "1m").
+func parseTokenLimit(value string) (int, error) {
+	raw := strings.ToLower(strings.TrimSpace(value))
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(raw, "k"):
+		multiplier, raw = 1000, strings.TrimSuffix(raw, "k")
+	case strings.HasSuffix(raw, "m"):
+		multiplier, raw = 1000000, strings.TrimSuffix(raw, "m")
+	}
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid token limit %q", value)
+	}
+	return int(n * float64(multiplier)), nil
+}
+
+// nextContextLimit steps to the next preset above (dir > 0) or below the
+// current limit, staying put at either end.
+func nextContextLimit(current, dir int) int {
+	if dir > 0 {
+		for _, preset := range contextLimitPresets {
+			if preset > current {
+				return preset
+			}
+		}
+		return current
+	}
+	for i := len(contextLimitPresets) - 1; i >= 0; i-- {
+		if contextLimitPresets[i] < current {
+			return contextLimitPresets[i]
+		}
+	}
+	return current
+}
+
+// contextBudgetRanking returns item indexes ordered largest first, ties by
+// ordinal.
+func contextBudgetRanking(items []contextItemEntry) []int {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return items[order[a]].tokenCount > items[order[b]].tokenCount
+	})
+	return order
+}
+
+// moveContextBudgetCursor moves the cursor through items in size order so
+// the largest-items list scrolls naturally.
+func (m *model) moveContextBudgetCursor(delta int) {
+	ranking := contextBudgetRanking(m.contextItems)
+	if len(ranking) == 0 {
+		return
+	}
+	rank := 0
+	for i, idx := range ranking {
+		if idx == m.contextCursor {
+			rank = i
+			break
+		}
+	}
+	m.contextCursor = ranking[clamp(rank+delta, 0, len(ranking)-1)]
+	m.contextDetailScroll = 0
+}
+
+func contextTokenTotal(items []contextItemEntry) int {
+	total := 0
+	for _, item := range items {
+		total += item.tokenCount
+	}
+	return total
+}
+
+func (m model) renderContextBudget() string {
+	if len(m.contextItems) == 0 {
+		return "No context items found for this session"
+	}
+	limit := max(1, m.contextLimit)
+	total := contextTokenTotal(m.contextItems)
+	width := max(20, m.width-4)
+
+	lines := []string{m.renderContextBudgetTotals(total, limit), ""}
+	lines = append(lines, m.renderContextBudgetBar(total, limit, width)...)
+	lines = append(lines, renderContextBudgetLegend(m.contextItems, total, width)...)
+	lines = append(lines, "")
+
+	available := max(4, m.height-4)
+	listHeight := max(3, available-len(lines)-1)
+	lines = append(lines, helpStyle.Render(fmt.Sprintf("Largest items (%s marks %.0f%%+ of context in use):", budgetOverStyle.Render("!"), contextBudgetFlagShare*100)))
+	ranking := contextBudgetRanking(m.contextItems)
+	rank := 0
+	for i, idx := range ranking {
+		if idx == m.contextCursor {
+			rank = i
+			break
+		}
+	}
+	offset := listOffset(rank, len(ranking), listHeight)
+	for i := offset; i < min(len(ranking), offset+listHeight); i++ {
+		idx := ranking[i]
+		item := m.contextItems[idx]
+		share := 0.0
+		if total > 0 {
+			share = float64(item.tokenCount) / float64(total)
+		}
+		flag := " "
+		if share >= contextBudgetFlagShare {
+			flag = budgetOverStyle.Render("!")
+		}
+		swatch := budgetCategoryStyle(budgetCategoryKey(item)).Render("█")
+		line := fmt.Sprintf("%s %s %5.1f%% %s", flag, swatch, share*100, strings.TrimLeft(m.formatContextItemLine(item), " "))
+		if idx == m.contextCursor {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(padLines(lines, available), "\n")
+}
+
+func (m model) renderContextBudgetTotals(total, limit int) string {
+	used := float64(total) / float64(limit) * 100
+	line := fmt.Sprintf("Used %s of %s tokens (%.1f%%)", formatTokenCount(total), formatTokenCount(limit), used)
+	headroom := limit - total
+	if headroom < 0 {
+		return line + " | " + budgetOverStyle.Render(fmt.Sprintf("over limit by %s", formatTokenCount(-headroom)))
+	}
+	return line + fmt.Sprintf(" | headroom %s (%.1f%%)", formatTokenCount(headroom), 100-used)
+}
+
+// renderContextBudgetBar draws items in ordinal order as a proportional bar
+// wrapped over contextBudgetBarRows rows. The scale is the limit, or the
+// total when the context overflows it, in which case the limit is marked.
+func (m model) renderContextBudgetBar(total, limit, width int) []string {
+	cells := width * contextBudgetBarRows
+	scale := max(total, limit)
+	bar := make([]string, cells)
+	for i := range bar {
+		bar[i] = budgetHeadroomStyle.Render("░")
+	}
+
+	cumulative := 0
+	for idx, item := range m.contextItems {
+		start := cumulative * cells / scale
+		cumulative += item.tokenCount
+		end := cumulative * cells / scale
+		style := budgetCategoryStyle(budgetCategoryKey(item))
+		char := "█"
+		if idx == m.contextCursor {
+			style, char = budgetSelectedStyle, "▓"
+		}
+		for c := start; c < min(end, cells); c++ {
+			bar[c] = style.Render(char)
+		}
+	}
+	if total > limit {
+		if mark := limit * cells / scale; mark < cells {
+			bar[mark] = budgetOverStyle.Render("|")
+		}
+	}
+
+	rows := make([]string, 0, contextBudgetBarRows)
+	for r := 0; r < contextBudgetBarRows; r++ {
+		rows = append(rows, "  "+strings.Join(bar[r*width:(r+1)*width], ""))
+	}
+	return rows
+}
+
+// renderContextBudgetLegend lists each category present with its token
+// total and share, wrapped to width.
+func renderContextBudgetLegend(items []contextItemEntry, total, width int) []string {
+	tokens := map[string]int{}
+	counts := map[string]int{}
+	for _, item := range items {
+		key := budgetCategoryKey(item)
+		tokens[key] += item.tokenCount
+		counts[key]++
+	}
+
+	var lines []string
+	line, lineWidth := " ", 1
+	for _, category := range contextBudgetCategories {
+		if counts[category.key] == 0 {
+			continue
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(tokens[category.key]) / float64(total) * 100
+		}
+		text := fmt.Sprintf("%s x%d %s (%.0f%%)", category.label, counts[category.key], formatTokenCount(tokens[category.key]), share)
+		entryWidth := len(text) + 3
+		if lineWidth+entryWidth > width && lineWidth > 1 {
+			lines = append(lines, line)
+			line, lineWidth = " ", 1
+		}
+		line += " " + category.style.Render("█") + " " + text
+		lineWidth += entryWidth
+	}
+	return append(lines, line)
+}
+
+// formatTokenCount renders counts compactly: 950, 12.3k, 200k, 1.2M.
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1000000:
+		return compactFloat(float64(n)/1000000) + "M"
+	case n >= 1000:
+		return compactFloat(float64(n)/1000) + "k"
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+func compactFloat(f float64) string {
+	return strings.TrimSuffix(strconv.FormatFloat(f, 'f', 1, 64), ".0")
+}
@@ -0,0 +1,476 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// contextPinsTable is the sidecar table recording pinned context items. LCM
+// itself never reads it; doctor flags pins whose item has left the context.
+const contextPinsTable = "lcm_tui_pins"
+
+// Context edit kinds. They double as journal op kinds.
+const (
+	contextEditReorder = "reorder"
+	contextEditDrop    = "drop"
+	contextEditPin     = "pin"
+	contextEditUnpin   = "unpin"
+)
+
+// contextEdit is one previewed change to a conversation's context window.
+type contextEdit struct {
+	kind           string
+	conversationID int64
+	item           contextItemEntry
+	// other is the neighbour a reorder swaps with.
+	other contextItemEntry
+	// itemsToShift counts the items after a dropped item that move up.
+	itemsToShift int
+	totalTokens  int
+}
+
+func (e contextEdit) describe() string {
+	switch e.kind {
+	case contextEditReorder:
+		return fmt.Sprintf("move %s from ordinal %d to %d", contextItemLabel(e.item), e.item.ordinal, e.other.ordinal)
+	case contextEditDrop:
+		return fmt.Sprintf("drop %s at ordinal %d from context", contextItemLabel(e.item), e.item.ordinal)
+	case contextEditPin:
+		return fmt.Sprintf("pin %s at ordinal %d", contextItemLabel(e.item), e.item.ordinal)
+	default:
+		return fmt.Sprintf("unpin %s at ordinal %d", contextItemLabel(e.item), e.item.ordinal)
+	}
+}
+
+func contextItemLabel(item contextItemEntry) string {
+	if item.itemType == "summary" {
+		return item.summaryID
+	}
+	return fmt.Sprintf("message #%d", item.messageID)
+}
+
+func contextPinKey(itemType, summaryID string, messageID int64) string {
+	if itemType == "summary" {
+		return "summary:" + summaryID
+	}
+	return fmt.Sprintf("message:%d", messageID)
+}
+
+func ensureContextPinsTable(ctx context.Context, q sqlQueryer) error {
+	if _, err := q.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+contextPinsTable+` (
+			pin_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			conversation_id INTEGER NOT NULL,
+			item_type TEXT NOT NULL,
+			summary_id TEXT,
+			message_id INTEGER,
+			pinned_at TEXT NOT NULL DEFAULT (datetime('now'))
+		)
+	`); err != nil {
+		return fmt.Errorf("create %s: %w", contextPinsTable, err)
+	}
+	return nil
+}
+
+func contextPinsTableExists(ctx context.Context, q sqlQueryer) (bool, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?
+	`, contextPinsTable).Scan(&count); err != nil {
+		return false, fmt.Errorf("check %s table: %w", contextPinsTable, err)
+	}
+	return count == 1, nil
+}
+
+// contextPin is one row of the pins table.
+type contextPin struct {
+	pinID     int64
+	itemType  string
+	summaryID sql.NullString
+	messageID sql.NullInt64
+	pinnedAt  string
+}
+
+func (p contextPin) key() string {
+	return contextPinKey(p.itemType, p.summaryID.String, p.messageID.Int64)
+}
+
+// loadContextPins returns a conversation's pins, or none when nothing has
+// been pinned yet and the table does not exist.
+func loadContextPins(ctx context.Context, q sqlQueryer, conversationID int64) ([]contextPin, error) {
+	exists, err := contextPinsTableExists(ctx, q)
+	if err != nil || !exists {
+		return nil, err
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT pin_id, item_type, summary_id, message_id, pinned_at
+		FROM `+contextPinsTable+`
+		WHERE conversation_id = ?
+		ORDER BY pin_id ASC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query pins for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	var pins []contextPin
+	for rows.Next() {
+		var pin contextPin
+		if err := rows.Scan(&pin.pinID, &pin.itemType, &pin.summaryID, &pin.messageID, &pin.pinnedAt); err != nil {
+			return nil, fmt.Errorf("scan pin: %w", err)
+		}
+		pins = append(pins, pin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pins: %w", err)
+	}
+	return pins, nil
+}
+
+// findContextPin returns the pin ID for an item, or 0 when it is not pinned.
+func findContextPin(ctx context.Context, q sqlQueryer, conversationID int64, item contextItemEntry) (int64, error) {
+	pins, err := loadContextPins(ctx, q, conversationID)
+	if err != nil {
+		return 0, err
+	}
+	key := contextPinKey(item.itemType, item.summaryID, item.messageID)
+	for _, pin := range pins {
+		if pin.key() == key {
+			return pin.pinID, nil
+		}
+	}
+	return 0, nil
+}
+
+// checkContextItemAt confirms the item is still at its ordinal, so an edit
+// previewed against a stale list is refused instead of hitting another item.
+func checkContextItemAt(ctx context.Context, q sqlQueryer, conversationID int64, item contextItemEntry) error {
+	var itemType string
+	var summaryID sql.NullString
+	var messageID sql.NullInt64
+	err := q.QueryRowContext(ctx, `
+		SELECT item_type, summary_id, message_id
+		FROM context_items
+		WHERE conversation_id = ? AND ordinal = ?
+	`, conversationID, item.ordinal).Scan(&itemType, &summaryID, &messageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("context ordinal %d is gone; reload and try again", item.ordinal)
+	}
+	if err != nil {
+		return fmt.Errorf("load context ordinal %d: %w", item.ordinal, err)
+	}
+	if contextPinKey(itemType, summaryID.String, messageID.Int64) != contextPinKey(item.itemType, item.summaryID, item.messageID) {
+		return fmt.Errorf("context ordinal %d changed since preview; reload and try again", item.ordinal)
+	}
+	return nil
+}
+
+// applyContextEdit writes one previewed edit in a journaled transaction and
+// returns the journal op ID.
+func applyContextEdit(ctx context.Context, db *sql.DB, edit contextEdit) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin %s transaction: %w", edit.kind, err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err := checkContextItemAt(ctx, tx, edit.conversationID, edit.item); err != nil {
+		return 0, err
+	}
+	if edit.kind == contextEditReorder {
+		if err := checkContextItemAt(ctx, tx, edit.conversationID, edit.other); err != nil {
+			return 0, err
+		}
+	}
+	pinID, err := findContextPin(ctx, tx, edit.conversationID, edit.item)
+	if err != nil {
+		return 0, err
+	}
+
+	j, err := startJournalOp(ctx, tx, edit.kind, edit.conversationID, edit.describe())
+	if err != nil {
+		return 0, err
+	}
+	switch edit.kind {
+	case contextEditReorder, contextEditDrop:
+		if edit.kind == contextEditDrop && pinID != 0 {
+			return 0, fmt.Errorf("%s is pinned; unpin it before dropping", contextItemLabel(edit.item))
+		}
+		contextBefore, err := captureContext(ctx, tx, edit.conversationID)
+		if err != nil {
+			return 0, err
+		}
+		if edit.kind == contextEditReorder {
+			err = swapContextOrdinals(ctx, tx, edit.conversationID, int64(edit.item.ordinal), int64(edit.other.ordinal))
+		} else {
+			err = removeContextOrdinal(ctx, tx, edit.conversationID, int64(edit.item.ordinal))
+		}
+		if err != nil {
+			return 0, err
+		}
+		if err := j.recordContext(ctx, edit.conversationID, contextBefore); err != nil {
+			return 0, err
+		}
+	case contextEditPin:
+		if pinID != 0 {
+			return 0, fmt.Errorf("%s is already pinned", contextItemLabel(edit.item))
+		}
+		if err := ensureContextPinsTable(ctx, tx); err != nil {
+			return 0, err
+		}
+		var summaryID, messageID any
+		if edit.item.itemType == "summary" {
+			summaryID = edit.item.summaryID
+		} else {
+			messageID = edit.item.messageID
+		}
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO `+contextPinsTable+` (conversation_id, item_type, summary_id, message_id)
+			VALUES (?, ?, ?, ?)
+		`, edit.conversationID, edit.item.itemType, summaryID, messageID)
+		if err != nil {
+			return 0, fmt.Errorf("pin %s: %w", contextItemLabel(edit.item), err)
+		}
+		newPinID, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("read pin ID: %w", err)
+		}
+		if err := j.recordInsert(ctx, contextPinsTable, "pin_id = ?", newPinID); err != nil {
+			return 0, err
+		}
+	case contextEditUnpin:
+		if pinID == 0 {
+			return 0, fmt.Errorf("%s is not pinned", contextItemLabel(edit.item))
+		}
+		if err := j.recordDelete(ctx, contextPinsTable, "pin_id = ?", pinID); err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+contextPinsTable+` WHERE pin_id = ?`, pinID); err != nil {
+			return 0, fmt.Errorf("unpin %s: %w", contextItemLabel(edit.item), err)
+		}
+	default:
+		return 0, fmt.Errorf("unknown context edit %q", edit.kind)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit %s: %w", edit.kind, err)
+	}
+	rollback = false
+	return j.opID, nil
+}
+
+// swapContextOrdinals exchanges two context items, parking the first on a
+// temp ordinal so the (conversation_id, ordinal) key never collides.
+func swapContextOrdinals(ctx context.Context, q sqlQueryer, conversationID, a, b int64) error {
+	const tempOffset = 10_000_000
+	steps := []struct{ from, to int64 }{
+		{a, a + tempOffset},
+		{b, a},
+		{a + tempOffset, b},
+	}
+	for _, step := range steps {
+		if _, err := q.ExecContext(ctx, `
+			UPDATE context_items SET ordinal = ?
+			WHERE conversation_id = ? AND ordinal = ?
+		`, step.to, conversationID, step.from); err != nil {
+			return fmt.Errorf("move context ordinal %d to %d: %w", step.from, step.to, err)
+		}
+	}
+	return nil
+}
+
+// removeContextOrdinal deletes one context item and closes the gap. The
+// summary or message it pointed at is left alone.
+func removeContextOrdinal(ctx context.Context, q sqlQueryer, conversationID, ordinal int64) error {
+	if _, err := q.ExecContext(ctx, `
+		DELETE FROM context_items WHERE conversation_id = ? AND ordinal = ?
+	`, conversationID, ordinal); err != nil {
+		return fmt.Errorf("delete context ordinal %d: %w", ordinal, err)
+	}
+	return shiftContextOrdinals(ctx, q, conversationID, ordinal, 1)
+}
+
+// shiftContextOrdinals moves every item after an ordinal down by shift,
+// going through temp ordinals to avoid collisions.
+func shiftContextOrdinals(ctx context.Context, q sqlQueryer, conversationID, afterOrdinal, shift int64) error {
+	const tempOffset = 10_000_000
+	if _, err := q.ExecContext(ctx, `
+		UPDATE context_items
+		SET ordinal = ordinal + ?
+		WHERE conversation_id = ? AND ordinal > ?
+	`, tempOffset, conversationID, afterOrdinal); err != nil {
+		return fmt.Errorf("shift items to temp ordinals: %w", err)
+	}
+	if _, err := q.ExecContext(ctx, `
+		UPDATE context_items
+		SET ordinal = ordinal - ? - ?
+		WHERE conversation_id = ? AND ordinal >= ?
+	`, tempOffset, shift, conversationID, tempOffset); err != nil {
+		return fmt.Errorf("shift items to final ordinals: %w", err)
+	}
+	return nil
+}
+
+// pinnedInRange counts pinned items between two item indexes, for the
+// condense and compact previews.
+func (m model) pinnedInRange(lo, hi int) int {
+	count := 0
+	for i := lo; i <= hi && i < len(m.contextItems); i++ {
+		if m.contextItems[i].pinned {
+			count++
+		}
+	}
+	return count
+}
+
+// startContextEdit previews an edit of the item under the cursor. delta
+// selects the neighbour for a reorder.
+func (m *model) startContextEdit(kind string, delta int) {
+	if len(m.contextItems) == 0 {
+		m.status = "No context items"
+		return
+	}
+	conversationID, ok := m.currentConversationID()
+	if !ok {
+		m.status = "Missing conversation ID for current session"
+		return
+	}
+	item := m.contextItems[m.contextCursor]
+	edit := contextEdit{
+		kind:           kind,
+		conversationID: conversationID,
+		item:           item,
+		totalTokens:    contextTokenTotal(m.contextItems),
+	}
+	switch kind {
+	case contextEditReorder:
+		target := m.contextCursor + delta
+		if target < 0 || target >= len(m.contextItems) {
+			m.status = "Already at the edge of the context"
+			return
+		}
+		edit.other = m.contextItems[target]
+	case contextEditDrop:
+		if item.pinned {
+			m.status = fmt.Sprintf("%s is pinned; press p to unpin it first", contextItemLabel(item))
+			return
+		}
+		edit.itemsToShift = len(m.contextItems) - m.contextCursor - 1
+	case contextEditPin:
+		if item.pinned {
+			edit.kind = contextEditUnpin
+		}
+	}
+	m.pendingContextEdit = &edit
+	m.status = "Preview: " + edit.describe() + ". y: apply, n: cancel"
+}
+
+func (m model) handleContextEditKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "enter":
+		return m, m.confirmContextEdit()
+	case "n", "esc", "b", "backspace":
+		m.pendingContextEdit = nil
+		m.status = "Edit canceled; nothing written"
+	}
+	return m, nil
+}
+
+// confirmContextEdit writes the pending edit in the background and reloads
+// the context with the cursor still on the edited item. Reorders and drops
+// snapshot lcm.db first; pins only touch the sidecar table.
+func (m *model) confirmContextEdit() tea.Cmd {
+	edit := *m.pendingContextEdit
+	m.pendingContextEdit = nil
+
+	cursor := -1
+	if edit.kind == contextEditReorder {
+		for i, item := range m.contextItems {
+			if item.ordinal == edit.other.ordinal {
+				cursor = i
+			}
+		}
+	}
+	reload := ""
+	if session, ok := m.currentSession(); ok {
+		reload = session.id
+	}
+	dbPath, backupsDir := m.paths.lcmDBPath, m.paths.backupsDir
+
+	_, seq := m.beginLoad(fmt.Sprintf("Applying %s...", edit.kind))
+	return m.startLoad(lcmWriteCmd(seq, reload, cursor, func(ctx context.Context) (string, error) {
+		db, err := openLCMDB(dbPath, dbReadWrite)
+		if err != nil {
+			return "", err
+		}
+		defer db.Close()
+
+		backupNote := ""
+		if edit.kind == contextEditReorder || edit.kind == contextEditDrop {
+			label := fmt.Sprintf("tui-%s-conv%d-%d", edit.kind, edit.conversationID, edit.item.ordinal)
+			backup, err := snapshotLCMDB(ctx, db, backupsDir, label)
+			if err != nil {
+				return "", fmt.Errorf("backup before %s: %w", edit.kind, err)
+			}
+			backupNote = " Backup: " + backup.name + "."
+		}
+		opID, err := applyContextEdit(ctx, db, edit)
+		if err != nil {
+			return "", err
+		}
+		description := edit.describe()
+		return fmt.Sprintf("%s%s.%s Undo with: lcm-tui undo %d", strings.ToUpper(description[:1]), description[1:], backupNote, opID), nil
+	}))
+}
+
+// renderContextEditConfirmation shows what the pending edit will change.
+func (m model) renderContextEditConfirmation() string {
+	edit := m.pendingContextEdit
+	item := edit.item
+	lines := []string{"Preview: " + edit.describe(), ""}
+	switch edit.kind {
+	case contextEditReorder:
+		lines = append(lines,
+			"Ordinals swap in context_items:",
+			fmt.Sprintf("  %3d -> %3d  %s (%dt)", item.ordinal, edit.other.ordinal, contextItemLabel(item), item.tokenCount),
+			fmt.Sprintf("  %3d -> %3d  %s (%dt)", edit.other.ordinal, item.ordinal, contextItemLabel(edit.other), edit.other.tokenCount),
+		)
+	case contextEditDrop:
+		lines = append(lines,
+			"  "+strings.TrimLeft(m.formatContextItemLine(item), " "),
+			"",
+			fmt.Sprintf("Context: %dt -> %dt (-%dt)", edit.totalTokens, edit.totalTokens-item.tokenCount, item.tokenCount),
+			fmt.Sprintf("Ordinal shift: %d item(s) after ordinal %d will shift by -1", edit.itemsToShift, item.ordinal),
+		)
+		if item.itemType == "summary" {
+			lines = append(lines, fmt.Sprintf("%s stays in the summary DAG; only its context item is removed.", item.summaryID))
+		} else {
+			lines = append(lines, fmt.Sprintf("Message #%d stays in the conversation; only its context item is removed.", item.messageID))
+		}
+	case contextEditPin:
+		lines = append(lines,
+			"  "+strings.TrimLeft(m.formatContextItemLine(item), " "),
+			"",
+			"Records the item in "+contextPinsTable+". Drop refuses pinned items, and",
+			"lcm-tui doctor reports the pin if compaction later removes the item.",
+		)
+	case contextEditUnpin:
+		lines = append(lines,
+			"  "+strings.TrimLeft(m.formatContextItemLine(item), " "),
+			"",
+			"Removes the pin from "+contextPinsTable+"; the context item is unchanged.",
+		)
+	}
+	lines = append(lines, "", "The edit is journaled. Press y or Enter to apply, n or Esc to cancel.")
+	return strings.Join(lines, "\n")
+}
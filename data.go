@@ -2,10 +2,12 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -19,6 +21,9 @@ import (
 type appDataPaths struct {
 	agentsDir        string
 	lcmDBPath        string
+	backupsDir       string
+	promptsDir       string
+	tokenizersDir    string
 	openclawDir      string
 	openclawConfig   string
 	openclawEnv      string
@@ -110,6 +115,7 @@ type contextItemEntry struct {
 	content    string // full sanitized content
 	preview    string // single-line preview for list
 	createdAt  string
+	pinned     bool // recorded in lcm_tui_pins
 }
 
 // summaryGraph is the in-memory DAG used by the summary drill-down view.
@@ -117,12 +123,16 @@ type summaryGraph struct {
 	conversationID int64
 	roots          []string
 	nodes          map[string]*summaryNode
+	// derivedBy maps a summary to the condensed summaries built from it. A
+	// summary with more than one is listed under each of them.
+	derivedBy map[string][]string
 }
 
 // summaryRow is one visible row in the flattened summary tree.
 type summaryRow struct {
 	summaryID string
 	depth     int
+	parentID  string // condensed summary this row is listed under; empty for roots
 }
 
 // contentBlock supports the JSONL message content block format.
@@ -160,6 +170,9 @@ func resolveDataPaths() (appDataPaths, error) {
 	return appDataPaths{
 		agentsDir:        filepath.Join(base, "agents"),
 		lcmDBPath:        filepath.Join(base, "lcm.db"),
+		backupsDir:       filepath.Join(base, "lcm-backups"),
+		promptsDir:       filepath.Join(base, "lcm-tui", "prompts"),
+		tokenizersDir:    filepath.Join(base, "lcm-tui", "tokenizers"),
 		openclawDir:      base,
 		openclawConfig:   filepath.Join(base, "openclaw.json"),
 		openclawEnv:      filepath.Join(base, ".env"),
@@ -190,6 +203,17 @@ func loadAgents(agentsDir string) ([]agentEntry, error) {
 	return agents, nil
 }
 
+// findAgentForSession returns the index of the agent whose sessions directory
+// holds the JSONL file for sessionID.
+func findAgentForSession(agents []agentEntry, sessionID string) (int, bool) {
+	for i, agent := range agents {
+		if _, err := os.Stat(filepath.Join(agent.path, "sessions", sessionID+".jsonl")); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 func discoverSessionFiles(agent agentEntry) ([]sessionFileEntry, error) {
 	sessionsDir := filepath.Join(agent.path, "sessions")
 	paths, err := filepath.Glob(filepath.Join(sessionsDir, "*.jsonl"))
@@ -217,7 +241,7 @@ func discoverSessionFiles(agent agentEntry) ([]sessionFileEntry, error) {
 	return sessions, nil
 }
 
-func loadSessionBatch(files []sessionFileEntry, offset, limit int, lcmDBPath string) ([]sessionEntry, int, error) {
+func loadSessionBatch(ctx context.Context, files []sessionFileEntry, offset, limit int, db *sql.DB) ([]sessionEntry, int, error) {
 	if offset < 0 {
 		offset = 0
 	}
@@ -236,6 +260,9 @@ func loadSessionBatch(files []sessionFileEntry, offset, limit int, lcmDBPath str
 	sessions := make([]sessionEntry, 0, end-offset)
 	sessionIDs := make([]string, 0, end-offset)
 	for _, file := range files[offset:end] {
+		if err := ctx.Err(); err != nil {
+			return nil, offset, err
+		}
 		messageCount, err := countMessages(file.path)
 		if err != nil {
 			messageCount = -1
@@ -251,9 +278,9 @@ func loadSessionBatch(files []sessionFileEntry, offset, limit int, lcmDBPath str
 		})
 	}
 
-	summaryCounts := loadSummaryCounts(lcmDBPath, sessionIDs)
-	fileCounts := loadFileCounts(lcmDBPath, sessionIDs)
-	conversationIDs := loadConversationIDs(lcmDBPath, sessionIDs)
+	summaryCounts := loadSummaryCounts(ctx, db, sessionIDs)
+	fileCounts := loadFileCounts(ctx, db, sessionIDs)
+	conversationIDs := loadConversationIDs(ctx, db, sessionIDs)
 	for i := range sessions {
 		sessions[i].summaryCount = summaryCounts[sessions[i].id]
 		sessions[i].fileCount = fileCounts[sessions[i].id]
@@ -263,12 +290,12 @@ func loadSessionBatch(files []sessionFileEntry, offset, limit int, lcmDBPath str
 	return sessions, end, nil
 }
 
-func loadSessions(agent agentEntry, lcmDBPath string) ([]sessionEntry, error) {
+func loadSessions(ctx context.Context, agent agentEntry, db *sql.DB) ([]sessionEntry, error) {
 	files, err := discoverSessionFiles(agent)
 	if err != nil {
 		return nil, err
 	}
-	sessions, _, err := loadSessionBatch(files, 0, len(files), lcmDBPath)
+	sessions, _, err := loadSessionBatch(ctx, files, 0, len(files), db)
 	if err != nil {
 		return nil, err
 	}
@@ -306,7 +333,7 @@ func countMessages(path string) (int, error) {
 	return count, nil
 }
 
-func parseSessionMessages(path string) ([]sessionMessage, error) {
+func parseSessionMessages(ctx context.Context, path string) ([]sessionMessage, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("open session %q: %w", path, err)
@@ -318,7 +345,14 @@ func parseSessionMessages(path string) ([]sessionMessage, error) {
 	scanner.Buffer(buf, 16*1024*1024)
 
 	messages := make([]sessionMessage, 0, 256)
-	for scanner.Scan() {
+	for lineNo := 0; scanner.Scan(); lineNo++ {
+		// Check for cancellation periodically so stale loads of large
+		// session files stop early.
+		if lineNo%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 		line := scanner.Bytes()
 		if len(line) == 0 {
 			continue
@@ -517,27 +551,91 @@ func formatContentBlock(block contentBlock) string {
 	}
 }
 
-func openLCMDB(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", path)
+// dbAccess selects how openLCMDB opens the LCM database.
+type dbAccess int
+
+const (
+	// dbReadOnly is used for browsing and dry runs. The connection cannot
+	// write, so it never takes the write lock the OpenClaw gateway needs.
+	dbReadOnly dbAccess = iota
+	// dbReadWrite is used only by paths that mutate the DB (apply modes and
+	// TUI mutations), and always on a separate handle from the browser's.
+	dbReadWrite
+)
+
+// errLCMDBUnavailable is returned by loaders when the TUI could not open the
+// LCM database at startup.
+var errLCMDBUnavailable = errors.New("LCM database is not open")
+
+// lcmDBBusyTimeout is how long a connection waits on a lock held by the
+// gateway before giving up with SQLITE_BUSY.
+const lcmDBBusyTimeout = 5 * time.Second
+
+// accessFor returns dbReadWrite when a command is going to apply changes.
+func accessFor(apply bool) dbAccess {
+	if apply {
+		return dbReadWrite
+	}
+	return dbReadOnly
+}
+
+// openLCMDB opens the LCM database and verifies that it is reachable.
+//
+// Read-only handles use mode=ro plus query_only so browsing can never write.
+// Read-write handles start transactions with BEGIN IMMEDIATE: in WAL mode a
+// deferred transaction that later upgrades to a write fails with SQLITE_BUSY
+// without honoring busy_timeout, whereas an immediate one waits its turn.
+// Neither mode changes the journal mode; that belongs to the gateway.
+func openLCMDB(path string, access dbAccess) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", lcmDBBusyTimeout.Milliseconds()))
+	switch access {
+	case dbReadWrite:
+		query.Set("mode", "rw")
+		query.Set("_txlock", "immediate")
+	default:
+		query.Set("mode", "ro")
+		query.Add("_pragma", "query_only(1)")
+	}
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?" + query.Encode()
+
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite db %q: %w", path, err)
 	}
+	if access == dbReadWrite {
+		// A single writer connection keeps our own transactions from
+		// contending with each other for the database lock.
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("open sqlite db %q: %w", path, err)
+	}
 	return db, nil
 }
 
-func loadSummaryGraph(dbPath, sessionID string) (summaryGraph, error) {
-	db, err := openLCMDB(dbPath)
-	if err != nil {
-		return summaryGraph{}, err
+// lcmJournalMode reports the database journal mode (normally "wal" for a DB
+// written by the gateway).
+func lcmJournalMode(ctx context.Context, db *sql.DB) (string, error) {
+	var mode string
+	if err := db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil {
+		return "", fmt.Errorf("query journal mode: %w", err)
 	}
-	defer db.Close()
+	return strings.ToLower(mode), nil
+}
 
-	conversationID, err := lookupConversationID(db, sessionID)
+func loadSummaryGraph(ctx context.Context, db *sql.DB, sessionID string) (summaryGraph, error) {
+	if db == nil {
+		return summaryGraph{}, errLCMDBUnavailable
+	}
+
+	conversationID, err := lookupConversationID(ctx, db, sessionID)
 	if err != nil {
 		return summaryGraph{}, err
 	}
 
-	nodes, err := loadSummaryNodes(db, conversationID)
+	nodes, err := loadSummaryNodes(ctx, db, conversationID)
 	if err != nil {
 		return summaryGraph{}, err
 	}
@@ -545,7 +643,7 @@ func loadSummaryGraph(dbPath, sessionID string) (summaryGraph, error) {
 		return summaryGraph{conversationID: conversationID, nodes: map[string]*summaryNode{}}, nil
 	}
 
-	childSet, err := populateSummaryChildren(db, conversationID, nodes)
+	childSet, err := populateSummaryChildren(ctx, db, conversationID, nodes)
 	if err != nil {
 		return summaryGraph{}, err
 	}
@@ -560,12 +658,13 @@ func loadSummaryGraph(dbPath, sessionID string) (summaryGraph, error) {
 		conversationID: conversationID,
 		roots:          roots,
 		nodes:          nodes,
+		derivedBy:      findSummaryDerivedBy(nodes),
 	}, nil
 }
 
-func lookupConversationID(db *sql.DB, sessionID string) (int64, error) {
+func lookupConversationID(ctx context.Context, db *sql.DB, sessionID string) (int64, error) {
 	var conversationID int64
-	err := db.QueryRow(`
+	err := db.QueryRowContext(ctx, `
 		SELECT conversation_id
 		FROM conversations
 		WHERE session_id = ?
@@ -581,8 +680,8 @@ func lookupConversationID(db *sql.DB, sessionID string) (int64, error) {
 	return conversationID, nil
 }
 
-func loadSummaryNodes(db *sql.DB, conversationID int64) (map[string]*summaryNode, error) {
-	rows, err := db.Query(`
+func loadSummaryNodes(ctx context.Context, db *sql.DB, conversationID int64) (map[string]*summaryNode, error) {
+	rows, err := db.QueryContext(ctx, `
 		SELECT summary_id, kind, COALESCE(depth, 0), content, created_at, token_count
 		FROM summaries
 		WHERE conversation_id = ?
@@ -607,8 +706,8 @@ func loadSummaryNodes(db *sql.DB, conversationID int64) (map[string]*summaryNode
 	return nodes, nil
 }
 
-func populateSummaryChildren(db *sql.DB, conversationID int64, nodes map[string]*summaryNode) (map[string]bool, error) {
-	rows, err := db.Query(`
+func populateSummaryChildren(ctx context.Context, db *sql.DB, conversationID int64, nodes map[string]*summaryNode) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `
 		SELECT sp.parent_summary_id, sp.summary_id
 		FROM summary_parents sp
 		JOIN summaries s ON s.summary_id = sp.summary_id
@@ -655,6 +754,21 @@ func findSummaryRoots(nodes map[string]*summaryNode, childSet map[string]bool) [
 	return roots
 }
 
+// findSummaryDerivedBy inverts the children lists: for each summary, the
+// condensed summaries that list it as a child, in display order.
+func findSummaryDerivedBy(nodes map[string]*summaryNode) map[string][]string {
+	derivedBy := make(map[string][]string)
+	for id, node := range nodes {
+		for _, childID := range node.children {
+			derivedBy[childID] = append(derivedBy[childID], id)
+		}
+	}
+	for _, ids := range derivedBy {
+		sortSummaryIDs(ids, nodes)
+	}
+	return derivedBy
+}
+
 func sortSummaryIDs(ids []string, nodes map[string]*summaryNode) {
 	sort.Slice(ids, func(i, j int) bool {
 		left := nodes[ids[i]]
@@ -669,14 +783,12 @@ func sortSummaryIDs(ids []string, nodes map[string]*summaryNode) {
 	})
 }
 
-func loadSummarySources(dbPath, summaryID string) ([]summarySource, error) {
-	db, err := openLCMDB(dbPath)
-	if err != nil {
-		return nil, err
+func loadSummarySources(ctx context.Context, db *sql.DB, summaryID string) ([]summarySource, error) {
+	if db == nil {
+		return nil, errLCMDBUnavailable
 	}
-	defer db.Close()
 
-	rows, err := db.Query(`
+	rows, err := db.QueryContext(ctx, `
 		SELECT m.message_id, m.role, m.content, m.created_at
 		FROM summary_messages sm
 		JOIN messages m ON m.message_id = sm.message_id
@@ -703,17 +815,11 @@ func loadSummarySources(dbPath, summaryID string) ([]summarySource, error) {
 	return sources, nil
 }
 
-func loadSummaryCounts(dbPath string, sessionIDs []string) map[string]int {
+func loadSummaryCounts(ctx context.Context, db *sql.DB, sessionIDs []string) map[string]int {
 	counts := make(map[string]int, len(sessionIDs))
-	if len(sessionIDs) == 0 {
-		return counts
-	}
-	db, err := openLCMDB(dbPath)
-	if err != nil {
+	if len(sessionIDs) == 0 || db == nil {
 		return counts
 	}
-	defer db.Close()
-
 	// Build query with placeholders
 	placeholders := make([]string, len(sessionIDs))
 	args := make([]any, len(sessionIDs))
@@ -729,7 +835,7 @@ func loadSummaryCounts(dbPath string, sessionIDs []string) map[string]int {
 		GROUP BY c.session_id
 	`, strings.Join(placeholders, ","))
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return counts
 	}
@@ -746,19 +852,17 @@ func loadSummaryCounts(dbPath string, sessionIDs []string) map[string]int {
 	return counts
 }
 
-func loadLargeFiles(dbPath, sessionID string) ([]largeFileEntry, error) {
-	db, err := openLCMDB(dbPath)
-	if err != nil {
-		return nil, err
+func loadLargeFiles(ctx context.Context, db *sql.DB, sessionID string) ([]largeFileEntry, error) {
+	if db == nil {
+		return nil, errLCMDBUnavailable
 	}
-	defer db.Close()
 
-	conversationID, err := lookupConversationID(db, sessionID)
+	conversationID, err := lookupConversationID(ctx, db, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := db.Query(`
+	rows, err := db.QueryContext(ctx, `
 		SELECT file_id, conversation_id, file_name, mime_type, byte_size, storage_uri, exploration_summary, created_at
 		FROM large_files
 		WHERE conversation_id = ?
@@ -789,17 +893,11 @@ func loadLargeFiles(dbPath, sessionID string) ([]largeFileEntry, error) {
 	return files, nil
 }
 
-func loadFileCounts(dbPath string, sessionIDs []string) map[string]int {
+func loadFileCounts(ctx context.Context, db *sql.DB, sessionIDs []string) map[string]int {
 	counts := make(map[string]int, len(sessionIDs))
-	if len(sessionIDs) == 0 {
-		return counts
-	}
-	db, err := openLCMDB(dbPath)
-	if err != nil {
+	if len(sessionIDs) == 0 || db == nil {
 		return counts
 	}
-	defer db.Close()
-
 	placeholders := make([]string, len(sessionIDs))
 	args := make([]any, len(sessionIDs))
 	for i, id := range sessionIDs {
@@ -814,7 +912,7 @@ func loadFileCounts(dbPath string, sessionIDs []string) map[string]int {
 		GROUP BY c.session_id
 	`, strings.Join(placeholders, ","))
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return counts
 	}
@@ -831,18 +929,12 @@ func loadFileCounts(dbPath string, sessionIDs []string) map[string]int {
 	return counts
 }
 
-func loadConversationIDs(dbPath string, sessionIDs []string) map[string]int64 {
+func loadConversationIDs(ctx context.Context, db *sql.DB, sessionIDs []string) map[string]int64 {
 	// Resolve one LCM conversation_id per session for list/header display.
 	ids := make(map[string]int64, len(sessionIDs))
-	if len(sessionIDs) == 0 {
+	if len(sessionIDs) == 0 || db == nil {
 		return ids
 	}
-	db, err := openLCMDB(dbPath)
-	if err != nil {
-		return ids
-	}
-	defer db.Close()
-
 	placeholders := make([]string, len(sessionIDs))
 	args := make([]any, len(sessionIDs))
 	for i, sessionID := range sessionIDs {
@@ -856,7 +948,7 @@ func loadConversationIDs(dbPath string, sessionIDs []string) map[string]int64 {
 		GROUP BY session_id
 	`, strings.Join(placeholders, ","))
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return ids
 	}
@@ -873,19 +965,17 @@ func loadConversationIDs(dbPath string, sessionIDs []string) map[string]int64 {
 	return ids
 }
 
-func loadContextItems(dbPath, sessionID string) ([]contextItemEntry, error) {
-	db, err := openLCMDB(dbPath)
-	if err != nil {
-		return nil, err
+func loadContextItems(ctx context.Context, db *sql.DB, sessionID string) ([]contextItemEntry, error) {
+	if db == nil {
+		return nil, errLCMDBUnavailable
 	}
-	defer db.Close()
 
-	conversationID, err := lookupConversationID(db, sessionID)
+	conversationID, err := lookupConversationID(ctx, db, sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := db.Query(`
+	rows, err := db.QueryContext(ctx, `
 		SELECT
 			ci.ordinal,
 			ci.item_type,
@@ -955,6 +1045,18 @@ func loadContextItems(dbPath, sessionID string) ([]contextItemEntry, error) {
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate context items: %w", err)
 	}
+
+	pins, err := loadContextPins(ctx, db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		pinned[pin.key()] = true
+	}
+	for i := range items {
+		items[i].pinned = pinned[contextPinKey(items[i].itemType, items[i].summaryID, items[i].messageID)]
+	}
 	return items, nil
 }
 
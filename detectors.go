@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultDetectors = "marker"
+
+	// oversizeFactor flags summaries this many times over the largest target
+	// their prompt asks for.
+	oversizeFactor = 2
+	// transcriptLineShare is the share of non-empty lines that must look like
+	// chat turns before content counts as a raw transcript dump.
+	transcriptLineShare = 0.5
+	transcriptMinLines  = 3
+)
+
+// summaryDetector is one named heuristic for spotting a summary that needs
+// repair. detect returns a short reason when the summary is flagged.
+type summaryDetector struct {
+	name        string
+	description string
+	detect      func(item repairSummary) (string, bool)
+}
+
+// summaryDetectors is the registry selectable with `repair --detect`.
+var summaryDetectors = []summaryDetector{
+	{name: "marker", description: "contains the LCM fallback truncation marker", detect: detectFallbackMarker},
+	{name: "empty", description: "content is empty or whitespace", detect: detectEmptySummary},
+	{name: "thinking", description: "content is only echoed thinking blocks", detect: detectThinkingEcho},
+	{name: "oversize", description: fmt.Sprintf("content is over %dx the prompt's target length", oversizeFactor), detect: detectOversizeSummary},
+	{name: "transcript", description: "content is a raw role-prefixed transcript dump", detect: detectTranscriptDump},
+	{name: "format", description: "condensed summary is missing required section headings", detect: detectMissingHeadings},
+}
+
+var (
+	thinkingBlockPattern  = regexp.MustCompile(`(?is)<(thinking|think)>.*?(</(thinking|think)>|$)`)
+	transcriptLinePattern = regexp.MustCompile(`(?i)^(\[(user|assistant|system|tool|toolresult)\]|(user|assistant|human|system|tool)\s*:)`)
+)
+
+// parseDetectors resolves a comma-separated detector list. "all" selects
+// every registered detector.
+func parseDetectors(spec string) ([]summaryDetector, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = defaultDetectors
+	}
+	if spec == "all" {
+		return summaryDetectors, nil
+	}
+
+	var selected []summaryDetector
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		detector, ok := findDetector(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown detector %q (want %s or all)", name, strings.Join(detectorNames(summaryDetectors), ", "))
+		}
+		seen[name] = true
+		selected = append(selected, detector)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("--detect selected no detectors")
+	}
+	return selected, nil
+}
+
+func findDetector(name string) (summaryDetector, bool) {
+	for _, detector := range summaryDetectors {
+		if detector.name == name {
+			return detector, true
+		}
+	}
+	return summaryDetector{}, false
+}
+
+func detectorNames(detectors []summaryDetector) []string {
+	names := make([]string, len(detectors))
+	for i, detector := range detectors {
+		names[i] = detector.name
+	}
+	return names
+}
+
+// runDetectors returns "name: reason" for every detector that flags item.
+func runDetectors(detectors []summaryDetector, item repairSummary) []string {
+	var findings []string
+	for _, detector := range detectors {
+		if reason, flagged := detector.detect(item); flagged {
+			findings = append(findings, detector.name+": "+reason)
+		}
+	}
+	return findings
+}
+
+// detectorLabels returns just the detector names from runDetectors output.
+func detectorLabels(findings []string) string {
+	names := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		name, _, _ := strings.Cut(finding, ":")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func isLeafSummary(item repairSummary) bool {
+	return item.depth == 0 || strings.EqualFold(item.kind, "leaf")
+}
+
+func detectFallbackMarker(item repairSummary) (string, bool) {
+	if strings.Contains(item.content, corruptedSummaryMarker) {
+		return "fallback marker present", true
+	}
+	return "", false
+}
+
+func detectEmptySummary(item repairSummary) (string, bool) {
+	if strings.TrimSpace(item.content) == "" {
+		return "empty content", true
+	}
+	return "", false
+}
+
+func detectThinkingEcho(item repairSummary) (string, bool) {
+	if !thinkingBlockPattern.MatchString(item.content) {
+		return "", false
+	}
+	if strings.TrimSpace(thinkingBlockPattern.ReplaceAllString(item.content, "")) == "" {
+		return "only thinking blocks", true
+	}
+	return "", false
+}
+
+func detectOversizeSummary(item repairSummary) (string, bool) {
+	target := condensedTargetTokens
+	if isLeafSummary(item) {
+		target = calculateLeafTargetTokens(1 << 30)
+	}
+	tokens := estimateTokenCount(item.content)
+	if tokens > target*oversizeFactor {
+		return fmt.Sprintf("~%dt vs %dt target", tokens, target), true
+	}
+	return "", false
+}
+
+func detectTranscriptDump(item repairSummary) (string, bool) {
+	total, matched := 0, 0
+	for _, line := range strings.Split(item.content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		total++
+		if transcriptLinePattern.MatchString(line) {
+			matched++
+		}
+	}
+	if total >= transcriptMinLines && float64(matched) >= float64(total)*transcriptLineShare {
+		return fmt.Sprintf("%d of %d lines are chat turns", matched, total), true
+	}
+	return "", false
+}
+
+func detectMissingHeadings(item repairSummary) (string, bool) {
+	if isLeafSummary(item) || strings.TrimSpace(item.content) == "" {
+		return "", false
+	}
+	present := make(map[string]bool)
+	for _, line := range strings.Split(item.content, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#* "))
+		line = strings.TrimSpace(strings.TrimRight(line, ":*"))
+		present[strings.ToLower(line)] = true
+	}
+	var missing []string
+	for _, heading := range condensedSummaryHeadings {
+		if !present[strings.ToLower(heading)] {
+			missing = append(missing, heading)
+		}
+	}
+	if len(missing) > 0 {
+		return "missing " + strings.Join(missing, ", "), true
+	}
+	return "", false
+}
@@ -55,7 +55,7 @@ func runDissolveCommand(args []string) error {
 		return err
 	}
 
-	db, err := openLCMDB(paths.lcmDBPath)
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
 	if err != nil {
 		return err
 	}
@@ -87,12 +87,17 @@ func runDissolveCommand(args []string) error {
 		return nil
 	}
 
-	fmt.Println("\nApplying...")
-	newCount, err := applyDissolvePlan(ctx, db, plan, opts.purge)
+	fmt.Println()
+	if err := backupBeforeApply(ctx, db, paths, fmt.Sprintf("dissolve-conv%d-%s", conversationID, opts.summaryID)); err != nil {
+		return err
+	}
+	fmt.Println("Applying...")
+	newCount, opID, err := applyDissolvePlan(ctx, db, plan, opts.purge)
 	if err != nil {
 		return err
 	}
 	fmt.Printf("\nDone. Context now has %d items. Changes take effect on next conversation turn.\n", newCount)
+	fmt.Printf("Undo with: lcm-tui undo %d --apply\n", opID)
 	return nil
 }
 
@@ -136,10 +141,10 @@ func buildDissolvePlan(ctx context.Context, db *sql.DB, conversationID int64, su
 }
 
 // applyDissolvePlan performs the transactional context rewrite from a dry-run plan.
-func applyDissolvePlan(ctx context.Context, db *sql.DB, plan dissolvePlan, purge bool) (int, error) {
+func applyDissolvePlan(ctx context.Context, db *sql.DB, plan dissolvePlan, purge bool) (int, int64, error) {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, fmt.Errorf("begin transaction: %w", err)
+		return 0, 0, fmt.Errorf("begin transaction: %w", err)
 	}
 	rollback := true
 	defer func() {
@@ -148,16 +153,29 @@ func applyDissolvePlan(ctx context.Context, db *sql.DB, plan dissolvePlan, purge
 		}
 	}()
 
+	description := fmt.Sprintf("dissolve %s", plan.target.summaryID)
+	if purge {
+		description += " (purge)"
+	}
+	j, err := startJournalOp(ctx, tx, "dissolve", plan.target.conversationID, description)
+	if err != nil {
+		return 0, 0, err
+	}
+	contextBefore, err := captureContext(ctx, tx, plan.target.conversationID)
+	if err != nil {
+		return 0, 0, err
+	}
+
 	res, err := tx.ExecContext(ctx, `
 		DELETE FROM context_items
 		WHERE conversation_id = ? AND ordinal = ? AND summary_id = ?
 	`, plan.target.conversationID, plan.target.ordinal, plan.target.summaryID)
 	if err != nil {
-		return 0, fmt.Errorf("delete condensed context_item: %w", err)
+		return 0, 0, fmt.Errorf("delete condensed context_item: %w", err)
 	}
 	deleted, _ := res.RowsAffected()
 	if deleted != 1 {
-		return 0, fmt.Errorf("expected to delete 1 context_item, deleted %d", deleted)
+		return 0, 0, fmt.Errorf("expected to delete 1 context_item, deleted %d", deleted)
 	}
 
 	if plan.shift > 0 {
@@ -168,7 +186,7 @@ func applyDissolvePlan(ctx context.Context, db *sql.DB, plan dissolvePlan, purge
 			WHERE conversation_id = ? AND ordinal > ?
 		`, tempOffset, plan.target.conversationID, plan.target.ordinal)
 		if err != nil {
-			return 0, fmt.Errorf("shift items to temp ordinals: %w", err)
+			return 0, 0, fmt.Errorf("shift items to temp ordinals: %w", err)
 		}
 
 		_, err = tx.ExecContext(ctx, `
@@ -177,7 +195,7 @@ func applyDissolvePlan(ctx context.Context, db *sql.DB, plan dissolvePlan, purge
 			WHERE conversation_id = ? AND ordinal >= ?
 		`, tempOffset, plan.shift, plan.target.conversationID, tempOffset)
 		if err != nil {
-			return 0, fmt.Errorf("shift items to final ordinals: %w", err)
+			return 0, 0, fmt.Errorf("shift items to final ordinals: %w", err)
 		}
 	}
 
@@ -188,27 +206,37 @@ func applyDissolvePlan(ctx context.Context, db *sql.DB, plan dissolvePlan, purge
 			VALUES (?, ?, 'summary', ?, datetime('now'))
 		`, plan.target.conversationID, newOrdinal, parent.summaryID)
 		if err != nil {
-			return 0, fmt.Errorf("insert parent %s at ordinal %d: %w", parent.summaryID, newOrdinal, err)
+			return 0, 0, fmt.Errorf("insert parent %s at ordinal %d: %w", parent.summaryID, newOrdinal, err)
 		}
 	}
 
+	if err := j.recordContext(ctx, plan.target.conversationID, contextBefore); err != nil {
+		return 0, 0, err
+	}
+
 	if purge {
+		if err := j.recordDelete(ctx, "summary_parents", "summary_id = ?", plan.target.summaryID); err != nil {
+			return 0, 0, err
+		}
 		_, err = tx.ExecContext(ctx, `
 			DELETE FROM summary_parents WHERE summary_id = ?
 		`, plan.target.summaryID)
 		if err != nil {
-			return 0, fmt.Errorf("delete summary_parents for %s: %w", plan.target.summaryID, err)
+			return 0, 0, fmt.Errorf("delete summary_parents for %s: %w", plan.target.summaryID, err)
+		}
+		if err := j.recordDelete(ctx, "summaries", "summary_id = ?", plan.target.summaryID); err != nil {
+			return 0, 0, err
 		}
 		_, err = tx.ExecContext(ctx, `
 			DELETE FROM summaries WHERE summary_id = ?
 		`, plan.target.summaryID)
 		if err != nil {
-			return 0, fmt.Errorf("delete summary record %s: %w", plan.target.summaryID, err)
+			return 0, 0, fmt.Errorf("delete summary record %s: %w", plan.target.summaryID, err)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return 0, fmt.Errorf("commit: %w", err)
+		return 0, 0, fmt.Errorf("commit: %w", err)
 	}
 	rollback = false
 
@@ -216,7 +244,7 @@ func applyDissolvePlan(ctx context.Context, db *sql.DB, plan dissolvePlan, purge
 	_ = db.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM context_items WHERE conversation_id = ?
 	`, plan.target.conversationID).Scan(&newCount)
-	return newCount, nil
+	return newCount, j.opID, nil
 }
 
 func parseDissolveArgs(args []string) (dissolveOptions, int64, error) {
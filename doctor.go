@@ -0,0 +1,1249 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Doctor check names. They appear verbatim in both report formats.
+const (
+	doctorCheckDanglingEdge           = "dangling_edge"
+	doctorCheckCrossConversationEdge  = "cross_conversation_edge"
+	doctorCheckCycle                  = "cycle"
+	doctorCheckOrphanSummary          = "orphan_summary"
+	doctorCheckMissingSummaryMessages = "missing_summary_messages"
+	doctorCheckDanglingSummaryMessage = "dangling_summary_message"
+	doctorCheckMissingSummaryParents  = "missing_summary_parents"
+	doctorCheckTokenDrift             = "token_drift"
+	doctorCheckOrdinalGap             = "ordinal_gap"
+	doctorCheckOrdinalDuplicate       = "ordinal_duplicate"
+	doctorCheckDanglingContextItem    = "dangling_context_item"
+	doctorCheckCorruptedSummary       = "corrupted_summary"
+	doctorCheckPinnedItemRemoved      = "pinned_item_removed"
+)
+
+// Token drift is reported only when the stored count is off from the
+// --tokenizer count by more than both thresholds, so short summaries and
+// tokenizer differences do not drown out real drift.
+const (
+	doctorTokenDriftRatio     = 0.5
+	doctorTokenDriftMinTokens = 32
+)
+
+// errDoctorFindings signals that the scan succeeded but found problems. main
+// exits non-zero without printing it as a failure.
+var errDoctorFindings = errors.New("doctor found problems")
+
+// Doctor fix classes, selectable with --fix=<class>[,<class>...].
+const (
+	doctorFixOrdinals = "ordinals" // renumber context_items to 0..n-1
+	doctorFixContext  = "context"  // delete context items whose target is gone
+	doctorFixEdges    = "edges"    // delete summary_parents rows with a missing endpoint
+	doctorFixLinks    = "links"    // delete summary_messages rows for missing messages
+	doctorFixTokens   = "tokens"   // recompute drifted summary token counts
+	doctorFixPins     = "pins"     // delete pins whose item left the context
+)
+
+var doctorFixClassNames = []string{doctorFixOrdinals, doctorFixContext, doctorFixEdges, doctorFixLinks, doctorFixTokens, doctorFixPins}
+
+type doctorOptions struct {
+	all       bool
+	json      bool
+	fix       doctorFixClasses
+	apply     bool
+	tokenizer string
+	tokens    tokenCounter
+}
+
+// doctorFixClasses is the set of enabled fix classes. As a flag it accepts
+// --fix=all or --fix=ordinals,tokens; normalizeDoctorArgs turns a bare --fix
+// into --fix=all.
+type doctorFixClasses map[string]bool
+
+func (c *doctorFixClasses) String() string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(c.names(), ",")
+}
+
+func (c *doctorFixClasses) Set(value string) error {
+	classes := doctorFixClasses{}
+	if value == "all" {
+		for _, name := range doctorFixClassNames {
+			classes[name] = true
+		}
+		*c = classes
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		name := strings.TrimSpace(part)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, candidate := range doctorFixClassNames {
+			if name == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown fix class %q (want %s)", name, strings.Join(doctorFixClassNames, ", "))
+		}
+		classes[name] = true
+	}
+	if len(classes) == 0 {
+		return errors.New("--fix needs at least one fix class")
+	}
+	*c = classes
+	return nil
+}
+
+// names returns the enabled classes in their canonical order.
+func (c doctorFixClasses) names() []string {
+	var names []string
+	for _, name := range doctorFixClassNames {
+		if c[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// doctorFinding is one invariant violation. Fields other than Check,
+// ConversationID and Detail are set when they identify the offending row.
+type doctorFinding struct {
+	Check           string `json:"check"`
+	ConversationID  int64  `json:"conversation_id"`
+	SummaryID       string `json:"summary_id,omitempty"`
+	ParentSummaryID string `json:"parent_summary_id,omitempty"`
+	MessageID       int64  `json:"message_id,omitempty"`
+	Ordinal         *int64 `json:"ordinal,omitempty"`
+	Detail          string `json:"detail"`
+	// TargetMissing marks a row that references a row which no longer
+	// exists, as opposed to one that exists in the wrong place.
+	TargetMissing bool `json:"target_missing,omitempty"`
+}
+
+type doctorReport struct {
+	Conversations []int64         `json:"conversations"`
+	Findings      []doctorFinding `json:"findings"`
+	Counts        map[string]int  `json:"counts"`
+}
+
+type doctorSummary struct {
+	summaryID  string
+	kind       string
+	depth      int
+	tokenCount int
+	content    string
+}
+
+// doctorEdge is one summary_parents row. Conversation IDs are invalid when the
+// referenced summary row no longer exists.
+type doctorEdge struct {
+	summaryID          string
+	parentSummaryID    string
+	childConversation  sql.NullInt64
+	parentConversation sql.NullInt64
+}
+
+type doctorSummaryMessage struct {
+	summaryID           string
+	messageID           int64
+	messageConversation sql.NullInt64
+}
+
+type doctorContextItem struct {
+	ordinal             int64
+	itemType            string
+	messageID           sql.NullInt64
+	summaryID           sql.NullString
+	messageConversation sql.NullInt64
+	summaryConversation sql.NullInt64
+}
+
+func runDoctorCommand(args []string) error {
+	opts, conversationID, err := parseDoctorArgs(args)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	opts.tokens, err = newTokenCounter(opts.tokenizer, paths)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	report, err := runDoctor(ctx, db, opts, conversationID)
+	if err != nil {
+		return err
+	}
+	if len(opts.fix) > 0 {
+		return runDoctorFix(ctx, db, paths.backupsDir, opts, conversationID, report)
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode doctor report: %w", err)
+		}
+	} else {
+		printDoctorReport(report)
+	}
+	if len(report.Findings) > 0 {
+		return errDoctorFindings
+	}
+	return nil
+}
+
+func parseDoctorArgs(args []string) (doctorOptions, int64, error) {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	all := fs.Bool("all", false, "check all conversations")
+	jsonOut := fs.Bool("json", false, "print the report as JSON")
+	var fix doctorFixClasses
+	fs.Var(&fix, "fix", "plan fixes for the given classes (default: all)")
+	apply := fs.Bool("apply", false, "apply the planned fixes")
+	tokenizer := fs.String("tokenizer", defaultTokenizer, "tokenizer for token drift: heuristic, an encoding, or a model name")
+
+	normalizedArgs, err := normalizeDoctorArgs(args)
+	if err != nil {
+		return doctorOptions{}, 0, fmt.Errorf("%w\n%s", err, doctorUsageText())
+	}
+	if err := fs.Parse(normalizedArgs); err != nil {
+		return doctorOptions{}, 0, fmt.Errorf("%w\n%s", err, doctorUsageText())
+	}
+
+	opts := doctorOptions{all: *all, json: *jsonOut, fix: fix, apply: *apply, tokenizer: strings.TrimSpace(*tokenizer)}
+	if opts.apply && len(opts.fix) == 0 {
+		return doctorOptions{}, 0, fmt.Errorf("--apply requires --fix\n%s", doctorUsageText())
+	}
+	if opts.all {
+		if fs.NArg() != 0 {
+			return doctorOptions{}, 0, fmt.Errorf("conversation ID is not allowed with --all\n%s", doctorUsageText())
+		}
+		return opts, 0, nil
+	}
+	if fs.NArg() != 1 {
+		return doctorOptions{}, 0, fmt.Errorf("conversation ID is required unless --all is used\n%s", doctorUsageText())
+	}
+
+	conversationID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return doctorOptions{}, 0, fmt.Errorf("parse conversation ID %q: %w", fs.Arg(0), err)
+	}
+	return opts, conversationID, nil
+}
+
+func normalizeDoctorArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--fix":
+			flags = append(flags, "--fix=all")
+		case arg == "--tokenizer":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"):
+			flags = append(flags, arg)
+		default:
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func doctorUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui doctor <conversation_id> [--json]
+  lcm-tui doctor --all [--json]
+  lcm-tui doctor <conversation_id>|--all --fix[=<classes>] [--apply] [--json]
+
+Checks the summary DAG and context window for structural problems and exits
+with status 1 when any are found (or, with --fix --apply, remain).
+
+Fix classes (comma-separated; bare --fix selects all):
+  ordinals   Renumber context_items ordinals contiguously
+  context    Delete context items pointing at deleted messages or summaries
+  edges      Delete summary_parents rows pointing at deleted summaries
+  links      Delete summary_messages rows pointing at deleted messages
+  tokens     Recompute drifted summary token_count values
+  pins       Delete pins (set in the TUI) whose item left the context
+
+Token drift compares token_count with --tokenizer (default heuristic); see
+lcm-tui recount for the accepted values.
+
+--fix alone prints the plan (dry run); add --apply to run it in one transaction.
+`)
+}
+
+// runDoctor checks the requested conversations and returns every finding.
+func runDoctor(ctx context.Context, q sqlQueryer, opts doctorOptions, conversationID int64) (doctorReport, error) {
+	conversationIDs := []int64{conversationID}
+	if opts.all {
+		ids, err := loadDoctorConversationIDs(ctx, q)
+		if err != nil {
+			return doctorReport{}, err
+		}
+		conversationIDs = ids
+	} else {
+		exists, err := conversationExists(ctx, q, conversationID)
+		if err != nil {
+			return doctorReport{}, err
+		}
+		if !exists {
+			return doctorReport{}, fmt.Errorf("conversation %d not found", conversationID)
+		}
+	}
+
+	report := doctorReport{
+		Conversations: conversationIDs,
+		Findings:      []doctorFinding{},
+		Counts:        map[string]int{},
+	}
+	for _, id := range conversationIDs {
+		findings, err := diagnoseConversation(ctx, q, id, opts.tokens)
+		if err != nil {
+			return doctorReport{}, err
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	if opts.all {
+		// Edges whose endpoints are both gone belong to no conversation.
+		findings, err := diagnoseUnownedEdges(ctx, q)
+		if err != nil {
+			return doctorReport{}, err
+		}
+		report.Findings = append(report.Findings, findings...)
+	}
+	for _, finding := range report.Findings {
+		report.Counts[finding.Check]++
+	}
+	return report, nil
+}
+
+// loadDoctorConversationIDs returns every conversation ID referenced by the
+// conversations, summaries or context_items tables, so rows that outlived
+// their conversation are still checked.
+func loadDoctorConversationIDs(ctx context.Context, q sqlQueryer) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT conversation_id FROM conversations
+		UNION
+		SELECT conversation_id FROM summaries
+		UNION
+		SELECT conversation_id FROM context_items
+		ORDER BY conversation_id ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query conversation IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan conversation ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate conversation IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// diagnoseConversation loads one conversation's summaries, edges, message
+// links and context items, then runs every check against them.
+func diagnoseConversation(ctx context.Context, q sqlQueryer, conversationID int64, tokens tokenCounter) ([]doctorFinding, error) {
+	summaries, err := loadDoctorSummaries(ctx, q, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := loadDoctorEdges(ctx, q, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	links, err := loadDoctorSummaryMessages(ctx, q, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := loadDoctorContextItems(ctx, q, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	pins, err := loadContextPins(ctx, q, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []doctorFinding
+	findings = append(findings, checkDoctorEdges(conversationID, edges)...)
+	findings = append(findings, checkDoctorCycles(conversationID, edges)...)
+	findings = append(findings, checkDoctorSummaries(conversationID, summaries, edges, links, items, tokens)...)
+	findings = append(findings, checkDoctorSummaryMessages(conversationID, links)...)
+	findings = append(findings, checkDoctorContextItems(conversationID, items)...)
+	findings = append(findings, checkDoctorPins(conversationID, pins, items)...)
+	return findings, nil
+}
+
+func loadDoctorSummaries(ctx context.Context, q sqlQueryer, conversationID int64) ([]doctorSummary, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT summary_id, kind, depth, token_count, content
+		FROM summaries
+		WHERE conversation_id = ?
+		ORDER BY depth ASC, created_at ASC, summary_id ASC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query summaries for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	var summaries []doctorSummary
+	for rows.Next() {
+		var item doctorSummary
+		if err := rows.Scan(&item.summaryID, &item.kind, &item.depth, &item.tokenCount, &item.content); err != nil {
+			return nil, fmt.Errorf("scan summary for conversation %d: %w", conversationID, err)
+		}
+		summaries = append(summaries, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate summaries for conversation %d: %w", conversationID, err)
+	}
+	return summaries, nil
+}
+
+// loadDoctorEdges returns every summary_parents row with at least one
+// endpoint in the conversation.
+func loadDoctorEdges(ctx context.Context, q sqlQueryer, conversationID int64) ([]doctorEdge, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT sp.summary_id, sp.parent_summary_id, cs.conversation_id, ps.conversation_id
+		FROM summary_parents sp
+		LEFT JOIN summaries cs ON cs.summary_id = sp.summary_id
+		LEFT JOIN summaries ps ON ps.summary_id = sp.parent_summary_id
+		WHERE cs.conversation_id = ? OR ps.conversation_id = ?
+		ORDER BY sp.summary_id ASC, sp.ordinal ASC
+	`, conversationID, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query summary edges for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	var edges []doctorEdge
+	for rows.Next() {
+		var edge doctorEdge
+		if err := rows.Scan(&edge.summaryID, &edge.parentSummaryID, &edge.childConversation, &edge.parentConversation); err != nil {
+			return nil, fmt.Errorf("scan summary edge for conversation %d: %w", conversationID, err)
+		}
+		edges = append(edges, edge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate summary edges for conversation %d: %w", conversationID, err)
+	}
+	return edges, nil
+}
+
+func loadDoctorSummaryMessages(ctx context.Context, q sqlQueryer, conversationID int64) ([]doctorSummaryMessage, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT sm.summary_id, sm.message_id, m.conversation_id
+		FROM summary_messages sm
+		JOIN summaries s ON s.summary_id = sm.summary_id
+		LEFT JOIN messages m ON m.message_id = sm.message_id
+		WHERE s.conversation_id = ?
+		ORDER BY sm.summary_id ASC, sm.ordinal ASC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query summary messages for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	var links []doctorSummaryMessage
+	for rows.Next() {
+		var link doctorSummaryMessage
+		if err := rows.Scan(&link.summaryID, &link.messageID, &link.messageConversation); err != nil {
+			return nil, fmt.Errorf("scan summary message for conversation %d: %w", conversationID, err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate summary messages for conversation %d: %w", conversationID, err)
+	}
+	return links, nil
+}
+
+func loadDoctorContextItems(ctx context.Context, q sqlQueryer, conversationID int64) ([]doctorContextItem, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT ci.ordinal, ci.item_type, ci.message_id, ci.summary_id, m.conversation_id, s.conversation_id
+		FROM context_items ci
+		LEFT JOIN messages m ON m.message_id = ci.message_id
+		LEFT JOIN summaries s ON s.summary_id = ci.summary_id
+		WHERE ci.conversation_id = ?
+		ORDER BY ci.ordinal ASC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query context items for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	var items []doctorContextItem
+	for rows.Next() {
+		var item doctorContextItem
+		if err := rows.Scan(
+			&item.ordinal,
+			&item.itemType,
+			&item.messageID,
+			&item.summaryID,
+			&item.messageConversation,
+			&item.summaryConversation,
+		); err != nil {
+			return nil, fmt.Errorf("scan context item for conversation %d: %w", conversationID, err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate context items for conversation %d: %w", conversationID, err)
+	}
+	return items, nil
+}
+
+// checkDoctorEdges reports edges with a missing endpoint and edges whose
+// endpoints live in different conversations. Cross-conversation edges are
+// reported once, by the conversation that owns the condensed summary.
+func checkDoctorEdges(conversationID int64, edges []doctorEdge) []doctorFinding {
+	var findings []doctorFinding
+	for _, edge := range edges {
+		switch {
+		case !edge.childConversation.Valid:
+			findings = append(findings, doctorFinding{
+				Check:           doctorCheckDanglingEdge,
+				ConversationID:  conversationID,
+				SummaryID:       edge.summaryID,
+				ParentSummaryID: edge.parentSummaryID,
+				Detail:          fmt.Sprintf("edge from missing summary %s to %s", edge.summaryID, edge.parentSummaryID),
+				TargetMissing:   true,
+			})
+		case !edge.parentConversation.Valid:
+			findings = append(findings, doctorFinding{
+				Check:           doctorCheckDanglingEdge,
+				ConversationID:  conversationID,
+				SummaryID:       edge.summaryID,
+				ParentSummaryID: edge.parentSummaryID,
+				Detail:          fmt.Sprintf("%s references missing parent summary %s", edge.summaryID, edge.parentSummaryID),
+				TargetMissing:   true,
+			})
+		case edge.childConversation.Int64 != edge.parentConversation.Int64 && edge.childConversation.Int64 == conversationID:
+			findings = append(findings, doctorFinding{
+				Check:           doctorCheckCrossConversationEdge,
+				ConversationID:  conversationID,
+				SummaryID:       edge.summaryID,
+				ParentSummaryID: edge.parentSummaryID,
+				Detail: fmt.Sprintf("%s (conv %d) references %s from conversation %d",
+					edge.summaryID, edge.childConversation.Int64, edge.parentSummaryID, edge.parentConversation.Int64),
+			})
+		}
+	}
+	return findings
+}
+
+// checkDoctorCycles reports each cycle in the summary_parents graph once,
+// starting from its lexically smallest summary ID.
+func checkDoctorCycles(conversationID int64, edges []doctorEdge) []doctorFinding {
+	adjacency := make(map[string][]string)
+	for _, edge := range edges {
+		if edge.childConversation.Valid && edge.parentConversation.Valid {
+			adjacency[edge.summaryID] = append(adjacency[edge.summaryID], edge.parentSummaryID)
+		}
+	}
+	nodes := make([]string, 0, len(adjacency))
+	for id := range adjacency {
+		nodes = append(nodes, id)
+	}
+	sort.Strings(nodes)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(adjacency))
+	seenCycles := make(map[string]bool)
+	var stack []string
+	var findings []doctorFinding
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, next := range adjacency[id] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != next {
+					start--
+				}
+				cycle := canonicalCycle(stack[start:])
+				key := strings.Join(cycle, " ")
+				if seenCycles[key] {
+					continue
+				}
+				seenCycles[key] = true
+				findings = append(findings, doctorFinding{
+					Check:          doctorCheckCycle,
+					ConversationID: conversationID,
+					SummaryID:      cycle[0],
+					Detail:         "cycle " + strings.Join(append(cycle, cycle[0]), " -> "),
+				})
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+	}
+	for _, id := range nodes {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return findings
+}
+
+// canonicalCycle rotates a cycle so it starts at its smallest summary ID.
+func canonicalCycle(cycle []string) []string {
+	minIdx := 0
+	for i, id := range cycle {
+		if id < cycle[minIdx] {
+			minIdx = i
+		}
+	}
+	rotated := make([]string, 0, len(cycle))
+	rotated = append(rotated, cycle[minIdx:]...)
+	return append(rotated, cycle[:minIdx]...)
+}
+
+// checkDoctorSummaries runs the per-summary checks: orphans, missing source
+// links, token drift and corruption markers.
+func checkDoctorSummaries(conversationID int64, summaries []doctorSummary, edges []doctorEdge, links []doctorSummaryMessage, items []doctorContextItem, tokens tokenCounter) []doctorFinding {
+	inContext := make(map[string]bool)
+	for _, item := range items {
+		if item.summaryID.Valid {
+			inContext[item.summaryID.String] = true
+		}
+	}
+	condensedInto := make(map[string]bool)
+	parentCount := make(map[string]int)
+	for _, edge := range edges {
+		condensedInto[edge.parentSummaryID] = true
+		parentCount[edge.summaryID]++
+	}
+	messageCount := make(map[string]int)
+	for _, link := range links {
+		messageCount[link.summaryID]++
+	}
+
+	var findings []doctorFinding
+	for _, summary := range summaries {
+		isLeaf := summary.depth == 0 || strings.EqualFold(summary.kind, "leaf")
+		add := func(check, detail string) {
+			findings = append(findings, doctorFinding{
+				Check:          check,
+				ConversationID: conversationID,
+				SummaryID:      summary.summaryID,
+				Detail:         detail,
+			})
+		}
+
+		if !inContext[summary.summaryID] && !condensedInto[summary.summaryID] {
+			add(doctorCheckOrphanSummary, fmt.Sprintf("%s is not in context and not condensed into any summary", summary.summaryID))
+		}
+		if isLeaf && messageCount[summary.summaryID] == 0 {
+			add(doctorCheckMissingSummaryMessages, fmt.Sprintf("leaf %s has no summary_messages rows", summary.summaryID))
+		}
+		if !isLeaf && parentCount[summary.summaryID] == 0 {
+			add(doctorCheckMissingSummaryParents, fmt.Sprintf("condensed %s (d%d) has no summary_parents rows", summary.summaryID, summary.depth))
+		}
+
+		estimated := tokens.countTokens(summary.content)
+		drift := summary.tokenCount - estimated
+		if drift < 0 {
+			drift = -drift
+		}
+		if drift > doctorTokenDriftMinTokens && float64(drift) > float64(estimated)*doctorTokenDriftRatio {
+			add(doctorCheckTokenDrift, fmt.Sprintf("%s stores %dt but content counts %dt", summary.summaryID, summary.tokenCount, estimated))
+		}
+
+		if strings.Contains(summary.content, corruptedSummaryMarker) {
+			add(doctorCheckCorruptedSummary, fmt.Sprintf("%s %s contains the fallback truncation marker", summary.kind, summary.summaryID))
+		}
+	}
+	return findings
+}
+
+// checkDoctorSummaryMessages reports links to messages that no longer exist.
+// Links to another conversation's messages are valid: transplant leaves its
+// copies pointing at the source conversation's messages.
+func checkDoctorSummaryMessages(conversationID int64, links []doctorSummaryMessage) []doctorFinding {
+	var findings []doctorFinding
+	for _, link := range links {
+		if link.messageConversation.Valid {
+			continue
+		}
+		findings = append(findings, doctorFinding{
+			Check:          doctorCheckDanglingSummaryMessage,
+			ConversationID: conversationID,
+			SummaryID:      link.summaryID,
+			MessageID:      link.messageID,
+			Detail:         fmt.Sprintf("%s references missing message #%d", link.summaryID, link.messageID),
+			TargetMissing:  true,
+		})
+	}
+	return findings
+}
+
+// checkDoctorContextItems reports rows pointing at missing or foreign targets,
+// and ordinals that are not the contiguous sequence 0..n-1.
+func checkDoctorContextItems(conversationID int64, items []doctorContextItem) []doctorFinding {
+	var findings []doctorFinding
+	add := func(check string, ordinal int64, item doctorContextItem, missing bool, detail string) {
+		finding := doctorFinding{
+			Check:          check,
+			ConversationID: conversationID,
+			Ordinal:        &ordinal,
+			Detail:         detail,
+			TargetMissing:  missing,
+		}
+		if item.summaryID.Valid {
+			finding.SummaryID = item.summaryID.String
+		}
+		if item.messageID.Valid {
+			finding.MessageID = item.messageID.Int64
+		}
+		findings = append(findings, finding)
+	}
+
+	for _, item := range items {
+		switch item.itemType {
+		case "summary":
+			switch {
+			case !item.summaryID.Valid:
+				add(doctorCheckDanglingContextItem, item.ordinal, item, true, fmt.Sprintf("summary item at ordinal %d has no summary_id", item.ordinal))
+			case !item.summaryConversation.Valid:
+				add(doctorCheckDanglingContextItem, item.ordinal, item, true, fmt.Sprintf("ordinal %d references missing summary %s", item.ordinal, item.summaryID.String))
+			case item.summaryConversation.Int64 != conversationID:
+				add(doctorCheckDanglingContextItem, item.ordinal, item, false, fmt.Sprintf("ordinal %d references %s from conversation %d", item.ordinal, item.summaryID.String, item.summaryConversation.Int64))
+			}
+		case "message":
+			switch {
+			case !item.messageID.Valid:
+				add(doctorCheckDanglingContextItem, item.ordinal, item, true, fmt.Sprintf("message item at ordinal %d has no message_id", item.ordinal))
+			case !item.messageConversation.Valid:
+				add(doctorCheckDanglingContextItem, item.ordinal, item, true, fmt.Sprintf("ordinal %d references missing message #%d", item.ordinal, item.messageID.Int64))
+			case item.messageConversation.Int64 != conversationID:
+				add(doctorCheckDanglingContextItem, item.ordinal, item, false, fmt.Sprintf("ordinal %d references message #%d from conversation %d", item.ordinal, item.messageID.Int64, item.messageConversation.Int64))
+			}
+		}
+	}
+
+	expected := int64(0)
+	for i, item := range items {
+		if i > 0 && item.ordinal == items[i-1].ordinal {
+			add(doctorCheckOrdinalDuplicate, item.ordinal, item, false, fmt.Sprintf("ordinal %d is used more than once", item.ordinal))
+			continue
+		}
+		if item.ordinal != expected {
+			add(doctorCheckOrdinalGap, item.ordinal, item, false, fmt.Sprintf("expected ordinal %d, found %d", expected, item.ordinal))
+		}
+		expected = item.ordinal + 1
+	}
+	return findings
+}
+
+// checkDoctorPins reports pinned items that are no longer in the context
+// window, usually because compaction folded them into a summary.
+func checkDoctorPins(conversationID int64, pins []contextPin, items []doctorContextItem) []doctorFinding {
+	inContext := make(map[string]bool, len(items))
+	for _, item := range items {
+		inContext[contextPinKey(item.itemType, item.summaryID.String, item.messageID.Int64)] = true
+	}
+	var findings []doctorFinding
+	for _, pin := range pins {
+		if inContext[pin.key()] {
+			continue
+		}
+		finding := doctorFinding{
+			Check:          doctorCheckPinnedItemRemoved,
+			ConversationID: conversationID,
+			TargetMissing:  true,
+		}
+		if pin.itemType == "summary" {
+			finding.SummaryID = pin.summaryID.String
+			finding.Detail = fmt.Sprintf("pinned summary %s (pinned %s) is no longer in context", pin.summaryID.String, pin.pinnedAt)
+		} else {
+			finding.MessageID = pin.messageID.Int64
+			finding.Detail = fmt.Sprintf("pinned message #%d (pinned %s) is no longer in context", pin.messageID.Int64, pin.pinnedAt)
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// diagnoseUnownedEdges reports summary_parents rows whose endpoints have both
+// been deleted, which no per-conversation scan can attribute.
+func diagnoseUnownedEdges(ctx context.Context, q sqlQueryer) ([]doctorFinding, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT sp.summary_id, sp.parent_summary_id
+		FROM summary_parents sp
+		LEFT JOIN summaries cs ON cs.summary_id = sp.summary_id
+		LEFT JOIN summaries ps ON ps.summary_id = sp.parent_summary_id
+		WHERE cs.summary_id IS NULL AND ps.summary_id IS NULL
+		ORDER BY sp.summary_id ASC, sp.ordinal ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query unowned summary edges: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []doctorFinding
+	for rows.Next() {
+		var finding doctorFinding
+		if err := rows.Scan(&finding.SummaryID, &finding.ParentSummaryID); err != nil {
+			return nil, fmt.Errorf("scan unowned summary edge: %w", err)
+		}
+		finding.Check = doctorCheckDanglingEdge
+		finding.Detail = fmt.Sprintf("edge between missing summaries %s and %s", finding.SummaryID, finding.ParentSummaryID)
+		finding.TargetMissing = true
+		findings = append(findings, finding)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate unowned summary edges: %w", err)
+	}
+	return findings, nil
+}
+
+func printDoctorReport(report doctorReport) {
+	fmt.Printf("Checked %d conversations.\n", len(report.Conversations))
+	if len(report.Findings) == 0 {
+		fmt.Println("No problems found.")
+		return
+	}
+
+	byConversation := make(map[int64][]doctorFinding)
+	var conversationIDs []int64
+	for _, finding := range report.Findings {
+		if _, seen := byConversation[finding.ConversationID]; !seen {
+			conversationIDs = append(conversationIDs, finding.ConversationID)
+		}
+		byConversation[finding.ConversationID] = append(byConversation[finding.ConversationID], finding)
+	}
+	sort.Slice(conversationIDs, func(i, j int) bool { return conversationIDs[i] < conversationIDs[j] })
+
+	for _, conversationID := range conversationIDs {
+		findings := byConversation[conversationID]
+		fmt.Println()
+		if conversationID == 0 {
+			fmt.Printf("Unattributed: %d problems\n", len(findings))
+		} else {
+			fmt.Printf("Conversation %d: %d problems\n", conversationID, len(findings))
+		}
+		for _, finding := range findings {
+			fmt.Printf("  %-25s %s\n", finding.Check, finding.Detail)
+		}
+	}
+
+	checks := make([]string, 0, len(report.Counts))
+	for check := range report.Counts {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+	parts := make([]string, 0, len(checks))
+	for _, check := range checks {
+		parts = append(parts, fmt.Sprintf("%d %s", report.Counts[check], check))
+	}
+	fmt.Printf("\nFound %d problems: %s\n", len(report.Findings), strings.Join(parts, ", "))
+}
+
+// doctorTokenFix recomputes one summary's stored token count.
+type doctorTokenFix struct {
+	summaryID string
+	from      int
+	to        int
+}
+
+// doctorFixPlan lists the row-level changes --fix will make. It is built from
+// a doctor report, so only rows that doctor flagged are touched.
+type doctorFixPlan struct {
+	classes      doctorFixClasses
+	contextItems []doctorFinding
+	edges        []doctorFinding
+	links        []doctorFinding
+	tokens       []doctorTokenFix
+	pins         []doctorFinding
+	renumber     []int64 // conversations whose context ordinals are rewritten
+}
+
+// changes returns the number of planned changes per fix class.
+func (p doctorFixPlan) changes() map[string]int {
+	changes := make(map[string]int)
+	for _, name := range p.classes.names() {
+		changes[name] = 0
+	}
+	changes[doctorFixContext] += len(p.contextItems)
+	changes[doctorFixEdges] += len(p.edges)
+	changes[doctorFixLinks] += len(p.links)
+	changes[doctorFixTokens] += len(p.tokens)
+	changes[doctorFixPins] += len(p.pins)
+	changes[doctorFixOrdinals] += len(p.renumber)
+	for name, count := range changes {
+		if !p.classes[name] && count == 0 {
+			delete(changes, name)
+		}
+	}
+	return changes
+}
+
+func (p doctorFixPlan) total() int {
+	return len(p.contextItems) + len(p.edges) + len(p.links) + len(p.tokens) + len(p.pins) + len(p.renumber)
+}
+
+// doctorFixResult is the --fix --json output.
+type doctorFixResult struct {
+	Classes []string       `json:"classes"`
+	Applied bool           `json:"applied"`
+	Changes map[string]int `json:"changes"`
+	Backup  string         `json:"backup,omitempty"`
+	Before  doctorReport   `json:"before"`
+	After   *doctorReport  `json:"after,omitempty"`
+}
+
+// runDoctorFix plans fixes for the report's findings and, with --apply,
+// executes them and re-checks inside the same transaction.
+func runDoctorFix(ctx context.Context, db *sql.DB, backupsDir string, opts doctorOptions, conversationID int64, before doctorReport) error {
+	plan, err := buildDoctorFixPlan(ctx, db, before, opts.fix, opts.tokens)
+	if err != nil {
+		return err
+	}
+
+	result := doctorFixResult{
+		Classes: opts.fix.names(),
+		Changes: plan.changes(),
+		Before:  before,
+	}
+	if opts.apply && plan.total() > 0 {
+		label := fmt.Sprintf("doctor-fix-conv%d", conversationID)
+		if opts.all {
+			label = "doctor-fix-all"
+		}
+		entry, err := snapshotLCMDB(ctx, db, backupsDir, label)
+		if err != nil {
+			return fmt.Errorf("backup before apply: %w", err)
+		}
+		result.Backup = entry.path
+		after, err := applyDoctorFixPlan(ctx, db, plan, opts, conversationID)
+		if err != nil {
+			return err
+		}
+		result.Applied = true
+		result.After = &after
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return fmt.Errorf("encode doctor fix result: %w", err)
+		}
+	} else {
+		printDoctorFixPlan(plan, before)
+		switch {
+		case result.After != nil:
+			fmt.Printf("\nBacked up lcm.db to %s\n", result.Backup)
+			printDoctorBeforeAfter(before, *result.After)
+		case plan.total() == 0:
+			fmt.Println("\nNothing to fix.")
+		default:
+			fmt.Println("\nDry run. Use --apply to execute.")
+		}
+	}
+
+	remaining := before
+	if result.After != nil {
+		remaining = *result.After
+	}
+	if len(remaining.Findings) > 0 {
+		return errDoctorFindings
+	}
+	return nil
+}
+
+// buildDoctorFixPlan selects the findings each enabled class can fix. Rows
+// that exist but sit in the wrong conversation are left alone: deleting them
+// would lose data rather than repair a reference.
+func buildDoctorFixPlan(ctx context.Context, q sqlQueryer, report doctorReport, classes doctorFixClasses, tokens tokenCounter) (doctorFixPlan, error) {
+	plan := doctorFixPlan{classes: classes}
+	renumber := make(map[int64]bool)
+	seenEdges := make(map[[2]string]bool)
+
+	for _, finding := range report.Findings {
+		switch finding.Check {
+		case doctorCheckDanglingContextItem:
+			if classes[doctorFixContext] && finding.TargetMissing {
+				plan.contextItems = append(plan.contextItems, finding)
+				if classes[doctorFixOrdinals] {
+					renumber[finding.ConversationID] = true
+				}
+			}
+		case doctorCheckOrdinalGap, doctorCheckOrdinalDuplicate:
+			if classes[doctorFixOrdinals] {
+				renumber[finding.ConversationID] = true
+			}
+		case doctorCheckDanglingEdge:
+			// Edges with one endpoint in each of two conversations are
+			// reported once per side.
+			key := [2]string{finding.SummaryID, finding.ParentSummaryID}
+			if classes[doctorFixEdges] && !seenEdges[key] {
+				seenEdges[key] = true
+				plan.edges = append(plan.edges, finding)
+			}
+		case doctorCheckDanglingSummaryMessage:
+			if classes[doctorFixLinks] && finding.TargetMissing {
+				plan.links = append(plan.links, finding)
+			}
+		case doctorCheckPinnedItemRemoved:
+			if classes[doctorFixPins] {
+				plan.pins = append(plan.pins, finding)
+			}
+		case doctorCheckTokenDrift:
+			if !classes[doctorFixTokens] {
+				continue
+			}
+			var stored int
+			var content string
+			if err := q.QueryRowContext(ctx, `
+				SELECT token_count, content FROM summaries WHERE summary_id = ?
+			`, finding.SummaryID).Scan(&stored, &content); err != nil {
+				return doctorFixPlan{}, fmt.Errorf("load summary %s for token fix: %w", finding.SummaryID, err)
+			}
+			plan.tokens = append(plan.tokens, doctorTokenFix{
+				summaryID: finding.SummaryID,
+				from:      stored,
+				to:        tokens.countTokens(content),
+			})
+		}
+	}
+
+	for conversationID := range renumber {
+		plan.renumber = append(plan.renumber, conversationID)
+	}
+	sort.Slice(plan.renumber, func(i, j int) bool { return plan.renumber[i] < plan.renumber[j] })
+	return plan, nil
+}
+
+// applyDoctorFixPlan runs every planned fix in one transaction, then re-runs
+// the checks against the uncommitted state so the caller can report what is
+// left. Nothing is committed if any step fails.
+func applyDoctorFixPlan(ctx context.Context, db *sql.DB, plan doctorFixPlan, opts doctorOptions, conversationID int64) (doctorReport, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return doctorReport{}, fmt.Errorf("begin doctor fix transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, item := range plan.contextItems {
+		var messageID, summaryID any
+		if item.MessageID != 0 {
+			messageID = item.MessageID
+		}
+		if item.SummaryID != "" {
+			summaryID = item.SummaryID
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM context_items
+			WHERE conversation_id = ? AND ordinal = ? AND message_id IS ? AND summary_id IS ?
+		`, item.ConversationID, *item.Ordinal, messageID, summaryID); err != nil {
+			return doctorReport{}, fmt.Errorf("delete context item %d/%d: %w", item.ConversationID, *item.Ordinal, err)
+		}
+	}
+
+	for _, edge := range plan.edges {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM summary_parents WHERE summary_id = ? AND parent_summary_id = ?
+		`, edge.SummaryID, edge.ParentSummaryID); err != nil {
+			return doctorReport{}, fmt.Errorf("delete edge %s -> %s: %w", edge.SummaryID, edge.ParentSummaryID, err)
+		}
+	}
+
+	for _, link := range plan.links {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM summary_messages WHERE summary_id = ? AND message_id = ?
+		`, link.SummaryID, link.MessageID); err != nil {
+			return doctorReport{}, fmt.Errorf("delete summary message %s -> #%d: %w", link.SummaryID, link.MessageID, err)
+		}
+	}
+
+	for _, fix := range plan.tokens {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE summaries SET token_count = ? WHERE summary_id = ?
+		`, fix.to, fix.summaryID); err != nil {
+			return doctorReport{}, fmt.Errorf("update token count for %s: %w", fix.summaryID, err)
+		}
+	}
+
+	for _, pin := range plan.pins {
+		var messageID, summaryID any
+		if pin.MessageID != 0 {
+			messageID = pin.MessageID
+		}
+		if pin.SummaryID != "" {
+			summaryID = pin.SummaryID
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM `+contextPinsTable+`
+			WHERE conversation_id = ? AND summary_id IS ? AND message_id IS ?
+		`, pin.ConversationID, summaryID, messageID); err != nil {
+			return doctorReport{}, fmt.Errorf("delete pin in conversation %d: %w", pin.ConversationID, err)
+		}
+	}
+
+	for _, id := range plan.renumber {
+		if err := renumberContextOrdinals(ctx, tx, id); err != nil {
+			return doctorReport{}, err
+		}
+	}
+
+	after, err := runDoctor(ctx, tx, opts, conversationID)
+	if err != nil {
+		return doctorReport{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return doctorReport{}, fmt.Errorf("commit doctor fixes: %w", err)
+	}
+	rollback = false
+	return after, nil
+}
+
+// renumberContextOrdinals rewrites a conversation's context ordinals to
+// 0..n-1, keeping the current order. Duplicate ordinals keep insertion order.
+func renumberContextOrdinals(ctx context.Context, tx *sql.Tx, conversationID int64) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT rowid FROM context_items
+		WHERE conversation_id = ?
+		ORDER BY ordinal ASC, rowid ASC
+	`, conversationID)
+	if err != nil {
+		return fmt.Errorf("query context items to renumber in conversation %d: %w", conversationID, err)
+	}
+	var rowIDs []int64
+	for rows.Next() {
+		var rowID int64
+		if err := rows.Scan(&rowID); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan context item rowid: %w", err)
+		}
+		rowIDs = append(rowIDs, rowID)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("iterate context items to renumber: %w", err)
+	}
+	rows.Close()
+
+	const tempOffset = 10_000_000
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE context_items SET ordinal = ordinal + ? WHERE conversation_id = ?
+	`, tempOffset, conversationID); err != nil {
+		return fmt.Errorf("shift context items to temp ordinals: %w", err)
+	}
+	for i, rowID := range rowIDs {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE context_items SET ordinal = ? WHERE rowid = ?
+		`, i, rowID); err != nil {
+			return fmt.Errorf("renumber context item to ordinal %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func printDoctorFixPlan(plan doctorFixPlan, before doctorReport) {
+	fmt.Printf("Doctor fix plan (%s) for %d conversations:\n", strings.Join(plan.classes.names(), ", "), len(before.Conversations))
+	if plan.total() == 0 {
+		fmt.Println("  (no fixable findings)")
+	}
+	for _, item := range plan.contextItems {
+		fmt.Printf("  %-9s delete conv %d ordinal %d: %s\n", doctorFixContext, item.ConversationID, *item.Ordinal, item.Detail)
+	}
+	for _, edge := range plan.edges {
+		fmt.Printf("  %-9s delete %s -> %s\n", doctorFixEdges, edge.SummaryID, edge.ParentSummaryID)
+	}
+	for _, link := range plan.links {
+		fmt.Printf("  %-9s delete %s -> message #%d\n", doctorFixLinks, link.SummaryID, link.MessageID)
+	}
+	for _, fix := range plan.tokens {
+		fmt.Printf("  %-9s %s %dt -> %dt\n", doctorFixTokens, fix.summaryID, fix.from, fix.to)
+	}
+	for _, pin := range plan.pins {
+		fmt.Printf("  %-9s delete pin: %s\n", doctorFixPins, pin.Detail)
+	}
+	for _, id := range plan.renumber {
+		fmt.Printf("  %-9s renumber conversation %d\n", doctorFixOrdinals, id)
+	}
+}
+
+// printDoctorBeforeAfter prints per-check finding counts around a fix.
+func printDoctorBeforeAfter(before, after doctorReport) {
+	checks := make(map[string]bool)
+	for check := range before.Counts {
+		checks[check] = true
+	}
+	for check := range after.Counts {
+		checks[check] = true
+	}
+	names := make([]string, 0, len(checks))
+	for check := range checks {
+		names = append(names, check)
+	}
+	sort.Strings(names)
+
+	fmt.Println("\nApplied in one transaction.")
+	fmt.Printf("\n  %-25s %6s %6s\n", "check", "before", "after")
+	for _, check := range names {
+		fmt.Printf("  %-25s %6d %6d\n", check, before.Counts[check], after.Counts[check])
+	}
+	fmt.Printf("  %-25s %6d %6d\n", "total", len(before.Findings), len(after.Findings))
+}
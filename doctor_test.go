@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckDoctorSummaryMessagesAllowsTransplantedLinks(t *testing.T) {
+	links := []doctorSummaryMessage{
+		{summaryID: "sum_own", messageID: 1, messageConversation: sql.NullInt64{Int64: 2, Valid: true}},
+		{summaryID: "sum_copy", messageID: 7, messageConversation: sql.NullInt64{Int64: 1, Valid: true}},
+		{summaryID: "sum_gone", messageID: 9},
+	}
+	findings := checkDoctorSummaryMessages(2, links)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(findings), findings)
+	}
+	if got := findings[0]; got.SummaryID != "sum_gone" || got.MessageID != 9 || !got.TargetMissing {
+		t.Fatalf("finding = %+v, want sum_gone -> missing #9", got)
+	}
+}
+
+// doctorFixTestFixture breaks conversation 1 of journalTestFixture: message
+// 5 moves to ordinal 4 and a missing message sits at ordinal 6, leaving two
+// gaps, message 3 duplicates ordinal 1, and sum_c gains an edge to a summary
+// that does not exist.
+const doctorFixTestFixture = `
+UPDATE context_items SET ordinal = 4 WHERE conversation_id = 1 AND message_id = 5;
+INSERT INTO context_items (conversation_id, ordinal, item_type, message_id, created_at) VALUES
+	(1, 1, 'message', 3, '2026-01-01 00:06:00'),
+	(1, 6, 'message', 99, '2026-01-01 00:07:00');
+INSERT INTO summary_parents VALUES ('sum_c', 'sum_gone', 2);
+`
+
+func TestDoctorFixRenumbersAndDropsDanglingRows(t *testing.T) {
+	ctx := context.Background()
+	// Duplicate ordinals need a context_items table without the
+	// (conversation_id, ordinal) key, as in databases doctor repairs.
+	schema := strings.Replace(repairTestSchema, ",\n\tPRIMARY KEY (conversation_id, ordinal)", "", 1)
+	path := filepath.Join(t.TempDir(), "lcm.db")
+	create, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := create.Exec(schema + journalTestFixture + doctorFixTestFixture); err != nil {
+		t.Fatal(err)
+	}
+	if err := create.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := openLCMDB(path, dbReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var classes doctorFixClasses
+	if err := classes.Set("all"); err != nil {
+		t.Fatal(err)
+	}
+	opts := doctorOptions{fix: classes, apply: true, tokenizer: tokenizerHeuristic, tokens: heuristicCounter{}}
+	before, err := runDoctor(ctx, db, opts, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for check, want := range map[string]int{
+		doctorCheckOrdinalGap:          2,
+		doctorCheckOrdinalDuplicate:    1,
+		doctorCheckDanglingContextItem: 1,
+		doctorCheckDanglingEdge:        1,
+	} {
+		if got := before.Counts[check]; got != want {
+			t.Errorf("before fix: %d %s findings, want %d", got, check, want)
+		}
+	}
+
+	plan, err := buildDoctorFixPlan(ctx, db, before, classes, opts.tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := plan.changes()
+	for class, want := range map[string]int{
+		doctorFixOrdinals: 1,
+		doctorFixContext:  1,
+		doctorFixEdges:    1,
+	} {
+		if got := changes[class]; got != want {
+			t.Errorf("plan has %d %s changes, want %d", got, class, want)
+		}
+	}
+
+	after, err := applyDoctorFixPlan(ctx, db, plan, opts, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Findings) != 0 {
+		t.Fatalf("after fix: %+v, want no findings", after.Findings)
+	}
+
+	rows, err := db.Query(`
+		SELECT ordinal, COALESCE(summary_id, 'msg ' || message_id)
+		FROM context_items WHERE conversation_id = 1 ORDER BY ordinal`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var ordinal int64
+		var item string
+		if err := rows.Scan(&ordinal, &item); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d:%s", ordinal, item))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"0:sum_c", "1:msg 4", "2:msg 3", "3:msg 5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("context after fix = %v, want %v", got, want)
+	}
+
+	var edges int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM summary_parents WHERE parent_summary_id = 'sum_gone'`).Scan(&edges); err != nil {
+		t.Fatal(err)
+	}
+	if edges != 0 {
+		t.Fatalf("dangling edge survived the fix")
+	}
+}
@@ -0,0 +1,750 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The bundle format is documented in specs/export-bundle.md. Bump the
+// version whenever a field changes meaning or goes away; adding fields does
+// not need a bump.
+const (
+	exportBundleFormat  = "lcm-tui-bundle"
+	exportBundleVersion = 1
+)
+
+var exportFormats = []string{"json", "markdown", "html"}
+
+type exportOptions struct {
+	conversationID int64
+	format         string
+	output         string
+	includeSession bool
+}
+
+// exportBundle is the portable JSON form of one conversation's LCM memory.
+type exportBundle struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	ExportedAt string `json:"exported_at"`
+
+	// Conversation is the conversations row as stored, column for column.
+	Conversation    journalRow          `json:"conversation"`
+	Summaries       []exportSummary     `json:"summaries"`
+	Roots           []string            `json:"roots"`
+	SummaryParents  []exportEdge        `json:"summary_parents"`
+	SummaryMessages []exportLink        `json:"summary_messages"`
+	Messages        []journalRow        `json:"messages"`
+	ContextItems    []exportContextItem `json:"context_items"`
+	LargeFiles      []exportLargeFile   `json:"large_files"`
+	Session         *exportSession      `json:"session,omitempty"`
+}
+
+// exportSummary is one summaries row plus its place in the DAG. Children
+// are the summaries it condenses, in display order.
+type exportSummary struct {
+	SummaryID     string   `json:"summary_id"`
+	Kind          string   `json:"kind"`
+	Depth         int      `json:"depth"`
+	TokenCount    int      `json:"token_count"`
+	CreatedAt     string   `json:"created_at"`
+	FileIDs       string   `json:"file_ids,omitempty"`
+	Content       string   `json:"content"`
+	ContentSHA256 string   `json:"content_sha256"`
+	Children      []string `json:"children,omitempty"`
+}
+
+// exportEdge is one summary_parents row: SummaryID condenses
+// ParentSummaryID.
+type exportEdge struct {
+	SummaryID       string `json:"summary_id"`
+	ParentSummaryID string `json:"parent_summary_id"`
+	Ordinal         int64  `json:"ordinal"`
+}
+
+type exportLink struct {
+	SummaryID string `json:"summary_id"`
+	MessageID int64  `json:"message_id"`
+	Ordinal   int64  `json:"ordinal"`
+}
+
+type exportContextItem struct {
+	Ordinal   int    `json:"ordinal"`
+	ItemType  string `json:"item_type"`
+	SummaryID string `json:"summary_id,omitempty"`
+	MessageID int64  `json:"message_id,omitempty"`
+	Pinned    bool   `json:"pinned,omitempty"`
+}
+
+type exportLargeFile struct {
+	FileID             string `json:"file_id"`
+	FileName           string `json:"file_name,omitempty"`
+	MimeType           string `json:"mime_type,omitempty"`
+	ByteSize           int64  `json:"byte_size,omitempty"`
+	StorageURI         string `json:"storage_uri"`
+	ExplorationSummary string `json:"exploration_summary,omitempty"`
+	CreatedAt          string `json:"created_at"`
+}
+
+// exportSession carries the raw session transcript when --include-session
+// is given.
+type exportSession struct {
+	SessionID string `json:"session_id"`
+	Path      string `json:"path"`
+	JSONL     string `json:"jsonl"`
+}
+
+// runExportCommand executes the standalone export CLI path.
+func runExportCommand(args []string) error {
+	opts, err := parseExportArgs(args)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, dbReadOnly)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	bundle, err := buildExportBundle(ctx, db, opts.conversationID)
+	if err != nil {
+		return err
+	}
+	if opts.includeSession {
+		session, err := loadExportSession(paths, bundle.Conversation)
+		if err != nil {
+			return err
+		}
+		bundle.Session = session
+	}
+
+	out := io.Writer(os.Stdout)
+	if opts.output != "" && opts.output != "-" {
+		file, err := os.Create(opts.output)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", opts.output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := writeExportBundle(out, bundle, opts.format); err != nil {
+		return err
+	}
+	if opts.output != "" && opts.output != "-" {
+		fmt.Fprintf(os.Stderr, "Exported conversation %d (%d summaries, %d context items) to %s\n",
+			opts.conversationID, len(bundle.Summaries), len(bundle.ContextItems), opts.output)
+	}
+	return nil
+}
+
+func parseExportArgs(args []string) (exportOptions, error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	format := fs.String("format", "json", "bundle format: json, markdown or html")
+	output := fs.String("output", "", "write the bundle to this file instead of stdout")
+	includeSession := fs.Bool("include-session", false, "embed the raw session JSONL")
+
+	normalized, err := normalizeExportArgs(args)
+	if err != nil {
+		return exportOptions{}, fmt.Errorf("%w\n%s", err, exportUsageText())
+	}
+	if err := fs.Parse(normalized); err != nil {
+		return exportOptions{}, fmt.Errorf("%w\n%s", err, exportUsageText())
+	}
+	if fs.NArg() != 1 {
+		return exportOptions{}, fmt.Errorf("exactly one conversation ID is required\n%s", exportUsageText())
+	}
+	conversationID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return exportOptions{}, fmt.Errorf("parse conversation ID %q: %w", fs.Arg(0), err)
+	}
+
+	opts := exportOptions{
+		conversationID: conversationID,
+		format:         strings.ToLower(strings.TrimSpace(*format)),
+		output:         strings.TrimSpace(*output),
+		includeSession: *includeSession,
+	}
+	if opts.format == "md" {
+		opts.format = "markdown"
+	}
+	known := false
+	for _, name := range exportFormats {
+		known = known || name == opts.format
+	}
+	if !known {
+		return exportOptions{}, fmt.Errorf("unknown --format %q (want %s)\n%s", *format, strings.Join(exportFormats, ", "), exportUsageText())
+	}
+	return opts, nil
+}
+
+func normalizeExportArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--include-session", "--help", "-h":
+			flags = append(flags, arg)
+		case "--format", "--output", "-o":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			if arg == "-o" {
+				arg = "--output"
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		default:
+			if strings.HasPrefix(arg, "--") {
+				flags = append(flags, arg)
+				continue
+			}
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func exportUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui export <conversation_id> [--format json|markdown|html] [--output FILE] [--include-session]
+
+Writes one conversation's LCM memory as a portable bundle: the conversation
+row, the summary DAG with its edges and message links, the linked messages,
+the context items, and large-file metadata. --include-session also embeds the
+raw session JSONL. json is the versioned machine format, described in
+specs/export-bundle.md; markdown and html are for reading. The bundle goes
+to stdout unless --output is given.
+`)
+}
+
+// buildExportBundle gathers everything the bundle holds for one
+// conversation. The DAG comes from the same loaders as the summary view;
+// content is re-read raw, since the view's copy is sanitized for the
+// terminal.
+func buildExportBundle(ctx context.Context, db *sql.DB, conversationID int64) (exportBundle, error) {
+	conversation, err := captureRows(ctx, db, "conversations", "conversation_id = ?", conversationID)
+	if err != nil {
+		return exportBundle{}, err
+	}
+	if len(conversation) == 0 {
+		return exportBundle{}, fmt.Errorf("conversation %d not found", conversationID)
+	}
+	bundle := exportBundle{
+		Format:          exportBundleFormat,
+		Version:         exportBundleVersion,
+		ExportedAt:      time.Now().UTC().Format(time.RFC3339),
+		Conversation:    conversation[0],
+		Summaries:       []exportSummary{},
+		Roots:           []string{},
+		SummaryParents:  []exportEdge{},
+		SummaryMessages: []exportLink{},
+		Messages:        []journalRow{},
+		ContextItems:    []exportContextItem{},
+		LargeFiles:      []exportLargeFile{},
+	}
+
+	nodes, err := loadSummaryNodes(ctx, db, conversationID)
+	if err != nil {
+		return exportBundle{}, err
+	}
+	childSet, err := populateSummaryChildren(ctx, db, conversationID, nodes)
+	if err != nil {
+		return exportBundle{}, err
+	}
+	ids := make([]string, 0, len(nodes))
+	for id, node := range nodes {
+		ids = append(ids, id)
+		sortSummaryIDs(node.children, nodes)
+	}
+	sortSummaryIDs(ids, nodes)
+	if len(nodes) > 0 {
+		bundle.Roots = findSummaryRoots(nodes, childSet)
+		sortSummaryIDs(bundle.Roots, nodes)
+	}
+
+	raw, err := loadSummariesByIDs(ctx, db, ids)
+	if err != nil {
+		return exportBundle{}, err
+	}
+	rawByID := make(map[string]transplantSummary, len(raw))
+	for _, summary := range raw {
+		rawByID[summary.summaryID] = summary
+	}
+	for _, id := range ids {
+		node := nodes[id]
+		summary := rawByID[id]
+		bundle.Summaries = append(bundle.Summaries, exportSummary{
+			SummaryID:     id,
+			Kind:          node.kind,
+			Depth:         node.depth,
+			TokenCount:    node.tokenCount,
+			CreatedAt:     node.createdAt,
+			FileIDs:       summary.fileIDs,
+			Content:       summary.content,
+			ContentSHA256: contentSHA256(summary.content),
+			Children:      node.children,
+		})
+	}
+
+	if bundle.SummaryParents, err = loadExportEdges(ctx, db, conversationID); err != nil {
+		return exportBundle{}, err
+	}
+	if bundle.SummaryMessages, err = loadExportLinks(ctx, db, conversationID); err != nil {
+		return exportBundle{}, err
+	}
+
+	sessionID := fmt.Sprint(bundle.Conversation["session_id"])
+	items, err := loadExportContextItems(ctx, db, conversationID)
+	if err != nil {
+		return exportBundle{}, err
+	}
+	bundle.ContextItems = items
+
+	messageIDs := make(map[int64]bool)
+	for _, link := range bundle.SummaryMessages {
+		messageIDs[link.MessageID] = true
+	}
+	for _, item := range bundle.ContextItems {
+		if item.ItemType == "message" {
+			messageIDs[item.MessageID] = true
+		}
+	}
+	sortedIDs := make([]int64, 0, len(messageIDs))
+	for id := range messageIDs {
+		sortedIDs = append(sortedIDs, id)
+	}
+	sort.Slice(sortedIDs, func(i, j int) bool { return sortedIDs[i] < sortedIDs[j] })
+	messages, err := captureRowsByIDs(ctx, db, "messages", "message_id", sortedIDs)
+	if err != nil {
+		return exportBundle{}, err
+	}
+	if messages != nil {
+		bundle.Messages = messages
+	}
+
+	files, err := loadLargeFiles(ctx, db, sessionID)
+	if err != nil {
+		return exportBundle{}, err
+	}
+	for _, file := range files {
+		if file.conversationID != conversationID {
+			continue
+		}
+		bundle.LargeFiles = append(bundle.LargeFiles, exportLargeFile{
+			FileID:             file.fileID,
+			FileName:           file.fileName,
+			MimeType:           file.mimeType,
+			ByteSize:           file.byteSize,
+			StorageURI:         file.storageURI,
+			ExplorationSummary: file.explorationSummary,
+			CreatedAt:          file.createdAt,
+		})
+	}
+	return bundle, nil
+}
+
+func loadExportEdges(ctx context.Context, db *sql.DB, conversationID int64) ([]exportEdge, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT sp.summary_id, sp.parent_summary_id, sp.ordinal
+		FROM summary_parents sp
+		JOIN summaries s ON s.summary_id = sp.summary_id
+		WHERE s.conversation_id = ?
+		ORDER BY sp.summary_id, sp.ordinal
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query summary edges for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	edges := []exportEdge{}
+	for rows.Next() {
+		var edge exportEdge
+		if err := rows.Scan(&edge.SummaryID, &edge.ParentSummaryID, &edge.Ordinal); err != nil {
+			return nil, fmt.Errorf("scan summary edge: %w", err)
+		}
+		edges = append(edges, edge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate summary edges: %w", err)
+	}
+	return edges, nil
+}
+
+func loadExportLinks(ctx context.Context, db *sql.DB, conversationID int64) ([]exportLink, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT sm.summary_id, sm.message_id, sm.ordinal
+		FROM summary_messages sm
+		JOIN summaries s ON s.summary_id = sm.summary_id
+		WHERE s.conversation_id = ?
+		ORDER BY sm.summary_id, sm.ordinal
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query summary messages for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	links := []exportLink{}
+	for rows.Next() {
+		var link exportLink
+		if err := rows.Scan(&link.SummaryID, &link.MessageID, &link.Ordinal); err != nil {
+			return nil, fmt.Errorf("scan summary message: %w", err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate summary messages: %w", err)
+	}
+	return links, nil
+}
+
+func loadExportContextItems(ctx context.Context, db *sql.DB, conversationID int64) ([]exportContextItem, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT ordinal, item_type, summary_id, message_id
+		FROM context_items
+		WHERE conversation_id = ?
+		ORDER BY ordinal
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query context items for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	items := []exportContextItem{}
+	for rows.Next() {
+		var item exportContextItem
+		var summaryID sql.NullString
+		var messageID sql.NullInt64
+		if err := rows.Scan(&item.Ordinal, &item.ItemType, &summaryID, &messageID); err != nil {
+			return nil, fmt.Errorf("scan context item: %w", err)
+		}
+		item.SummaryID = summaryID.String
+		item.MessageID = messageID.Int64
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate context items: %w", err)
+	}
+
+	pins, err := loadContextPins(ctx, db, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[string]bool, len(pins))
+	for _, pin := range pins {
+		pinned[pin.key()] = true
+	}
+	for i := range items {
+		items[i].Pinned = pinned[contextPinKey(items[i].ItemType, items[i].SummaryID, items[i].MessageID)]
+	}
+	return items, nil
+}
+
+// loadExportSession reads the conversation's session JSONL from whichever
+// agent holds it.
+func loadExportSession(paths appDataPaths, conversation journalRow) (*exportSession, error) {
+	sessionID := fmt.Sprint(conversation["session_id"])
+	agents, err := loadAgents(paths.agentsDir)
+	if err != nil {
+		return nil, err
+	}
+	idx, ok := findAgentForSession(agents, sessionID)
+	if !ok {
+		return nil, fmt.Errorf("session %s not found under any agent in %s", sessionID, paths.agentsDir)
+	}
+	path := filepath.Join(agents[idx].path, "sessions", sessionID+".jsonl")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read session %s: %w", sessionID, err)
+	}
+	return &exportSession{SessionID: sessionID, Path: path, JSONL: string(data)}, nil
+}
+
+func writeExportBundle(out io.Writer, bundle exportBundle, format string) error {
+	switch format {
+	case "markdown":
+		_, err := io.WriteString(out, renderExportMarkdown(bundle))
+		return err
+	case "html":
+		return renderExportHTML(out, bundle)
+	default:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(bundle); err != nil {
+			return fmt.Errorf("encode bundle: %w", err)
+		}
+		return nil
+	}
+}
+
+// exportTreeNode is the summary DAG unrolled into a tree for the readable
+// formats. A summary condensed by several parents appears under each.
+type exportTreeNode struct {
+	SummaryID string
+	Label     string
+	Repeat    bool
+	Children  []exportTreeNode
+}
+
+func buildExportTree(bundle exportBundle) []exportTreeNode {
+	byID := make(map[string]exportSummary, len(bundle.Summaries))
+	for _, summary := range bundle.Summaries {
+		byID[summary.SummaryID] = summary
+	}
+	shown := make(map[string]bool)
+	var build func(id string, path map[string]bool) exportTreeNode
+	build = func(id string, path map[string]bool) exportTreeNode {
+		summary := byID[id]
+		node := exportTreeNode{SummaryID: id, Label: exportSummaryLabel(summary), Repeat: shown[id]}
+		if node.Repeat || path[id] {
+			node.Repeat = true
+			return node
+		}
+		shown[id] = true
+		path[id] = true
+		for _, child := range summary.Children {
+			node.Children = append(node.Children, build(child, path))
+		}
+		delete(path, id)
+		return node
+	}
+	tree := make([]exportTreeNode, 0, len(bundle.Roots))
+	for _, root := range bundle.Roots {
+		tree = append(tree, build(root, make(map[string]bool)))
+	}
+	return tree
+}
+
+func exportSummaryLabel(summary exportSummary) string {
+	return fmt.Sprintf("%s d%d, %d tokens", summary.Kind, summary.Depth, summary.TokenCount)
+}
+
+// exportContextLabel describes a context item for the readable formats.
+func exportContextLabel(bundle exportBundle, item exportContextItem) string {
+	label := ""
+	if item.ItemType == "summary" {
+		label = item.SummaryID
+		for _, summary := range bundle.Summaries {
+			if summary.SummaryID == item.SummaryID {
+				label += " (" + exportSummaryLabel(summary) + ")"
+				break
+			}
+		}
+	} else {
+		label = fmt.Sprintf("message #%d", item.MessageID)
+		for _, message := range bundle.Messages {
+			if rowInt64(message["message_id"]) == item.MessageID {
+				label += fmt.Sprintf(" (%v): %s", message["role"], previewForLog(fmt.Sprint(message["content"]), 80))
+				break
+			}
+		}
+	}
+	if item.Pinned {
+		label += " [pinned]"
+	}
+	return label
+}
+
+func exportTitle(bundle exportBundle) string {
+	return fmt.Sprintf("Conversation %v (session %v)", bundle.Conversation["conversation_id"], bundle.Conversation["session_id"])
+}
+
+func renderExportMarkdown(bundle exportBundle) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", exportTitle(bundle))
+	fmt.Fprintf(&b, "Exported %s by lcm-tui (%s v%d).\n\n", bundle.ExportedAt, bundle.Format, bundle.Version)
+	columns := make([]string, 0, len(bundle.Conversation))
+	for column := range bundle.Conversation {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	for _, column := range columns {
+		if value := bundle.Conversation[column]; value != nil {
+			fmt.Fprintf(&b, "- %s: %v\n", column, value)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## Context (%d items)\n\n", len(bundle.ContextItems))
+	for _, item := range bundle.ContextItems {
+		fmt.Fprintf(&b, "- [%d] %s\n", item.Ordinal, exportContextLabel(bundle, item))
+	}
+
+	fmt.Fprintf(&b, "\n## Summary DAG (%d summaries)\n\n", len(bundle.Summaries))
+	var writeTree func(nodes []exportTreeNode, indent string)
+	writeTree = func(nodes []exportTreeNode, indent string) {
+		for _, node := range nodes {
+			suffix := ""
+			if node.Repeat {
+				suffix = " (see above)"
+			}
+			fmt.Fprintf(&b, "%s- [`%s`](#%s) %s%s\n", indent, node.SummaryID, node.SummaryID, node.Label, suffix)
+			writeTree(node.Children, indent+"  ")
+		}
+	}
+	writeTree(buildExportTree(bundle), "")
+
+	linked := make(map[string][]string)
+	for _, link := range bundle.SummaryMessages {
+		linked[link.SummaryID] = append(linked[link.SummaryID], fmt.Sprintf("#%d", link.MessageID))
+	}
+	b.WriteString("\n## Summaries\n")
+	for _, summary := range bundle.Summaries {
+		fmt.Fprintf(&b, "\n### %s\n\n", summary.SummaryID)
+		fmt.Fprintf(&b, "%s, created %s, sha256 `%s`\n", exportSummaryLabel(summary), summary.CreatedAt, summary.ContentSHA256)
+		if len(summary.Children) > 0 {
+			fmt.Fprintf(&b, "\nCondenses: %s\n", strings.Join(summary.Children, ", "))
+		}
+		if messages := linked[summary.SummaryID]; len(messages) > 0 {
+			fmt.Fprintf(&b, "\nSource messages: %s\n", strings.Join(messages, ", "))
+		}
+		fence := markdownFence(summary.Content)
+		fmt.Fprintf(&b, "\n%s\n%s\n%s\n", fence, strings.TrimRight(summary.Content, "\n"), fence)
+	}
+
+	if len(bundle.LargeFiles) > 0 {
+		fmt.Fprintf(&b, "\n## Large files (%d)\n\n", len(bundle.LargeFiles))
+		b.WriteString("| File ID | Name | MIME type | Size | Storage URI |\n|---|---|---|---|---|\n")
+		for _, file := range bundle.LargeFiles {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", file.FileID, file.FileName, file.MimeType, formatByteSizeCompact(file.ByteSize), file.StorageURI)
+		}
+	}
+
+	if bundle.Session != nil {
+		fence := markdownFence(bundle.Session.JSONL)
+		fmt.Fprintf(&b, "\n## Session JSONL\n\n%s\n\n%sjsonl\n%s\n%s\n", bundle.Session.Path, fence, strings.TrimRight(bundle.Session.JSONL, "\n"), fence)
+	}
+	return b.String()
+}
+
+// markdownFence returns a backtick fence longer than any run inside text.
+func markdownFence(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+			continue
+		}
+		run = 0
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+var exportHTMLTemplate = template.Must(template.New("bundle").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; }
+pre { white-space: pre-wrap; background: #f6f6f6; padding: 0.75rem; }
+ul.dag { list-style: none; padding-left: 1.25rem; }
+.meta { color: #666; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.25rem 0.5rem; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Exported {{.Bundle.ExportedAt}} by lcm-tui ({{.Bundle.Format}} v{{.Bundle.Version}}).</p>
+<h2>Context ({{len .Context}} items)</h2>
+<ol>
+{{- range .Context}}
+<li value="{{.Ordinal}}">{{.Label}}</li>
+{{- end}}
+</ol>
+<h2>Summary DAG ({{len .Bundle.Summaries}} summaries)</h2>
+{{template "tree" .Tree}}
+<h2>Summaries</h2>
+{{- range .Summaries}}
+<h3 id="{{.SummaryID}}">{{.SummaryID}}</h3>
+<p class="meta">{{.Label}}, created {{.CreatedAt}}, sha256 <code>{{.ContentSHA256}}</code>
+{{- if .Children}}<br>Condenses: {{range $i, $c := .Children}}{{if $i}}, {{end}}<a href="#{{$c}}">{{$c}}</a>{{end}}{{end}}
+{{- if .Messages}}<br>Source messages: {{.Messages}}{{end}}</p>
+<pre>{{.Content}}</pre>
+{{- end}}
+{{- if .Bundle.LargeFiles}}
+<h2>Large files ({{len .Bundle.LargeFiles}})</h2>
+<table>
+<tr><th>File ID</th><th>Name</th><th>MIME type</th><th>Size</th><th>Storage URI</th></tr>
+{{- range .Bundle.LargeFiles}}
+<tr><td>{{.FileID}}</td><td>{{.FileName}}</td><td>{{.MimeType}}</td><td>{{.ByteSize}}</td><td>{{.StorageURI}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Bundle.Session}}
+<h2>Session JSONL</h2>
+<p class="meta">{{.Path}}</p>
+<pre>{{.JSONL}}</pre>
+{{- end}}
+</body>
+</html>
+{{define "tree"}}{{if .}}<ul class="dag">
+{{- range .}}
+<li><a href="#{{.SummaryID}}">{{.SummaryID}}</a> {{.Label}}{{if .Repeat}} (see above){{end}}{{template "tree" .Children}}</li>
+{{- end}}
+</ul>{{end}}{{end}}
+`))
+
+type exportHTMLSummary struct {
+	exportSummary
+	Label    string
+	Messages string
+}
+
+type exportHTMLContextItem struct {
+	Ordinal int
+	Label   string
+}
+
+func renderExportHTML(out io.Writer, bundle exportBundle) error {
+	linked := make(map[string][]string)
+	for _, link := range bundle.SummaryMessages {
+		linked[link.SummaryID] = append(linked[link.SummaryID], fmt.Sprintf("#%d", link.MessageID))
+	}
+	summaries := make([]exportHTMLSummary, 0, len(bundle.Summaries))
+	for _, summary := range bundle.Summaries {
+		summaries = append(summaries, exportHTMLSummary{
+			exportSummary: summary,
+			Label:         exportSummaryLabel(summary),
+			Messages:      strings.Join(linked[summary.SummaryID], ", "),
+		})
+	}
+	items := make([]exportHTMLContextItem, 0, len(bundle.ContextItems))
+	for _, item := range bundle.ContextItems {
+		items = append(items, exportHTMLContextItem{Ordinal: item.Ordinal, Label: exportContextLabel(bundle, item)})
+	}
+
+	if err := exportHTMLTemplate.Execute(out, map[string]any{
+		"Title":     exportTitle(bundle),
+		"Bundle":    bundle,
+		"Context":   items,
+		"Summaries": summaries,
+		"Tree":      buildExportTree(bundle),
+	}); err != nil {
+		return fmt.Errorf("render html: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var graphFormats = []string{"dot", "mermaid", "graphml"}
+
+type graphOptions struct {
+	conversationID int64
+	format         string
+	output         string
+	detectors      []summaryDetector
+}
+
+// summaryDAG is the conversation's summary graph with one node per summary,
+// unlike the summary view's tree, which repeats shared sources under every
+// condensed summary. Edges run from a condensed summary to the summaries it
+// condenses.
+type summaryDAG struct {
+	conversationID int64
+	ids            []string
+	nodes          map[string]*summaryNode
+	contextOrdinal map[string]int
+	findings       map[string][]string
+}
+
+func (g summaryDAG) edgeCount() int {
+	count := 0
+	for _, node := range g.nodes {
+		count += len(node.children)
+	}
+	return count
+}
+
+// runGraphCommand executes the standalone graph CLI path.
+func runGraphCommand(args []string) error {
+	opts, err := parseGraphArgs(args)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+	db, err := openLCMDB(paths.lcmDBPath, dbReadOnly)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	graph, err := loadSummaryDAG(context.Background(), db, opts.conversationID, opts.detectors)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if opts.output != "" && opts.output != "-" {
+		file, err := os.Create(opts.output)
+		if err != nil {
+			return fmt.Errorf("create %s: %w", opts.output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+	switch opts.format {
+	case "mermaid":
+		err = writeGraphMermaid(out, graph)
+	case "graphml":
+		err = writeGraphML(out, graph)
+	default:
+		err = writeGraphDOT(out, graph)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.output != "" && opts.output != "-" {
+		fmt.Fprintf(os.Stderr, "Wrote conversation %d (%d summaries, %d edges) to %s\n",
+			opts.conversationID, len(graph.ids), graph.edgeCount(), opts.output)
+	}
+	return nil
+}
+
+func parseGraphArgs(args []string) (graphOptions, error) {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	format := fs.String("format", "dot", "graph format: dot, mermaid or graphml")
+	output := fs.String("output", "", "write the graph to this file instead of stdout")
+	detect := fs.String("detect", defaultDetectors, "detectors that mark corrupted summaries (as in repair --detect)")
+
+	normalized, err := normalizeGraphArgs(args)
+	if err != nil {
+		return graphOptions{}, fmt.Errorf("%w\n%s", err, graphUsageText())
+	}
+	if err := fs.Parse(normalized); err != nil {
+		return graphOptions{}, fmt.Errorf("%w\n%s", err, graphUsageText())
+	}
+	if fs.NArg() != 1 {
+		return graphOptions{}, fmt.Errorf("exactly one conversation ID is required\n%s", graphUsageText())
+	}
+	conversationID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return graphOptions{}, fmt.Errorf("parse conversation ID %q: %w", fs.Arg(0), err)
+	}
+	detectors, err := parseDetectors(*detect)
+	if err != nil {
+		return graphOptions{}, fmt.Errorf("%w\n%s", err, graphUsageText())
+	}
+
+	opts := graphOptions{
+		conversationID: conversationID,
+		format:         strings.ToLower(strings.TrimSpace(*format)),
+		output:         strings.TrimSpace(*output),
+		detectors:      detectors,
+	}
+	known := false
+	for _, name := range graphFormats {
+		known = known || name == opts.format
+	}
+	if !known {
+		return graphOptions{}, fmt.Errorf("unknown --format %q (want %s)\n%s", *format, strings.Join(graphFormats, ", "), graphUsageText())
+	}
+	return opts, nil
+}
+
+func normalizeGraphArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--help", "-h":
+			flags = append(flags, arg)
+		case "--format", "--output", "-o", "--detect":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			if arg == "-o" {
+				arg = "--output"
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		default:
+			if strings.HasPrefix(arg, "--") {
+				flags = append(flags, arg)
+				continue
+			}
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func graphUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui graph <conversation_id> [--format dot|mermaid|graphml] [--output FILE] [--detect LIST]
+
+Writes the conversation's summary DAG with one node per summary, labeled
+with its ID, kind, depth and token count. Edges run from a condensed summary
+to the summaries it condenses, so a summary shared by several condensed
+summaries has several incoming edges. Summaries in context_items are
+highlighted with their ordinal; summaries flagged by --detect (repair's
+detectors, default marker) are marked corrupted.
+
+  lcm-tui graph 553 | dot -Tsvg > conv553.svg
+`)
+}
+
+// loadSummaryDAG loads the summaries and edges with the summary view's
+// loaders, then marks context membership and detector findings.
+func loadSummaryDAG(ctx context.Context, db *sql.DB, conversationID int64, detectors []summaryDetector) (summaryDAG, error) {
+	exists, err := conversationExists(ctx, db, conversationID)
+	if err != nil {
+		return summaryDAG{}, err
+	}
+	if !exists {
+		return summaryDAG{}, fmt.Errorf("conversation %d not found", conversationID)
+	}
+
+	nodes, err := loadSummaryNodes(ctx, db, conversationID)
+	if err != nil {
+		return summaryDAG{}, err
+	}
+	if _, err := populateSummaryChildren(ctx, db, conversationID, nodes); err != nil {
+		return summaryDAG{}, err
+	}
+	graph := summaryDAG{
+		conversationID: conversationID,
+		nodes:          nodes,
+		contextOrdinal: make(map[string]int),
+		findings:       make(map[string][]string),
+	}
+	for id, node := range nodes {
+		graph.ids = append(graph.ids, id)
+		sortSummaryIDs(node.children, nodes)
+	}
+	sortSummaryIDs(graph.ids, nodes)
+
+	items, err := loadExportContextItems(ctx, db, conversationID)
+	if err != nil {
+		return summaryDAG{}, err
+	}
+	for _, item := range items {
+		if item.ItemType == "summary" {
+			graph.contextOrdinal[item.SummaryID] = item.Ordinal
+		}
+	}
+
+	corrupted, err := loadCorruptedSummaries(ctx, db, conversationID, "", detectors)
+	if err != nil {
+		return summaryDAG{}, err
+	}
+	for _, item := range corrupted {
+		graph.findings[item.summaryID] = item.findings
+	}
+	return graph, nil
+}
+
+// graphNodeLabel returns the label lines for one summary.
+func graphNodeLabel(graph summaryDAG, id string) []string {
+	node := graph.nodes[id]
+	lines := []string{id, fmt.Sprintf("%s d%d, %dt", node.kind, node.depth, node.tokenCount)}
+	if ordinal, ok := graph.contextOrdinal[id]; ok {
+		lines = append(lines, fmt.Sprintf("context #%d", ordinal))
+	}
+	if findings := graph.findings[id]; len(findings) > 0 {
+		lines = append(lines, "corrupted: "+detectorLabels(findings))
+	}
+	return lines
+}
+
+func writeGraphDOT(out io.Writer, graph summaryDAG) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph conversation_%d {\n", graph.conversationID)
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, fontname=\"Helvetica\", fontsize=10];\n")
+	for _, id := range graph.ids {
+		attrs := []string{"label=" + dotQuote(strings.Join(graphNodeLabel(graph, id), "\n"))}
+		_, inContext := graph.contextOrdinal[id]
+		if inContext {
+			attrs = append(attrs, "style=filled", "fillcolor=\"#cfe8ff\"", "penwidth=2")
+		}
+		if len(graph.findings[id]) > 0 {
+			attrs = append(attrs, "color=\"#d62728\"", "fontcolor=\"#d62728\"", "penwidth=2")
+			if !inContext {
+				attrs = append(attrs, "style=dashed")
+			}
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(id), strings.Join(attrs, ", "))
+	}
+
+	// Keep each depth on one rank so the levels read top to bottom.
+	byDepth := make(map[int][]string)
+	for _, id := range graph.ids {
+		byDepth[graph.nodes[id].depth] = append(byDepth[graph.nodes[id].depth], dotQuote(id))
+	}
+	depths := make([]int, 0, len(byDepth))
+	for depth := range byDepth {
+		depths = append(depths, depth)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(depths)))
+	for _, depth := range depths {
+		fmt.Fprintf(&b, "  { rank=same; %s; }\n", strings.Join(byDepth[depth], "; "))
+	}
+
+	for _, id := range graph.ids {
+		for _, child := range graph.nodes[id].children {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(id), dotQuote(child))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func dotQuote(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + replacer.Replace(text) + `"`
+}
+
+func writeGraphMermaid(out io.Writer, graph summaryDAG) error {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	b.WriteString("  classDef context fill:#cfe8ff,stroke:#1f77b4,stroke-width:2px\n")
+	b.WriteString("  classDef corrupted stroke:#d62728,stroke-width:2px,color:#d62728,stroke-dasharray:4 2\n")
+	for _, id := range graph.ids {
+		label := strings.ReplaceAll(strings.Join(graphNodeLabel(graph, id), "<br>"), `"`, "#quot;")
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", mermaidID(id), label)
+	}
+	for _, id := range graph.ids {
+		for _, child := range graph.nodes[id].children {
+			fmt.Fprintf(&b, "  %s --> %s\n", mermaidID(id), mermaidID(child))
+		}
+	}
+	var inContext, corrupted []string
+	for _, id := range graph.ids {
+		if _, ok := graph.contextOrdinal[id]; ok {
+			inContext = append(inContext, mermaidID(id))
+		}
+		if len(graph.findings[id]) > 0 {
+			corrupted = append(corrupted, mermaidID(id))
+		}
+	}
+	if len(inContext) > 0 {
+		fmt.Fprintf(&b, "  class %s context\n", strings.Join(inContext, ","))
+	}
+	if len(corrupted) > 0 {
+		fmt.Fprintf(&b, "  class %s corrupted\n", strings.Join(corrupted, ","))
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// mermaidID keeps only characters Mermaid accepts in a bare node ID.
+func mermaidID(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		if r == '_' || r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+func writeGraphML(out io.Writer, graph summaryDAG) error {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "kind", For: "node", Name: "kind", Type: "string"},
+			{ID: "depth", For: "node", Name: "depth", Type: "int"},
+			{ID: "tokens", For: "node", Name: "token_count", Type: "int"},
+			{ID: "in_context", For: "node", Name: "in_context", Type: "boolean"},
+			{ID: "context_ordinal", For: "node", Name: "context_ordinal", Type: "int"},
+			{ID: "corrupted", For: "node", Name: "corrupted", Type: "boolean"},
+			{ID: "findings", For: "node", Name: "findings", Type: "string"},
+		},
+	}
+	doc.Graph.ID = fmt.Sprintf("conversation_%d", graph.conversationID)
+	doc.Graph.EdgeDefault = "directed"
+	for _, id := range graph.ids {
+		node := graph.nodes[id]
+		ordinal, inContext := graph.contextOrdinal[id]
+		findings := graph.findings[id]
+		data := []graphMLData{
+			{Key: "label", Value: strings.Join(graphNodeLabel(graph, id), "\n")},
+			{Key: "kind", Value: node.kind},
+			{Key: "depth", Value: strconv.Itoa(node.depth)},
+			{Key: "tokens", Value: strconv.Itoa(node.tokenCount)},
+			{Key: "in_context", Value: strconv.FormatBool(inContext)},
+		}
+		if inContext {
+			data = append(data, graphMLData{Key: "context_ordinal", Value: strconv.Itoa(ordinal)})
+		}
+		data = append(data, graphMLData{Key: "corrupted", Value: strconv.FormatBool(len(findings) > 0)})
+		if len(findings) > 0 {
+			data = append(data, graphMLData{Key: "findings", Value: strings.Join(findings, "; ")})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: id, Data: data})
+		for _, child := range node.children {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: id, Target: child})
+		}
+	}
+
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode graphml: %w", err)
+	}
+	_, err := io.WriteString(out, "\n")
+	return err
+}
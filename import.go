@@ -0,0 +1,635 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	importContextPrepend = "prepend"
+	importContextAppend  = "append"
+	importContextNone    = "none"
+)
+
+type importOptions struct {
+	bundlePath string
+	// intoConversationID is the target; zero with newConversation set
+	// creates one.
+	intoConversationID int64
+	newConversation    bool
+	sessionID          string
+	attach             string
+	apply              bool
+	dryRun             bool
+}
+
+// importPlan reuses the cross-database transplant plan: the bundle plays
+// the source database, so summaries, links, edges and messages are copied
+// by the same code.
+type importPlan struct {
+	bundle          exportBundle
+	transplant      transplantPlan
+	newConversation bool
+	sessionID       string
+	attach          string
+}
+
+// runImportCommand executes the standalone import CLI path.
+func runImportCommand(args []string) error {
+	opts, err := parseImportArgs(args)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := readImportBundle(opts.bundlePath)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	plan, err := buildImportPlan(ctx, db, bundle, opts, paths.lcmDBPath)
+	if err != nil {
+		return err
+	}
+	if len(plan.transplant.ordered) == 0 {
+		fmt.Printf("Bundle %s has no summaries. Nothing to import.\n", opts.bundlePath)
+		return nil
+	}
+
+	for _, line := range importReportLines(plan) {
+		fmt.Println(line)
+	}
+	if len(plan.transplant.duplicates) > 0 && !plan.newConversation {
+		if opts.apply {
+			return fmt.Errorf("aborting import: target conversation %d already contains %d matching summary content hashes", plan.transplant.targetConversationID, len(plan.transplant.duplicates))
+		}
+		return nil
+	}
+	if opts.dryRun {
+		fmt.Println()
+		fmt.Println("Run with --apply to execute.")
+		return nil
+	}
+
+	fmt.Println()
+	label := fmt.Sprintf("import-to-conv%d", plan.transplant.targetConversationID)
+	if plan.newConversation {
+		label = "import-new-conv"
+	}
+	if err := backupBeforeApply(ctx, db, paths, label); err != nil {
+		return err
+	}
+	copied, opID, err := applyImport(ctx, db, &plan, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nDone. %d summaries imported into conversation %d", copied, plan.transplant.targetConversationID)
+	switch plan.attach {
+	case importContextPrepend:
+		fmt.Printf("; %d context items prepended", len(plan.transplant.sourceContext))
+	case importContextAppend:
+		fmt.Printf("; %d context items appended", len(plan.transplant.sourceContext))
+	}
+	fmt.Println(".")
+	fmt.Printf("Undo with: lcm-tui undo %d --apply\n", opID)
+	return nil
+}
+
+func parseImportArgs(args []string) (importOptions, error) {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	apply := fs.Bool("apply", false, "apply the import to the DB")
+	dryRun := fs.Bool("dry-run", true, "show what would be imported")
+	into := fs.String("into", "", "target conversation ID, or new")
+	sessionID := fs.String("session-id", "", "session ID for --into new (default: the bundle's)")
+	attach := fs.String("context", importContextPrepend, "attach the bundle's context summaries: prepend, append or none")
+
+	normalized, err := normalizeImportArgs(args)
+	if err != nil {
+		return importOptions{}, fmt.Errorf("%w\n%s", err, importUsageText())
+	}
+	if err := fs.Parse(normalized); err != nil {
+		return importOptions{}, fmt.Errorf("%w\n%s", err, importUsageText())
+	}
+	if fs.NArg() != 1 {
+		return importOptions{}, fmt.Errorf("exactly one bundle file is required\n%s", importUsageText())
+	}
+
+	opts := importOptions{
+		bundlePath: fs.Arg(0),
+		sessionID:  strings.TrimSpace(*sessionID),
+		attach:     strings.ToLower(strings.TrimSpace(*attach)),
+		apply:      *apply,
+		dryRun:     *dryRun,
+	}
+	switch target := strings.TrimSpace(*into); target {
+	case "":
+		return importOptions{}, fmt.Errorf("--into is required\n%s", importUsageText())
+	case "new":
+		opts.newConversation = true
+	default:
+		id, err := strconv.ParseInt(target, 10, 64)
+		if err != nil {
+			return importOptions{}, fmt.Errorf("parse --into %q: %w", target, err)
+		}
+		opts.intoConversationID = id
+	}
+	if opts.sessionID != "" && !opts.newConversation {
+		return importOptions{}, fmt.Errorf("--session-id only applies to --into new\n%s", importUsageText())
+	}
+	switch opts.attach {
+	case importContextPrepend, importContextAppend, importContextNone:
+	default:
+		return importOptions{}, fmt.Errorf("unknown --context %q (want prepend, append or none)\n%s", *attach, importUsageText())
+	}
+	if opts.apply {
+		opts.dryRun = false
+	}
+	if !opts.apply {
+		opts.dryRun = true
+	}
+	return opts, nil
+}
+
+func normalizeImportArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "--apply", "--dry-run", "--help", "-h":
+			flags = append(flags, arg)
+		case "--into", "--session-id", "--context":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		default:
+			if strings.HasPrefix(arg, "--") {
+				flags = append(flags, arg)
+				continue
+			}
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func importUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui import <bundle.json> --into <conversation_id>|new [--context prepend|append|none] [--dry-run]
+  lcm-tui import <bundle.json> --into <conversation_id>|new [--session-id ID] --apply
+
+Loads a bundle written by lcm-tui export --format json. Summaries get fresh
+IDs and are inserted in depth order with their summary_parents edges; the
+messages they link to are copied with new IDs, except those the target
+conversation already holds with the same role and content. The bundle's
+context summaries are prepended to the target's context by default.
+
+--into new creates a conversation under the bundle's session ID, or
+--session-id if that one is already taken. Large-file metadata and message
+parts are not imported. Nothing is written without --apply.
+`)
+}
+
+// readImportBundle decodes a JSON bundle and checks its format and version
+// before anything else looks at it.
+func readImportBundle(path string) (exportBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return exportBundle{}, fmt.Errorf("read bundle: %w", err)
+	}
+	var header struct {
+		Format  string `json:"format"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return exportBundle{}, fmt.Errorf("parse bundle %s: %w", path, err)
+	}
+	if header.Format != exportBundleFormat {
+		return exportBundle{}, fmt.Errorf("%s is not an lcm-tui bundle (format %q)", path, header.Format)
+	}
+	if header.Version != exportBundleVersion {
+		return exportBundle{}, fmt.Errorf("%s is bundle version %d; this lcm-tui reads version %d", path, header.Version, exportBundleVersion)
+	}
+
+	var bundle exportBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return exportBundle{}, fmt.Errorf("parse bundle %s: %w", path, err)
+	}
+	// Row images go through the journal decoder so integer columns stay
+	// integers.
+	var rows struct {
+		Conversation json.RawMessage `json:"conversation"`
+		Messages     json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return exportBundle{}, fmt.Errorf("parse bundle %s: %w", path, err)
+	}
+	if bundle.Messages, err = decodeJournalRows(string(rows.Messages)); err != nil {
+		return exportBundle{}, fmt.Errorf("bundle messages: %w", err)
+	}
+	conversation, err := decodeJournalRows("[" + string(rows.Conversation) + "]")
+	if err != nil || len(conversation) != 1 {
+		return exportBundle{}, fmt.Errorf("bundle %s has no conversation row", path)
+	}
+	bundle.Conversation = conversation[0]
+
+	if err := validateImportBundle(bundle); err != nil {
+		return exportBundle{}, fmt.Errorf("invalid bundle %s: %w", path, err)
+	}
+	return bundle, nil
+}
+
+// validateImportBundle checks that the bundle is internally consistent:
+// content matches its hash, and every edge, link and context item points at
+// something the bundle carries.
+func validateImportBundle(bundle exportBundle) error {
+	depths := make(map[string]int, len(bundle.Summaries))
+	for _, summary := range bundle.Summaries {
+		if summary.SummaryID == "" {
+			return fmt.Errorf("summary without summary_id")
+		}
+		if _, ok := depths[summary.SummaryID]; ok {
+			return fmt.Errorf("summary %s appears twice", summary.SummaryID)
+		}
+		if contentSHA256(summary.Content) != summary.ContentSHA256 {
+			return fmt.Errorf("summary %s content does not match its content_sha256", summary.SummaryID)
+		}
+		depths[summary.SummaryID] = summary.Depth
+	}
+	for _, edge := range bundle.SummaryParents {
+		depth, ok := depths[edge.SummaryID]
+		parentDepth, parentOK := depths[edge.ParentSummaryID]
+		if !ok || !parentOK {
+			return fmt.Errorf("edge %s -> %s references a summary not in the bundle", edge.SummaryID, edge.ParentSummaryID)
+		}
+		if parentDepth >= depth {
+			return fmt.Errorf("edge %s (d%d) -> %s (d%d) does not point to a lower depth", edge.SummaryID, depth, edge.ParentSummaryID, parentDepth)
+		}
+	}
+	messages := make(map[int64]bool, len(bundle.Messages))
+	for _, message := range bundle.Messages {
+		messages[rowInt64(message["message_id"])] = true
+	}
+	for _, link := range bundle.SummaryMessages {
+		if _, ok := depths[link.SummaryID]; !ok {
+			return fmt.Errorf("summary_messages row for %s, which is not in the bundle", link.SummaryID)
+		}
+		if !messages[link.MessageID] {
+			return fmt.Errorf("summary %s links message #%d, which is not in the bundle", link.SummaryID, link.MessageID)
+		}
+	}
+	for _, item := range bundle.ContextItems {
+		if item.ItemType != "summary" {
+			continue
+		}
+		if _, ok := depths[item.SummaryID]; !ok {
+			return fmt.Errorf("context item %d references summary %s, which is not in the bundle", item.Ordinal, item.SummaryID)
+		}
+	}
+	return nil
+}
+
+// buildImportPlan lays the bundle out as a cross-database transplant plan
+// against the target conversation.
+func buildImportPlan(ctx context.Context, db *sql.DB, bundle exportBundle, opts importOptions, dbPath string) (importPlan, error) {
+	plan := importPlan{
+		bundle:          bundle,
+		newConversation: opts.newConversation,
+		attach:          opts.attach,
+	}
+	tp := transplantPlan{
+		sourceConversationID: rowInt64(bundle.Conversation["conversation_id"]),
+		targetConversationID: opts.intoConversationID,
+		depthCounts:          make(map[int]int),
+		duplicates:           []transplantDuplicate{},
+		crossDB: &transplantCrossDB{
+			sourcePath: opts.bundlePath,
+			targetPath: dbPath,
+			reused:     make(map[int64]int64),
+			links:      make(map[string][]transplantLink),
+			edges:      make(map[string][]transplantEdge),
+		},
+	}
+	cross := tp.crossDB
+
+	summaries := append([]exportSummary(nil), bundle.Summaries...)
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Depth != summaries[j].Depth {
+			return summaries[i].Depth < summaries[j].Depth
+		}
+		if summaries[i].CreatedAt != summaries[j].CreatedAt {
+			return summaries[i].CreatedAt < summaries[j].CreatedAt
+		}
+		return summaries[i].SummaryID < summaries[j].SummaryID
+	})
+	byID := make(map[string]exportSummary, len(summaries))
+	for _, summary := range summaries {
+		byID[summary.SummaryID] = summary
+		tp.ordered = append(tp.ordered, transplantSummary{
+			summaryID:      summary.SummaryID,
+			conversationID: tp.sourceConversationID,
+			kind:           summary.Kind,
+			content:        summary.Content,
+			tokenCount:     summary.TokenCount,
+			createdAt:      summary.CreatedAt,
+			fileIDs:        summary.FileIDs,
+			depth:          summary.Depth,
+		})
+		tp.depthCounts[summary.Depth]++
+	}
+	if opts.attach != importContextNone {
+		for _, item := range bundle.ContextItems {
+			if item.ItemType != "summary" {
+				continue
+			}
+			summary := byID[item.SummaryID]
+			tp.sourceContext = append(tp.sourceContext, transplantContextSummary{
+				ordinal:    int64(item.Ordinal),
+				summaryID:  summary.SummaryID,
+				kind:       summary.Kind,
+				depth:      summary.Depth,
+				tokenCount: summary.TokenCount,
+				content:    summary.Content,
+				createdAt:  summary.CreatedAt,
+				fileIDs:    summary.FileIDs,
+			})
+			tp.contextTokenOverhead += summary.TokenCount
+		}
+	}
+	for _, edge := range bundle.SummaryParents {
+		cross.edges[edge.SummaryID] = append(cross.edges[edge.SummaryID], transplantEdge{parentSummaryID: edge.ParentSummaryID, ordinal: edge.Ordinal})
+	}
+	linked := make(map[int64]bool)
+	for _, link := range bundle.SummaryMessages {
+		cross.links[link.SummaryID] = append(cross.links[link.SummaryID], transplantLink{messageID: link.MessageID, ordinal: link.Ordinal})
+		linked[link.MessageID] = true
+	}
+
+	existing := map[string]int64{}
+	if opts.newConversation {
+		plan.sessionID = opts.sessionID
+		if plan.sessionID == "" {
+			plan.sessionID = fmt.Sprint(bundle.Conversation["session_id"])
+		}
+		var taken int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM conversations WHERE session_id = ?`, plan.sessionID).Scan(&taken); err != nil {
+			return importPlan{}, fmt.Errorf("check session %s: %w", plan.sessionID, err)
+		}
+		if taken > 0 {
+			return importPlan{}, fmt.Errorf("session %s already has a conversation in this database; pass --session-id to import under another", plan.sessionID)
+		}
+		duplicates, err := detectSummaryContentDuplicatesInDB(ctx, db, tp.ordered)
+		if err != nil {
+			return importPlan{}, err
+		}
+		tp.duplicates = duplicates
+	} else {
+		exists, err := conversationExists(ctx, db, opts.intoConversationID)
+		if err != nil {
+			return importPlan{}, err
+		}
+		if !exists {
+			return importPlan{}, fmt.Errorf("target conversation %d not found", opts.intoConversationID)
+		}
+		if tp.targetContext, err = loadContextStats(ctx, db, opts.intoConversationID); err != nil {
+			return importPlan{}, err
+		}
+		if tp.duplicates, err = detectSummaryContentDuplicates(ctx, db, opts.intoConversationID, tp.ordered); err != nil {
+			return importPlan{}, err
+		}
+		if existing, err = loadMessageHashes(ctx, db, opts.intoConversationID); err != nil {
+			return importPlan{}, err
+		}
+	}
+
+	for _, message := range bundle.Messages {
+		id := rowInt64(message["message_id"])
+		if !linked[id] {
+			continue
+		}
+		if targetID, ok := existing[messageHashKey(fmt.Sprint(message["role"]), fmt.Sprint(message["content"]))]; ok {
+			cross.reused[id] = targetID
+			continue
+		}
+		cross.messages = append(cross.messages, message)
+	}
+	plan.transplant = tp
+	return plan, nil
+}
+
+// detectSummaryContentDuplicatesInDB is detectSummaryContentDuplicates
+// across every conversation, for imports into a new one.
+func detectSummaryContentDuplicatesInDB(ctx context.Context, q sqlQueryer, summaries []transplantSummary) ([]transplantDuplicate, error) {
+	rows, err := q.QueryContext(ctx, `SELECT content FROM summaries`)
+	if err != nil {
+		return nil, fmt.Errorf("query summaries: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]int)
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(&content); err != nil {
+			return nil, fmt.Errorf("scan summary content: %w", err)
+		}
+		hashes[contentSHA256(content)]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate summaries: %w", err)
+	}
+
+	duplicates := make([]transplantDuplicate, 0)
+	for _, summary := range summaries {
+		hash := contentSHA256(summary.content)
+		if hashes[hash] > 0 {
+			duplicates = append(duplicates, transplantDuplicate{summaryID: summary.summaryID, contentHash: hash, targetCount: hashes[hash]})
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].summaryID < duplicates[j].summaryID
+	})
+	return duplicates, nil
+}
+
+func importReportLines(plan importPlan) []string {
+	tp := plan.transplant
+	cross := tp.crossDB
+	target := fmt.Sprintf("conversation %d", tp.targetConversationID)
+	if plan.newConversation {
+		target = fmt.Sprintf("new conversation (session %s)", plan.sessionID)
+	}
+	lines := []string{
+		fmt.Sprintf("Import: %s -> %s", cross.sourcePath, target),
+		fmt.Sprintf("  bundle: %s v%d, exported %s from conversation %d (session %v)",
+			plan.bundle.Format, plan.bundle.Version, plan.bundle.ExportedAt, tp.sourceConversationID, plan.bundle.Conversation["session_id"]),
+		"",
+		fmt.Sprintf("Summaries to insert, in depth order (%d):", len(tp.ordered)),
+	}
+	for _, summary := range tp.ordered {
+		lines = append(lines, fmt.Sprintf("  d%d  %s  %-9s %dt  %d parents  %d messages  %q",
+			summary.depth, summary.summaryID, summary.kind, summary.tokenCount,
+			len(cross.edges[summary.summaryID]), len(cross.links[summary.summaryID]), previewForLog(summary.content, 48)))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, fmt.Sprintf("Messages to insert (%d):", len(cross.messages)))
+	for _, message := range cross.messages {
+		lines = append(lines, fmt.Sprintf("  #%d  %-9v %q", rowInt64(message["message_id"]), message["role"], previewForLog(fmt.Sprint(message["content"]), 56)))
+	}
+	if len(cross.reused) > 0 {
+		lines = append(lines, fmt.Sprintf("  %d more already in the target by content hash (linked, not copied)", len(cross.reused)))
+	}
+	lines = append(lines, "")
+
+	switch plan.attach {
+	case importContextNone:
+		lines = append(lines, "Context: not attached (--context none)")
+	default:
+		lines = append(lines, fmt.Sprintf("Context summaries to %s (%d, ~%d tokens):", plan.attach, len(tp.sourceContext), tp.contextTokenOverhead))
+		for _, item := range tp.sourceContext {
+			lines = append(lines, formatTransplantContextLine(item, nil))
+		}
+		if !plan.newConversation {
+			lines = append(lines, fmt.Sprintf("  target context now: %d summaries + %d messages", tp.targetContext.summaries, tp.targetContext.messages))
+		}
+	}
+	if len(plan.bundle.LargeFiles) > 0 {
+		lines = append(lines, fmt.Sprintf("Large files: %d in the bundle, not imported", len(plan.bundle.LargeFiles)))
+	}
+
+	if len(tp.duplicates) > 0 {
+		where := "target conversation"
+		if plan.newConversation {
+			where = "database (importing into a new conversation anyway)"
+		}
+		lines = append(lines, "", fmt.Sprintf("Warning: %d bundle summaries have content already present in the %s:", len(tp.duplicates), where))
+		for _, duplicate := range tp.duplicates {
+			lines = append(lines, fmt.Sprintf("  %s  hash=%s  matches=%d", duplicate.summaryID, duplicate.contentHash, duplicate.targetCount))
+		}
+	}
+	return lines
+}
+
+// applyImport writes the plan in one journaled transaction, creating the
+// target conversation first for --into new.
+func applyImport(ctx context.Context, db *sql.DB, plan *importPlan, out io.Writer) (int, int64, error) {
+	tp := &plan.transplant
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin import transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if plan.newConversation {
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(conversation_id), 0) + 1 FROM conversations`).Scan(&tp.targetConversationID); err != nil {
+			return 0, 0, fmt.Errorf("allocate conversation ID: %w", err)
+		}
+	}
+	description := fmt.Sprintf("import %d summaries from %s", len(tp.ordered), tp.crossDB.sourcePath)
+	j, err := startJournalOp(ctx, tx, "import", tp.targetConversationID, description)
+	if err != nil {
+		return 0, 0, err
+	}
+	if plan.newConversation {
+		columns, err := tableColumns(ctx, tx, "conversations")
+		if err != nil {
+			return 0, 0, err
+		}
+		row := make(journalRow, len(plan.bundle.Conversation))
+		for column, value := range plan.bundle.Conversation {
+			row[column] = value
+		}
+		row["conversation_id"] = tp.targetConversationID
+		row["session_id"] = plan.sessionID
+		if err := insertCopiedRow(ctx, tx, "conversations", columns, row); err != nil {
+			return 0, 0, err
+		}
+		if err := j.recordInsert(ctx, "conversations", "conversation_id = ?", tp.targetConversationID); err != nil {
+			return 0, 0, err
+		}
+		fmt.Fprintf(out, "Created conversation %d (session %s)\n", tp.targetConversationID, plan.sessionID)
+	}
+	contextBefore, err := captureContext(ctx, tx, tp.targetConversationID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	oldToNew, copied, err := copyCrossDBSummaries(ctx, tx, j, *tp, out)
+	if err != nil {
+		return copied, 0, err
+	}
+	switch plan.attach {
+	case importContextPrepend:
+		err = prependTransplantedContextItems(ctx, tx, tp.targetConversationID, tp.sourceContext, oldToNew)
+	case importContextAppend:
+		err = appendTransplantedContextItems(ctx, tx, tp.targetConversationID, tp.sourceContext, oldToNew)
+	}
+	if err != nil {
+		return copied, 0, err
+	}
+	if err := j.recordContext(ctx, tp.targetConversationID, contextBefore); err != nil {
+		return copied, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return copied, 0, fmt.Errorf("commit import transaction: %w", err)
+	}
+	rollback = false
+	return copied, j.opID, nil
+}
+
+// appendTransplantedContextItems is prependTransplantedContextItems for the
+// end of the context: copied summaries follow the existing items.
+func appendTransplantedContextItems(ctx context.Context, q sqlQueryer, targetConversationID int64, sourceContext []transplantContextSummary, oldToNew map[string]string) error {
+	var next int64
+	if err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(ordinal), -1) + 1
+		FROM context_items
+		WHERE conversation_id = ?
+	`, targetConversationID).Scan(&next); err != nil {
+		return fmt.Errorf("query max target context ordinal for conversation %d: %w", targetConversationID, err)
+	}
+	for i, source := range sourceContext {
+		newSummaryID, ok := oldToNew[source.summaryID]
+		if !ok {
+			return fmt.Errorf("missing remapped summary ID for context summary %s", source.summaryID)
+		}
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO context_items (conversation_id, ordinal, item_type, summary_id)
+			VALUES (?, ?, 'summary', ?)
+		`, targetConversationID, next+int64(i), newSummaryID); err != nil {
+			return fmt.Errorf("insert imported context item %d (%s): %w", next+int64(i), source.summaryID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,767 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	journalOpsTable     = "lcm_tui_journal_ops"
+	journalEntriesTable = "lcm_tui_journal"
+	journalListLimit    = 20
+)
+
+// Journal entry actions. journalActionContext stores a whole conversation's
+// context_items list, because dissolve and transplant renumber every ordinal
+// and per-row keys would not survive the shift.
+const (
+	journalActionInsert  = "insert"
+	journalActionDelete  = "delete"
+	journalActionUpdate  = "update"
+	journalActionContext = "context"
+)
+
+// journalTableKeys lists the primary key columns used to find a journaled
+// row again at undo time.
+var journalTableKeys = map[string][]string{
+	"conversations":    {"conversation_id"},
+	"summaries":        {"summary_id"},
+	"summary_parents":  {"summary_id", "parent_summary_id"},
+	"summary_messages": {"summary_id", "message_id"},
+	"messages":         {"message_id"},
+	"message_parts":    {"part_id"},
+	contextPinsTable:   {"pin_id"},
+}
+
+// journalRow is one row image, keyed by column name.
+type journalRow map[string]any
+
+// journalOp is one recorded operation.
+type journalOp struct {
+	opID           int64
+	kind           string
+	conversationID int64
+	description    string
+	createdAt      string
+	undoneAt       sql.NullString
+	entryCount     int
+}
+
+type journalEntry struct {
+	seq       int
+	tableName string
+	action    string
+	oldRows   string
+	newRows   string
+}
+
+// journal records row images for one operation inside the caller's
+// transaction, so the journal commits or rolls back with the change itself.
+// Operations that commit in several transactions (repair) build a journal
+// per transaction for the same opID.
+type journal struct {
+	q    sqlQueryer
+	opID int64
+}
+
+// startJournalOp creates the journal tables if needed and opens a new
+// operation record.
+func startJournalOp(ctx context.Context, q sqlQueryer, kind string, conversationID int64, description string) (*journal, error) {
+	if err := ensureJournalTables(ctx, q); err != nil {
+		return nil, err
+	}
+	res, err := q.ExecContext(ctx, `
+		INSERT INTO `+journalOpsTable+` (kind, conversation_id, description)
+		VALUES (?, ?, ?)
+	`, kind, conversationID, description)
+	if err != nil {
+		return nil, fmt.Errorf("insert journal op: %w", err)
+	}
+	opID, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("read journal op ID: %w", err)
+	}
+	return &journal{q: q, opID: opID}, nil
+}
+
+func ensureJournalTables(ctx context.Context, q sqlQueryer) error {
+	if _, err := q.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+journalOpsTable+` (
+			op_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			conversation_id INTEGER NOT NULL,
+			description TEXT NOT NULL,
+			created_at TEXT NOT NULL DEFAULT (datetime('now')),
+			undone_at TEXT
+		)
+	`); err != nil {
+		return fmt.Errorf("create %s: %w", journalOpsTable, err)
+	}
+	if _, err := q.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+journalEntriesTable+` (
+			op_id INTEGER NOT NULL REFERENCES `+journalOpsTable+`(op_id) ON DELETE CASCADE,
+			seq INTEGER NOT NULL,
+			table_name TEXT NOT NULL,
+			action TEXT NOT NULL,
+			old_rows TEXT,
+			new_rows TEXT,
+			PRIMARY KEY (op_id, seq)
+		)
+	`); err != nil {
+		return fmt.Errorf("create %s: %w", journalEntriesTable, err)
+	}
+	return nil
+}
+
+func journalTablesExist(ctx context.Context, q sqlQueryer) (bool, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type = 'table' AND name IN (?, ?)
+	`, journalOpsTable, journalEntriesTable).Scan(&count); err != nil {
+		return false, fmt.Errorf("check journal tables: %w", err)
+	}
+	return count == 2, nil
+}
+
+// captureRows returns full images of the rows in table matching where.
+func captureRows(ctx context.Context, q sqlQueryer, table, where string, args ...any) ([]journalRow, error) {
+	rows, err := q.QueryContext(ctx, `SELECT * FROM `+table+` WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("capture %s rows: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("read %s columns: %w", table, err)
+	}
+	var images []journalRow
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("scan %s row: %w", table, err)
+		}
+		image := make(journalRow, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			image[column] = values[i]
+		}
+		images = append(images, image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate %s rows: %w", table, err)
+	}
+	return images, nil
+}
+
+// captureContext returns a conversation's context_items in ordinal order.
+func captureContext(ctx context.Context, q sqlQueryer, conversationID int64) ([]journalRow, error) {
+	return captureRows(ctx, q, "context_items", "conversation_id = ? ORDER BY ordinal ASC", conversationID)
+}
+
+func (j *journal) write(ctx context.Context, table, action string, oldRows, newRows []journalRow) error {
+	encode := func(rows []journalRow) (any, error) {
+		if rows == nil {
+			return nil, nil
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+	oldJSON, err := encode(oldRows)
+	if err != nil {
+		return fmt.Errorf("encode journal old rows: %w", err)
+	}
+	newJSON, err := encode(newRows)
+	if err != nil {
+		return fmt.Errorf("encode journal new rows: %w", err)
+	}
+
+	if _, err := j.q.ExecContext(ctx, `
+		INSERT INTO `+journalEntriesTable+` (op_id, seq, table_name, action, old_rows, new_rows)
+		SELECT ?, COALESCE(MAX(seq), 0) + 1, ?, ?, ?, ?
+		FROM `+journalEntriesTable+`
+		WHERE op_id = ?
+	`, j.opID, table, action, oldJSON, newJSON, j.opID); err != nil {
+		return fmt.Errorf("insert journal entry for op %d: %w", j.opID, err)
+	}
+	return nil
+}
+
+// recordInsert journals rows the caller has just inserted.
+func (j *journal) recordInsert(ctx context.Context, table, where string, args ...any) error {
+	rows, err := captureRows(ctx, j.q, table, where, args...)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return j.write(ctx, table, journalActionInsert, nil, rows)
+}
+
+// recordDelete journals rows the caller is about to delete.
+func (j *journal) recordDelete(ctx context.Context, table, where string, args ...any) error {
+	rows, err := captureRows(ctx, j.q, table, where, args...)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return j.write(ctx, table, journalActionDelete, rows, nil)
+}
+
+// recordUpdate journals rows the caller has just updated, given their images
+// from before the update.
+func (j *journal) recordUpdate(ctx context.Context, table string, before []journalRow) error {
+	after := make([]journalRow, 0, len(before))
+	for _, row := range before {
+		where, args := journalKeyWhere(table, row)
+		current, err := captureRows(ctx, j.q, table, where, args...)
+		if err != nil {
+			return err
+		}
+		if len(current) != 1 {
+			return fmt.Errorf("journal update: expected 1 %s row, found %d", table, len(current))
+		}
+		after = append(after, current[0])
+	}
+	return j.write(ctx, table, journalActionUpdate, before, after)
+}
+
+// recordContext journals a conversation's context window, given its rows
+// from before the operation.
+func (j *journal) recordContext(ctx context.Context, conversationID int64, before []journalRow) error {
+	after, err := captureContext(ctx, j.q, conversationID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		before = []journalRow{}
+	}
+	if after == nil {
+		after = []journalRow{}
+	}
+	return j.write(ctx, "context_items", journalActionContext, before, after)
+}
+
+func journalKeyWhere(table string, row journalRow) (string, []any) {
+	keys := journalTableKeys[table]
+	clauses := make([]string, len(keys))
+	args := make([]any, len(keys))
+	for i, key := range keys {
+		clauses[i] = key + " = ?"
+		args[i] = row[key]
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// decodeJournalRows parses stored row images, keeping integers as int64.
+func decodeJournalRows(data string) ([]journalRow, error) {
+	if data == "" {
+		return nil, nil
+	}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var rows []journalRow
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("decode journal rows: %w", err)
+	}
+	for _, row := range rows {
+		for column, value := range row {
+			number, ok := value.(json.Number)
+			if !ok {
+				continue
+			}
+			if i, err := number.Int64(); err == nil {
+				row[column] = i
+			} else if f, err := number.Float64(); err == nil {
+				row[column] = f
+			}
+		}
+	}
+	return rows, nil
+}
+
+// sameRow reports whether two row images hold identical values.
+func sameRow(a, b journalRow) bool {
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(left, right)
+}
+
+// sameContextItem compares what a context item points at, ignoring ordinal
+// and timestamps.
+func sameContextItem(a, b journalRow) bool {
+	return fmt.Sprint(a["item_type"]) == fmt.Sprint(b["item_type"]) &&
+		fmt.Sprint(a["message_id"]) == fmt.Sprint(b["message_id"]) &&
+		fmt.Sprint(a["summary_id"]) == fmt.Sprint(b["summary_id"])
+}
+
+func insertJournalRow(ctx context.Context, q sqlQueryer, table string, row journalRow) error {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		placeholders[i] = "?"
+		args[i] = row[column]
+	}
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO `+table+` (`+strings.Join(columns, ", ")+`)
+		VALUES (`+strings.Join(placeholders, ", ")+`)
+	`, args...); err != nil {
+		return fmt.Errorf("restore %s row: %w", table, err)
+	}
+	return nil
+}
+
+// updateJournalRow writes every column of row back over the row with the same
+// key, leaving rows that reference it untouched.
+func updateJournalRow(ctx context.Context, q sqlQueryer, table string, row journalRow) error {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	assignments := make([]string, len(columns))
+	args := make([]any, 0, len(columns)+2)
+	for i, column := range columns {
+		assignments[i] = column + " = ?"
+		args = append(args, row[column])
+	}
+	where, keyArgs := journalKeyWhere(table, row)
+	args = append(args, keyArgs...)
+	if _, err := q.ExecContext(ctx, `UPDATE `+table+` SET `+strings.Join(assignments, ", ")+` WHERE `+where, args...); err != nil {
+		return fmt.Errorf("revert %s row: %w", table, err)
+	}
+	return nil
+}
+
+// loadJournalOps returns recent operations, newest first. kind and
+// conversationID filter when non-empty / positive.
+func loadJournalOps(ctx context.Context, q sqlQueryer, kind string, conversationID int64, limit int) ([]journalOp, error) {
+	exists, err := journalTablesExist(ctx, q)
+	if err != nil || !exists {
+		return nil, err
+	}
+	query := `
+		SELECT o.op_id, o.kind, o.conversation_id, o.description, o.created_at, o.undone_at,
+			(SELECT COUNT(*) FROM ` + journalEntriesTable + ` e WHERE e.op_id = o.op_id)
+		FROM ` + journalOpsTable + ` o
+		WHERE 1 = 1
+	`
+	var args []any
+	if kind != "" {
+		query += " AND o.kind = ?"
+		args = append(args, kind)
+	}
+	if conversationID > 0 {
+		query += " AND o.conversation_id = ?"
+		args = append(args, conversationID)
+	}
+	query += " ORDER BY o.op_id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query journal ops: %w", err)
+	}
+	defer rows.Close()
+
+	var ops []journalOp
+	for rows.Next() {
+		var op journalOp
+		if err := rows.Scan(&op.opID, &op.kind, &op.conversationID, &op.description, &op.createdAt, &op.undoneAt, &op.entryCount); err != nil {
+			return nil, fmt.Errorf("scan journal op: %w", err)
+		}
+		ops = append(ops, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate journal ops: %w", err)
+	}
+	return ops, nil
+}
+
+func loadJournalOp(ctx context.Context, q sqlQueryer, opID int64) (journalOp, []journalEntry, error) {
+	exists, err := journalTablesExist(ctx, q)
+	if err != nil {
+		return journalOp{}, nil, err
+	}
+	if !exists {
+		return journalOp{}, nil, fmt.Errorf("journal op %d not found (no operations journaled yet)", opID)
+	}
+
+	var op journalOp
+	if err := q.QueryRowContext(ctx, `
+		SELECT op_id, kind, conversation_id, description, created_at, undone_at
+		FROM `+journalOpsTable+`
+		WHERE op_id = ?
+	`, opID).Scan(&op.opID, &op.kind, &op.conversationID, &op.description, &op.createdAt, &op.undoneAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return journalOp{}, nil, fmt.Errorf("journal op %d not found", opID)
+		}
+		return journalOp{}, nil, fmt.Errorf("query journal op %d: %w", opID, err)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT seq, table_name, action, COALESCE(old_rows, ''), COALESCE(new_rows, '')
+		FROM `+journalEntriesTable+`
+		WHERE op_id = ?
+		ORDER BY seq ASC
+	`, opID)
+	if err != nil {
+		return journalOp{}, nil, fmt.Errorf("query journal entries for op %d: %w", opID, err)
+	}
+	defer rows.Close()
+
+	var entries []journalEntry
+	for rows.Next() {
+		var entry journalEntry
+		if err := rows.Scan(&entry.seq, &entry.tableName, &entry.action, &entry.oldRows, &entry.newRows); err != nil {
+			return journalOp{}, nil, fmt.Errorf("scan journal entry for op %d: %w", opID, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return journalOp{}, nil, fmt.Errorf("iterate journal entries for op %d: %w", opID, err)
+	}
+	op.entryCount = len(entries)
+	return op, entries, nil
+}
+
+// undoJournalOp inverts an operation's entries in reverse order inside one
+// transaction. Each entry first checks that the rows still look the way the
+// operation left them; if anything changed since, nothing is undone. Context
+// items appended after the operation (new turns from the gateway) are kept
+// after the restored window.
+func undoJournalOp(ctx context.Context, db *sql.DB, opID int64) (journalOp, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return journalOp{}, fmt.Errorf("begin undo transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	op, entries, err := loadJournalOp(ctx, tx, opID)
+	if err != nil {
+		return journalOp{}, err
+	}
+	if op.undoneAt.Valid {
+		return journalOp{}, fmt.Errorf("journal op %d was already undone at %s", opID, op.undoneAt.String)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := undoJournalEntry(ctx, tx, op, entries[i]); err != nil {
+			return journalOp{}, fmt.Errorf("undo op %d entry %d (%s %s): %w", opID, entries[i].seq, entries[i].action, entries[i].tableName, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE `+journalOpsTable+` SET undone_at = datetime('now') WHERE op_id = ?
+	`, opID); err != nil {
+		return journalOp{}, fmt.Errorf("mark journal op %d undone: %w", opID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return journalOp{}, fmt.Errorf("commit undo: %w", err)
+	}
+	rollback = false
+	return op, nil
+}
+
+func undoJournalEntry(ctx context.Context, q sqlQueryer, op journalOp, entry journalEntry) error {
+	oldRows, err := decodeJournalRows(entry.oldRows)
+	if err != nil {
+		return err
+	}
+	newRows, err := decodeJournalRows(entry.newRows)
+	if err != nil {
+		return err
+	}
+
+	if entry.action == journalActionContext {
+		return undoContextEntry(ctx, q, op.conversationID, oldRows, newRows)
+	}
+	if _, ok := journalTableKeys[entry.tableName]; !ok {
+		return fmt.Errorf("unsupported journal table %q", entry.tableName)
+	}
+
+	switch entry.action {
+	case journalActionInsert:
+		for _, row := range newRows {
+			where, args := journalKeyWhere(entry.tableName, row)
+			current, err := captureRows(ctx, q, entry.tableName, where, args...)
+			if err != nil {
+				return err
+			}
+			if len(current) != 1 || !sameRow(current[0], row) {
+				return fmt.Errorf("%s row %v changed since the operation", entry.tableName, args)
+			}
+			if _, err := q.ExecContext(ctx, `DELETE FROM `+entry.tableName+` WHERE `+where, args...); err != nil {
+				return fmt.Errorf("delete inserted %s row: %w", entry.tableName, err)
+			}
+		}
+	case journalActionDelete:
+		for _, row := range oldRows {
+			where, args := journalKeyWhere(entry.tableName, row)
+			current, err := captureRows(ctx, q, entry.tableName, where, args...)
+			if err != nil {
+				return err
+			}
+			if len(current) != 0 {
+				return fmt.Errorf("%s row %v was recreated since the operation", entry.tableName, args)
+			}
+			if err := insertJournalRow(ctx, q, entry.tableName, row); err != nil {
+				return err
+			}
+		}
+	case journalActionUpdate:
+		for i, row := range newRows {
+			where, args := journalKeyWhere(entry.tableName, row)
+			current, err := captureRows(ctx, q, entry.tableName, where, args...)
+			if err != nil {
+				return err
+			}
+			if len(current) != 1 || !sameRow(current[0], row) {
+				return fmt.Errorf("%s row %v changed since the operation", entry.tableName, args)
+			}
+			if err := updateJournalRow(ctx, q, entry.tableName, oldRows[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown journal action %q", entry.action)
+	}
+	return nil
+}
+
+// undoContextEntry restores a conversation's context window. The current
+// window must begin with exactly the items the operation produced; anything
+// after that was appended later and is kept.
+func undoContextEntry(ctx context.Context, q sqlQueryer, conversationID int64, oldRows, newRows []journalRow) error {
+	current, err := captureContext(ctx, q, conversationID)
+	if err != nil {
+		return err
+	}
+	if len(current) < len(newRows) {
+		return fmt.Errorf("context for conversation %d has %d items, expected at least %d", conversationID, len(current), len(newRows))
+	}
+	for i, row := range newRows {
+		if !sameContextItem(current[i], row) {
+			return fmt.Errorf("context for conversation %d changed at ordinal %d since the operation", conversationID, i)
+		}
+	}
+	appended := current[len(newRows):]
+
+	if _, err := q.ExecContext(ctx, `DELETE FROM context_items WHERE conversation_id = ?`, conversationID); err != nil {
+		return fmt.Errorf("clear context for conversation %d: %w", conversationID, err)
+	}
+	ordinal := int64(0)
+	for _, row := range append(append([]journalRow{}, oldRows...), appended...) {
+		restored := make(journalRow, len(row))
+		for column, value := range row {
+			restored[column] = value
+		}
+		restored["ordinal"] = ordinal
+		if err := insertJournalRow(ctx, q, "context_items", restored); err != nil {
+			return err
+		}
+		ordinal++
+	}
+	return nil
+}
+
+// undoOptions configures the undo CLI.
+type undoOptions struct {
+	apply  bool
+	dbPath string
+}
+
+func runUndoCommand(args []string) error {
+	opts, opID, err := parseUndoArgs(args)
+	if err != nil {
+		return err
+	}
+
+	defaults, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+	paths, err := pathsForDB(defaults, opts.dbPath)
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if opID == 0 {
+		ops, err := loadJournalOps(ctx, db, "", 0, journalListLimit)
+		if err != nil {
+			return err
+		}
+		printJournalOps(ops)
+		return nil
+	}
+
+	op, entries, err := loadJournalOp(ctx, db, opID)
+	if err != nil {
+		return err
+	}
+	printJournalOpDetail(op, entries)
+	if op.undoneAt.Valid {
+		fmt.Printf("\nAlready undone at %s.\n", op.undoneAt.String)
+		return nil
+	}
+	if !opts.apply {
+		fmt.Println("\nDry run. Use --apply to execute.")
+		return nil
+	}
+
+	fmt.Println()
+	if err := backupBeforeApply(ctx, db, paths, fmt.Sprintf("undo-op%d", opID)); err != nil {
+		return err
+	}
+	if _, err := undoJournalOp(ctx, db, opID); err != nil {
+		return err
+	}
+	fmt.Printf("\nDone. Undid op %d (%s).\n", op.opID, op.description)
+	return nil
+}
+
+func printJournalOps(ops []journalOp) {
+	if len(ops) == 0 {
+		fmt.Println("No journaled operations.")
+		return
+	}
+	fmt.Printf("Recent operations (newest first):\n")
+	for _, op := range ops {
+		state := ""
+		if op.undoneAt.Valid {
+			state = "  [undone]"
+		}
+		fmt.Printf("  %5d  %s  %-10s conv %-6d %s (%d entries)%s\n",
+			op.opID, op.createdAt, op.kind, op.conversationID, op.description, op.entryCount, state)
+	}
+	fmt.Println("\nRun `lcm-tui undo <op-id>` to preview an undo.")
+}
+
+func printJournalOpDetail(op journalOp, entries []journalEntry) {
+	fmt.Printf("Op %d: %s (%s, conversation %d, %s)\n", op.opID, op.description, op.kind, op.conversationID, op.createdAt)
+	fmt.Println("Undo will, in order:")
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		oldRows, _ := decodeJournalRows(entry.oldRows)
+		newRows, _ := decodeJournalRows(entry.newRows)
+		switch entry.action {
+		case journalActionInsert:
+			fmt.Printf("  delete %d inserted %s rows\n", len(newRows), entry.tableName)
+		case journalActionDelete:
+			fmt.Printf("  restore %d deleted %s rows\n", len(oldRows), entry.tableName)
+		case journalActionUpdate:
+			fmt.Printf("  revert %d updated %s rows\n", len(oldRows), entry.tableName)
+		case journalActionContext:
+			fmt.Printf("  restore context window (%d items -> %d items)\n", len(newRows), len(oldRows))
+		}
+	}
+}
+
+func parseUndoArgs(args []string) (undoOptions, int64, error) {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	apply := fs.Bool("apply", false, "apply the undo")
+	dbPath := fs.String("db", "", "lcm.db holding the journal (default: ~/.openclaw/lcm.db)")
+
+	normalizedArgs, err := normalizeUndoArgs(args)
+	if err != nil {
+		return undoOptions{}, 0, fmt.Errorf("%w\n%s", err, undoUsageText())
+	}
+	if err := fs.Parse(normalizedArgs); err != nil {
+		return undoOptions{}, 0, fmt.Errorf("%w\n%s", err, undoUsageText())
+	}
+
+	opts := undoOptions{apply: *apply, dbPath: *dbPath}
+	switch fs.NArg() {
+	case 0:
+		if opts.apply {
+			return undoOptions{}, 0, fmt.Errorf("--apply requires an op ID\n%s", undoUsageText())
+		}
+		return opts, 0, nil
+	case 1:
+		opID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+		if err != nil || opID <= 0 {
+			return undoOptions{}, 0, fmt.Errorf("invalid op ID %q\n%s", fs.Arg(0), undoUsageText())
+		}
+		return opts, opID, nil
+	default:
+		return undoOptions{}, 0, fmt.Errorf("expected at most one op ID\n%s", undoUsageText())
+	}
+}
+
+func normalizeUndoArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--db":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"):
+			flags = append(flags, arg)
+		default:
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func undoUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui undo [--db PATH]                     # list recent journaled operations
+  lcm-tui undo <op-id> [--db PATH] [--apply]   # preview, then invert one operation
+
+Dissolve, transplant and repair record every row they change in the
+lcm_tui_journal table. Undo refuses to run if those rows changed since.
+--db undoes an operation journaled in another lcm.db file, such as a
+transplant's --target-db; its snapshot goes to that file's backups.
+`)
+}
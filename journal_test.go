@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// journalTestFixture gives conversation 1 two leaves under a condensed
+// summary in context followed by two raw messages, and conversation 2 one
+// leaf and one raw message, so every journaled operation has something to
+// rewrite.
+const journalTestFixture = `
+CREATE TABLE large_files (
+	file_id TEXT PRIMARY KEY,
+	conversation_id INTEGER NOT NULL,
+	file_name TEXT,
+	mime_type TEXT,
+	byte_size INTEGER,
+	storage_uri TEXT NOT NULL,
+	exploration_summary TEXT,
+	created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+INSERT INTO conversations (conversation_id, session_id) VALUES (1, 'sess-1'), (2, 'sess-2');
+INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count) VALUES
+	(1, 1, 0, 'user', 'deploy the pipeline', 4),
+	(2, 1, 1, 'assistant', 'deployed to staging', 4),
+	(3, 1, 2, 'user', 'promote to production', 4),
+	(4, 1, 3, 'assistant', 'promoted; smoke tests pass', 5),
+	(5, 1, 4, 'user', 'tag the release', 4),
+	(6, 2, 0, 'user', 'rotate the keys', 4),
+	(7, 2, 1, 'assistant', 'keys rotated', 3);
+INSERT INTO message_parts (part_id, message_id, session_id, part_type, ordinal, text_content) VALUES
+	('part_1', 1, 'sess-1', 'text', 0, 'deploy the pipeline');
+INSERT INTO summaries (summary_id, conversation_id, kind, depth, content, token_count, created_at) VALUES
+	('sum_a', 1, 'leaf', 0, 'Deployed the pipeline to staging.', 8, '2026-01-01 00:01:00'),
+	('sum_b', 1, 'leaf', 0, 'Promoted to production.', 6, '2026-01-01 00:02:00'),
+	('sum_c', 1, 'condensed', 1, 'Shipped the pipeline.', 5, '2026-01-01 00:03:00'),
+	('sum_d', 2, 'leaf', 0, 'Rotated the keys.', 5, '2026-01-02 00:01:00');
+INSERT INTO summary_messages VALUES ('sum_a', 1, 0), ('sum_a', 2, 1), ('sum_b', 3, 0), ('sum_d', 6, 0);
+INSERT INTO summary_parents VALUES ('sum_c', 'sum_a', 0), ('sum_c', 'sum_b', 1);
+INSERT INTO context_items (conversation_id, ordinal, item_type, summary_id, message_id, created_at) VALUES
+	(1, 0, 'summary', 'sum_c', NULL, '2026-01-01 00:03:00'),
+	(1, 1, 'message', NULL, 4, '2026-01-01 00:04:00'),
+	(1, 2, 'message', NULL, 5, '2026-01-01 00:05:00'),
+	(2, 0, 'summary', 'sum_d', NULL, '2026-01-02 00:01:00'),
+	(2, 1, 'message', NULL, 7, '2026-01-02 00:02:00');
+`
+
+// journalTestTables are compared before an operation and after its undo.
+var journalTestTables = []string{
+	"conversations", "messages", "message_parts", "summaries",
+	"summary_parents", "summary_messages", "context_items",
+}
+
+func newJournalTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lcm.db")
+	create, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := create.Exec(repairTestSchema + journalTestFixture); err != nil {
+		t.Fatal(err)
+	}
+	if err := create.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := openLCMDB(path, dbReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// snapshotJournalTables returns every row of journalTestTables as sorted
+// JSON, so reinserted rows compare equal whatever their rowid.
+func snapshotJournalTables(t *testing.T, db *sql.DB) map[string][]string {
+	t.Helper()
+	snapshot := make(map[string][]string, len(journalTestTables))
+	for _, table := range journalTestTables {
+		rows, err := captureRows(context.Background(), db, table, "1 = 1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded := make([]string, 0, len(rows))
+		for _, row := range rows {
+			data, err := json.Marshal(row)
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded = append(encoded, string(data))
+		}
+		sort.Strings(encoded)
+		snapshot[table] = encoded
+	}
+	return snapshot
+}
+
+func execJournalTest(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", strings.TrimSpace(query), err)
+	}
+}
+
+func TestUndoRestoresEachOperation(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, db *sql.DB)
+		apply func(t *testing.T, ctx context.Context, db *sql.DB) int64
+	}{
+		{
+			name: "dissolve --purge",
+			apply: func(t *testing.T, ctx context.Context, db *sql.DB) int64 {
+				plan, err := buildDissolvePlan(ctx, db, 1, "sum_c")
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, opID, err := applyDissolvePlan(ctx, db, plan, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return opID
+			},
+		},
+		{
+			name: "transplant",
+			apply: func(t *testing.T, ctx context.Context, db *sql.DB) int64 {
+				plan, err := buildTransplantPlan(ctx, db, 1, 2, transplantSelection{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, opID, err := applyTransplant(ctx, db, plan, io.Discard)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return opID
+			},
+		},
+		{
+			name: "import",
+			apply: func(t *testing.T, ctx context.Context, db *sql.DB) int64 {
+				bundle, err := buildExportBundle(ctx, db, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				opts := importOptions{bundlePath: "conv1.json", intoConversationID: 2, attach: importContextAppend, apply: true}
+				plan, err := buildImportPlan(ctx, db, bundle, opts, "lcm.db")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(plan.transplant.crossDB.messages) == 0 {
+					t.Fatal("import plan copies no messages")
+				}
+				_, opID, err := applyImport(ctx, db, &plan, io.Discard)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return opID
+			},
+		},
+		{
+			name: "condense",
+			setup: func(t *testing.T, db *sql.DB) {
+				execJournalTest(t, db, `DELETE FROM context_items WHERE conversation_id = 1`)
+				execJournalTest(t, db, `INSERT INTO context_items (conversation_id, ordinal, item_type, summary_id, message_id, created_at) VALUES
+					(1, 0, 'summary', 'sum_a', NULL, '2026-01-01 00:01:00'),
+					(1, 1, 'summary', 'sum_b', NULL, '2026-01-01 00:02:00'),
+					(1, 2, 'message', NULL, 4, '2026-01-01 00:04:00')`)
+			},
+			apply: func(t *testing.T, ctx context.Context, db *sql.DB) int64 {
+				plan, err := buildCondensePlan(ctx, db, 1, 0, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, opID, err := applyCondense(ctx, db, plan, "Shipped it.", 3)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return opID
+			},
+		},
+		{
+			name: "compact",
+			apply: func(t *testing.T, ctx context.Context, db *sql.DB) int64 {
+				plan, err := buildCompactPlan(ctx, db, 1, 1, 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, opID, err := applyCompact(ctx, db, plan, "Promoted and tagged.", 4)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return opID
+			},
+		},
+		{
+			name: "repair",
+			setup: func(t *testing.T, db *sql.DB) {
+				execJournalTest(t, db, `UPDATE summaries SET content = ? WHERE summary_id IN ('sum_a', 'sum_c')`, corruptedSummaryMarker+" garbage")
+			},
+			apply: func(t *testing.T, ctx context.Context, db *sql.DB) int64 {
+				opts := newRepairTestOptions(t)
+				client, err := newSummarizer(opts.summarizer, appDataPaths{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				plan, err := buildRepairPlan(ctx, db, 1, "", opts.detectors)
+				if err != nil {
+					t.Fatal(err)
+				}
+				run, err := startRepairRun(ctx, db, []int64{1}, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				outcome, err := applyRepairs(ctx, db, plan, opts, client, run)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if outcome.repaired != 2 {
+					t.Fatalf("repaired %d summaries, want 2", outcome.repaired)
+				}
+				if err := run.finish(ctx, db); err != nil {
+					t.Fatal(err)
+				}
+				return run.opID
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newJournalTestDB(t)
+			if tt.setup != nil {
+				tt.setup(t, db)
+			}
+			before := snapshotJournalTables(t, db)
+
+			opID := tt.apply(t, ctx, db)
+			if reflect.DeepEqual(snapshotJournalTables(t, db), before) {
+				t.Fatal("operation changed nothing")
+			}
+
+			if _, err := undoJournalOp(ctx, db, opID); err != nil {
+				t.Fatal(err)
+			}
+			after := snapshotJournalTables(t, db)
+			for _, table := range journalTestTables {
+				if !reflect.DeepEqual(after[table], before[table]) {
+					t.Errorf("%s after undo:\n  got  %v\n  want %v", table, after[table], before[table])
+				}
+			}
+
+			if _, err := undoJournalOp(ctx, db, opID); err == nil {
+				t.Fatal("second undo of the same op succeeded")
+			}
+		})
+	}
+}
+
+func TestUndoKeepsItemsAppendedAfterOperation(t *testing.T) {
+	ctx := context.Background()
+	db := newJournalTestDB(t)
+	before, err := captureContext(ctx, db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := buildDissolvePlan(ctx, db, 1, "sum_c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, opID, err := applyDissolvePlan(ctx, db, plan, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new turn arrives after the dissolve.
+	execJournalTest(t, db, `INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count) VALUES (8, 1, 5, 'assistant', 'tagged v1.2', 3)`)
+	execJournalTest(t, db, `INSERT INTO context_items (conversation_id, ordinal, item_type, message_id) SELECT 1, MAX(ordinal) + 1, 'message', 8 FROM context_items WHERE conversation_id = 1`)
+
+	if _, err := undoJournalOp(ctx, db, opID); err != nil {
+		t.Fatal(err)
+	}
+	after, err := captureContext(ctx, db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before)+1 {
+		t.Fatalf("context has %d items after undo, want %d", len(after), len(before)+1)
+	}
+	for i, row := range before {
+		if !sameRow(after[i], row) {
+			t.Errorf("ordinal %d = %v, want %v", i, after[i], row)
+		}
+	}
+	last := after[len(after)-1]
+	if last["message_id"] != int64(8) || last["ordinal"] != int64(len(before)) {
+		t.Fatalf("appended item = %v, want message 8 at ordinal %d", last, len(before))
+	}
+}
+
+func TestUndoRefusesEditedRows(t *testing.T) {
+	tests := []struct {
+		name string
+		edit string
+	}{
+		{"summary rewritten", `UPDATE summaries SET content = 'edited' WHERE summary_id NOT IN ('sum_a', 'sum_b', 'sum_c', 'sum_d')`},
+		{"context item dropped", `DELETE FROM context_items WHERE conversation_id = 1 AND ordinal = 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newJournalTestDB(t)
+			plan, err := buildCompactPlan(ctx, db, 1, 1, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, opID, err := applyCompact(ctx, db, plan, "Promoted.", 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			execJournalTest(t, db, tt.edit)
+			edited := snapshotJournalTables(t, db)
+
+			if _, err := undoJournalOp(ctx, db, opID); err == nil {
+				t.Fatal("undo succeeded over an edited row")
+			}
+			if !reflect.DeepEqual(snapshotJournalTables(t, db), edited) {
+				t.Fatal("refused undo still changed rows")
+			}
+			op, _, err := loadJournalOp(ctx, db, opID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if op.undoneAt.Valid {
+				t.Fatal("refused undo marked the op undone")
+			}
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadMode distinguishes a first open (reset cursors, switch screens) from an
+// in-place reload of the current screen.
+type loadMode int
+
+const (
+	loadOpen loadMode = iota
+	loadReload
+)
+
+// sessionsLoadedMsg carries one batch of session list entries.
+type sessionsLoadedMsg struct {
+	seq        int
+	agentName  string
+	files      []sessionFileEntry
+	sessions   []sessionEntry
+	nextCursor int
+	initial    bool
+	err        error
+}
+
+// messagesLoadedMsg carries parsed JSONL messages for one session.
+type messagesLoadedMsg struct {
+	seq          int
+	sessionIndex int
+	filename     string
+	messages     []sessionMessage
+	mode         loadMode
+	err          error
+}
+
+// summaryGraphLoadedMsg carries the summary DAG for the current session.
+type summaryGraphLoadedMsg struct {
+	seq   int
+	graph summaryGraph
+	mode  loadMode
+	note  string // optional status override, e.g. after a dissolve
+	err   error
+}
+
+// contextItemsLoadedMsg carries the active context window for the current session.
+type contextItemsLoadedMsg struct {
+	seq   int
+	items []contextItemEntry
+	mode  loadMode
+	note  string // optional status override, e.g. after a condense
+	err   error
+}
+
+// largeFilesLoadedMsg carries large-file metadata for the current session.
+type largeFilesLoadedMsg struct {
+	seq   int
+	files []largeFileEntry
+	mode  loadMode
+	err   error
+}
+
+// lcmWriteDoneMsg reports a snapshot-and-write run off the UI goroutine. When
+// reloadSession is set the context view is reloaded with the cursor on
+// index cursor (-1 keeps it where it is).
+type lcmWriteDoneMsg struct {
+	seq           int
+	note          string
+	reloadSession string
+	cursor        int
+	err           error
+}
+
+// summarySourcesLoadedMsg carries source messages for one summary. Sources are
+// cached by summary ID, so these results are never considered stale.
+type summarySourcesLoadedMsg struct {
+	summaryID string
+	sources   []summarySource
+	err       error
+}
+
+// beginLoad cancels any in-flight load and starts a new one labeled for the
+// status bar. Results carrying an older sequence number are discarded.
+func (m *model) beginLoad(label string) (context.Context, int) {
+	if m.loadCancel != nil {
+		m.loadCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.loadSeq++
+	m.loadCancel = cancel
+	m.loading = label
+	return ctx, m.loadSeq
+}
+
+// cancelLoad abandons the in-flight load, if any, when the user navigates away.
+func (m *model) cancelLoad() {
+	if m.loadCancel == nil {
+		return
+	}
+	m.loadCancel()
+	m.loadCancel = nil
+	m.loadSeq++
+	m.loading = ""
+}
+
+// finishLoad reports whether a result with seq is still current and, if so,
+// clears the loading state.
+func (m *model) finishLoad(seq int) bool {
+	if seq != m.loadSeq {
+		return false
+	}
+	if m.loadCancel != nil {
+		m.loadCancel()
+		m.loadCancel = nil
+	}
+	m.loading = ""
+	return true
+}
+
+// isLoading reports whether the spinner should keep ticking.
+func (m model) isLoading() bool {
+	return m.loading != "" || len(m.pendingSources) > 0
+}
+
+func loadSessionBatchCmd(ctx context.Context, seq int, agentName string, files []sessionFileEntry, offset, limit int, db *sql.DB, initial bool) tea.Cmd {
+	return func() tea.Msg {
+		sessions, next, err := loadSessionBatch(ctx, files, offset, limit, db)
+		return sessionsLoadedMsg{seq: seq, agentName: agentName, files: files, sessions: sessions, nextCursor: next, initial: initial, err: err}
+	}
+}
+
+func loadMessagesCmd(ctx context.Context, seq, sessionIndex int, session sessionEntry, mode loadMode) tea.Cmd {
+	return func() tea.Msg {
+		messages, err := parseSessionMessages(ctx, session.path)
+		return messagesLoadedMsg{seq: seq, sessionIndex: sessionIndex, filename: session.filename, messages: messages, mode: mode, err: err}
+	}
+}
+
+func loadSummaryGraphCmd(ctx context.Context, seq int, db *sql.DB, sessionID string, mode loadMode, note string) tea.Cmd {
+	return func() tea.Msg {
+		graph, err := loadSummaryGraph(ctx, db, sessionID)
+		return summaryGraphLoadedMsg{seq: seq, graph: graph, mode: mode, note: note, err: err}
+	}
+}
+
+func loadContextItemsCmd(ctx context.Context, seq int, db *sql.DB, sessionID string, mode loadMode, note string) tea.Cmd {
+	return func() tea.Msg {
+		items, err := loadContextItems(ctx, db, sessionID)
+		return contextItemsLoadedMsg{seq: seq, items: items, mode: mode, note: note, err: err}
+	}
+}
+
+func loadLargeFilesCmd(ctx context.Context, seq int, db *sql.DB, sessionID string, mode loadMode) tea.Cmd {
+	return func() tea.Msg {
+		files, err := loadLargeFiles(ctx, db, sessionID)
+		return largeFilesLoadedMsg{seq: seq, files: files, mode: mode, err: err}
+	}
+}
+
+func loadSummarySourcesCmd(db *sql.DB, summaryID string) tea.Cmd {
+	return func() tea.Msg {
+		sources, err := loadSummarySources(context.Background(), db, summaryID)
+		return summarySourcesLoadedMsg{summaryID: summaryID, sources: sources, err: err}
+	}
+}
+
+// lcmWriteCmd runs write, which snapshots lcm.db and applies one edit, in the
+// background. The write gets its own context so navigating away cannot
+// cancel it between the snapshot and the commit.
+func lcmWriteCmd(seq int, reloadSession string, cursor int, write func(ctx context.Context) (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		note, err := write(context.Background())
+		return lcmWriteDoneMsg{seq: seq, note: note, reloadSession: reloadSession, cursor: cursor, err: err}
+	}
+}
+
+// handleLCMWriteDone reports a finished write even if the user has moved on,
+// since it is already committed; the context only reloads if it is still
+// the current load.
+func (m model) handleLCMWriteDone(msg lcmWriteDoneMsg) (tea.Model, tea.Cmd) {
+	current := m.finishLoad(msg.seq)
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+	m.status = msg.note
+	if !current || msg.reloadSession == "" {
+		return m, nil
+	}
+	if msg.cursor >= 0 && msg.cursor != m.contextCursor {
+		m.contextCursor = msg.cursor
+		m.contextDetailScroll = 0
+	}
+	ctx, seq := m.beginLoad("Reloading context...")
+	return m, m.startLoad(loadContextItemsCmd(ctx, seq, m.db, msg.reloadSession, loadReload, msg.note))
+}
+
+// startLoad wraps a loader command with a spinner tick so the status bar
+// animates while the load is in flight.
+func (m model) startLoad(cmd tea.Cmd) tea.Cmd {
+	return tea.Batch(cmd, m.spinner.Tick)
+}
+
+func (m model) handleSessionsLoaded(msg sessionsLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+
+	previousLoaded := len(m.sessions)
+	m.sessionFileCursor = msg.nextCursor
+	if msg.initial {
+		m.sessionFiles = msg.files
+		m.sessions = msg.sessions
+		m.sessionCursor = clamp(m.sessionCursor, 0, max(0, len(m.sessions)-1))
+		m.messages = nil
+		m.summary = summaryGraph{}
+		m.summaryRows = nil
+		m.screen = screenSessions
+		m.status = fmt.Sprintf("Loaded %d of %d sessions for agent %s", len(m.sessions), len(m.sessionFiles), msg.agentName)
+		return m, nil
+	}
+	m.sessions = append(m.sessions, msg.sessions...)
+	// Keep the cursor moving past the old end of the list when the batch was
+	// triggered by scrolling onto the last loaded row.
+	if len(msg.sessions) > 0 && m.sessionCursor == previousLoaded-1 && m.sessionAdvancePending {
+		m.sessionCursor = clamp(m.sessionCursor+1, 0, len(m.sessions)-1)
+	}
+	m.sessionAdvancePending = false
+	if len(msg.sessions) > 0 {
+		m.status = fmt.Sprintf("Loaded %d of %d sessions", len(m.sessions), len(m.sessionFiles))
+	}
+	return m, nil
+}
+
+func (m model) handleMessagesLoaded(msg messagesLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+
+	m.sessionCursor = clamp(msg.sessionIndex, 0, max(0, len(m.sessions)-1))
+	m.messages = msg.messages
+	m.screen = screenConversation
+	m.refreshConversationViewport()
+	if msg.mode == loadReload {
+		m.status = fmt.Sprintf("Reloaded %d messages", len(msg.messages))
+		return m, nil
+	}
+	if conversationID, ok := m.currentConversationID(); ok {
+		m.status = fmt.Sprintf("Loaded %d messages from %s (conv_id:%d)", len(msg.messages), msg.filename, conversationID)
+	} else {
+		m.status = fmt.Sprintf("Loaded %d messages from %s", len(msg.messages), msg.filename)
+	}
+	return m, nil
+}
+
+func (m model) handleSummaryGraphLoaded(msg summaryGraphLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		if msg.note != "" {
+			m.status = fmt.Sprintf("%s, but reload failed: %v", msg.note, msg.err)
+		} else {
+			m.status = "Error: " + msg.err.Error()
+		}
+		return m, nil
+	}
+
+	m.summary = msg.graph
+	m.summaryConsumers = nil
+	m.summaryRows = buildSummaryRows(msg.graph)
+	if msg.mode == loadOpen {
+		m.summaryCursor = 0
+	} else {
+		m.summaryCursor = clamp(m.summaryCursor, 0, len(m.summaryRows)-1)
+	}
+	m.summaryDetailScroll = 0
+	m.summarySources = make(map[string][]summarySource)
+	m.summarySourceErr = make(map[string]string)
+	m.screen = screenSummaries
+	switch {
+	case msg.note != "":
+		m.status = msg.note
+	case msg.mode == loadReload:
+		m.status = fmt.Sprintf("Reloaded %d summaries", len(msg.graph.nodes))
+	default:
+		m.status = fmt.Sprintf("Loaded %d summaries for conversation %d", len(msg.graph.nodes), msg.graph.conversationID)
+	}
+	return m, m.loadCurrentSummarySources()
+}
+
+func (m model) handleContextItemsLoaded(msg contextItemsLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		if msg.note != "" {
+			m.status = fmt.Sprintf("%s, but reload failed: %v", msg.note, msg.err)
+		} else {
+			m.status = "Error: " + msg.err.Error()
+		}
+		return m, nil
+	}
+
+	m.contextItems = msg.items
+	m.contextMark = -1
+	m.screen = screenContext
+	if msg.mode == loadReload {
+		m.contextCursor = clamp(m.contextCursor, 0, len(m.contextItems)-1)
+		m.status = firstNonEmpty(msg.note, fmt.Sprintf("Reloaded %d context items", len(msg.items)))
+		return m, nil
+	}
+	m.contextCursor = 0
+	m.contextDetailScroll = 0
+	m.status = contextStatusLine(msg.items)
+	return m, nil
+}
+
+func (m model) handleLargeFilesLoaded(msg largeFilesLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+
+	m.largeFiles = msg.files
+	m.screen = screenFiles
+	if msg.mode == loadReload {
+		m.fileCursor = clamp(m.fileCursor, 0, len(m.largeFiles)-1)
+		m.status = fmt.Sprintf("Reloaded %d large files", len(msg.files))
+		return m, nil
+	}
+	m.fileCursor = 0
+	if len(msg.files) == 0 {
+		m.status = "No large files for this session"
+	} else {
+		m.status = fmt.Sprintf("Loaded %d large files", len(msg.files))
+	}
+	return m, nil
+}
+
+func (m model) handleSummarySourcesLoaded(msg summarySourcesLoadedMsg) (tea.Model, tea.Cmd) {
+	delete(m.pendingSources, msg.summaryID)
+	if msg.err != nil {
+		m.summarySourceErr[msg.summaryID] = msg.err.Error()
+		return m, nil
+	}
+	m.summarySources[msg.summaryID] = msg.sources
+	return m, nil
+}
+
+// contextStatusLine summarizes item and token totals for the context screen.
+func contextStatusLine(items []contextItemEntry) string {
+	if len(items) == 0 {
+		return "No context items for this session"
+	}
+	totalTokens := 0
+	summaryCount := 0
+	messageCount := 0
+	for _, it := range items {
+		totalTokens += it.tokenCount
+		if it.itemType == "summary" {
+			summaryCount++
+		} else {
+			messageCount++
+		}
+	}
+	return fmt.Sprintf("Context: %d summaries + %d messages = %d items, %dk tokens",
+		summaryCount, messageCount, len(items), totalTokens/1000)
+}
+
+// loadingStatus prefixes the status bar with the spinner while loads run.
+func (m model) loadingStatus(status string) string {
+	if !m.isLoading() {
+		return status
+	}
+	label := m.loading
+	if label == "" {
+		label = "Loading sources..."
+	}
+	parts := []string{m.spinner.View() + " " + label}
+	if strings.TrimSpace(status) != "" {
+		parts = append(parts, status)
+	}
+	return strings.Join(parts, " | ")
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -21,6 +25,8 @@ const (
 	screenSummaries
 	screenFiles
 	screenContext
+	screenSearch
+	screenRepair
 )
 
 const (
@@ -32,6 +38,9 @@ const (
 type model struct {
 	screen screen
 	paths  appDataPaths
+	// db is the long-lived read-only handle shared by all browsing loads.
+	// Mutations open their own read-write handle.
+	db *sql.DB
 
 	agents            []agentEntry
 	sessionFiles      []sessionFileEntry
@@ -44,8 +53,11 @@ type model struct {
 	largeFiles []largeFileEntry
 	fileCursor int
 
-	contextItems  []contextItemEntry
-	contextCursor int
+	contextItems      []contextItemEntry
+	contextCursor     int
+	contextBudgetView bool
+	contextLimit      int
+	contextMark       int // anchor of the marked range, -1 when unset
 
 	agentCursor         int
 	sessionCursor       int
@@ -57,9 +69,37 @@ type model struct {
 	width        int
 	height       int
 
-	summarySources   map[string][]summarySource
-	summarySourceErr map[string]string
-	pendingDissolve  *dissolvePlan
+	summarySources     map[string][]summarySource
+	summarySourceErr   map[string]string
+	pendingDissolve    *dissolvePlan
+	pendingUndo        *journalOp
+	pendingCondense    *condenseReview
+	pendingCompact     *compactReview
+	pendingContextEdit *contextEdit
+	transplantPick     *transplantPicker
+	summaryConsumers   *summaryConsumerList
+
+	repairCandidates     []repairCandidate
+	repairCursor         int
+	repairConversationID int64
+	repairClient         summarizer
+	repairPrompts        *repairPrompts
+	repairTokens         tokenCounter
+	repairOpID           int64
+	repairBackup         string
+
+	searchInput  textinput.Model
+	searchQuery  string
+	searchHits   []searchHit
+	searchCursor int
+	searchReturn screen
+
+	spinner               spinner.Model
+	loading               string // label of the in-flight load, empty when idle
+	loadSeq               int
+	loadCancel            context.CancelFunc
+	pendingSources        map[string]bool
+	sessionAdvancePending bool
 
 	status string
 }
@@ -84,6 +124,27 @@ func main() {
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "resummarize" {
+		if err := runResummarizeCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui resummarize failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "compact" {
+		if err := runCompactCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui compact failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "recount" {
+		if err := runRecountCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui recount failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if len(os.Args) > 1 && os.Args[1] == "transplant" {
 		if err := runTransplantCommand(os.Args[2:]); err != nil {
 			fmt.Fprintf(os.Stderr, "lcm-tui transplant failed: %v\n", err)
@@ -91,6 +152,57 @@ func main() {
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExportCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui export failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImportCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui import failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "graph" {
+		if err := runGraphCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui graph failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "search" {
+		if err := runSearchCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui search failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		if err := runDoctorCommand(os.Args[2:]); err != nil {
+			if !errors.Is(err, errDoctorFindings) {
+				fmt.Fprintf(os.Stderr, "lcm-tui doctor failed: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backups" {
+		if err := runBackupsCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui backups failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "undo" {
+		if err := runUndoCommand(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "lcm-tui undo failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if len(os.Args) > 1 && os.Args[1] == "dissolve" {
 		if err := runDissolveCommand(os.Args[2:]); err != nil {
 			fmt.Fprintf(os.Stderr, "lcm-tui dissolve failed: %v\n", err)
@@ -100,6 +212,9 @@ func main() {
 	}
 
 	m := newModel()
+	if m.db != nil {
+		defer m.db.Close()
+	}
 	program := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := program.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "openclaw-tui failed: %v\n", err)
@@ -112,7 +227,14 @@ func newModel() model {
 		screen:           screenAgents,
 		summarySources:   make(map[string][]summarySource),
 		summarySourceErr: make(map[string]string),
+		searchInput:      textinput.New(),
+		spinner:          spinner.New(spinner.WithSpinner(spinner.Dot)),
+		pendingSources:   make(map[string]bool),
+		contextLimit:     defaultContextLimit,
+		contextMark:      -1,
 	}
+	m.searchInput.Placeholder = "summaries, messages, file explorations"
+	m.searchInput.Prompt = ""
 
 	paths, err := resolveDataPaths()
 	if err != nil {
@@ -128,6 +250,20 @@ func newModel() model {
 	}
 	m.agents = agents
 	m.status = fmt.Sprintf("Loaded %d agents from %s", len(agents), paths.agentsDir)
+	m.contextLimit, err = contextLimitFromEnv()
+	if err != nil {
+		m.status += " | Error: " + err.Error()
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, dbReadOnly)
+	if err != nil {
+		m.status += " | Error: " + err.Error()
+		return m
+	}
+	m.db = db
+	if mode, err := lcmJournalMode(context.Background(), db); err == nil && mode != "wal" {
+		m.status += fmt.Sprintf(" | LCM DB journal_mode=%s: browsing may briefly delay gateway writes", mode)
+	}
 	return m
 }
 
@@ -144,10 +280,47 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.refreshConversationViewport()
 		return m, nil
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" || msg.String() == "q" {
+		typing := m.screen == screenSearch && m.searchInput.Focused()
+		if msg.String() == "ctrl+c" || (msg.String() == "q" && !typing) {
 			return m, tea.Quit
 		}
+		if msg.String() == "/" && !typing && m.pendingDissolve == nil && m.pendingUndo == nil && m.pendingCondense == nil && m.pendingCompact == nil && m.pendingContextEdit == nil && m.transplantPick == nil && m.summaryConsumers == nil {
+			return m, m.startSearch()
+		}
 		return m.handleKey(msg)
+	case spinner.TickMsg:
+		if !m.isLoading() {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case sessionsLoadedMsg:
+		return m.handleSessionsLoaded(msg)
+	case messagesLoadedMsg:
+		return m.handleMessagesLoaded(msg)
+	case summaryGraphLoadedMsg:
+		return m.handleSummaryGraphLoaded(msg)
+	case contextItemsLoadedMsg:
+		return m.handleContextItemsLoaded(msg)
+	case condenseCandidateMsg:
+		return m.handleCondenseCandidate(msg)
+	case compactCandidateMsg:
+		return m.handleCompactCandidate(msg)
+	case largeFilesLoadedMsg:
+		return m.handleLargeFilesLoaded(msg)
+	case summarySourcesLoadedMsg:
+		return m.handleSummarySourcesLoaded(msg)
+	case searchResultsMsg:
+		return m.handleSearchResults(msg)
+	case lcmWriteDoneMsg:
+		return m.handleLCMWriteDone(msg)
+	case searchJumpLoadedMsg:
+		return m.handleSearchJumpLoaded(msg)
+	case repairPlanLoadedMsg:
+		return m.handleRepairPlanLoaded(msg)
+	case repairCandidateMsg:
+		return m.handleRepairCandidate(msg)
 	}
 	return m, nil
 }
@@ -166,6 +339,10 @@ func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleFilesKey(msg)
 	case screenContext:
 		return m.handleContextKey(msg)
+	case screenSearch:
+		return m.handleSearchKey(msg)
+	case screenRepair:
+		return m.handleRepairKey(msg)
 	default:
 		return m, nil
 	}
@@ -183,16 +360,13 @@ func (m model) handleAgentsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		agent := m.agents[m.agentCursor]
-		if err := m.loadInitialSessions(agent); err != nil {
+		cmd, err := m.loadInitialSessions(agent)
+		if err != nil {
 			m.status = "Error: " + err.Error()
 			return m, nil
 		}
 		m.sessionCursor = 0
-		m.messages = nil
-		m.summary = summaryGraph{}
-		m.summaryRows = nil
-		m.screen = screenSessions
-		m.status = fmt.Sprintf("Loaded %d of %d sessions for agent %s", len(m.sessions), len(m.sessionFiles), agent.name)
+		return m, cmd
 	case "r":
 		agents, err := loadAgents(m.paths.agentsDir)
 		if err != nil {
@@ -207,36 +381,32 @@ func (m model) handleAgentsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) handleSessionsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.transplantPick != nil {
+		return m.handleTransplantPickerKey(msg)
+	}
 	switch msg.String() {
 	case "up", "k":
 		m.sessionCursor = clamp(m.sessionCursor-1, 0, len(m.sessions)-1)
 	case "down", "j":
 		previousLoaded := len(m.sessions)
 		m.sessionCursor = clamp(m.sessionCursor+1, 0, len(m.sessions)-1)
-		loaded := m.maybeLoadMoreSessions()
-		if loaded > 0 && m.sessionCursor == previousLoaded-1 {
-			m.sessionCursor = clamp(m.sessionCursor+1, 0, len(m.sessions)-1)
+		cmd := m.maybeLoadMoreSessions()
+		if cmd != nil && m.sessionCursor == previousLoaded-1 {
+			m.sessionAdvancePending = true
 		}
+		return m, cmd
 	case "enter":
 		session, ok := m.currentSession()
 		if !ok {
 			m.status = "No session selected"
 			return m, nil
 		}
-		messages, err := parseSessionMessages(session.path)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.messages = messages
-		m.screen = screenConversation
-		m.refreshConversationViewport()
-		if session.conversationID > 0 {
-			m.status = fmt.Sprintf("Loaded %d messages from %s (conv_id:%d)", len(messages), session.filename, session.conversationID)
-		} else {
-			m.status = fmt.Sprintf("Loaded %d messages from %s", len(messages), session.filename)
-		}
+		ctx, seq := m.beginLoad("Loading " + session.filename + "...")
+		return m, m.startLoad(loadMessagesCmd(ctx, seq, m.sessionCursor, session, loadOpen))
+	case "t":
+		m.startSessionTransplant()
 	case "b", "backspace":
+		m.cancelLoad()
 		m.screen = screenAgents
 		m.sessionFiles = nil
 		m.sessionFileCursor = 0
@@ -249,12 +419,12 @@ func (m model) handleSessionsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.status = "No agent selected"
 			return m, nil
 		}
-		if err := m.loadInitialSessions(agent); err != nil {
+		cmd, err := m.loadInitialSessions(agent)
+		if err != nil {
 			m.status = "Error: " + err.Error()
 			return m, nil
 		}
-		m.sessionCursor = clamp(m.sessionCursor, 0, len(m.sessions)-1)
-		m.status = fmt.Sprintf("Reloaded %d of %d sessions", len(m.sessions), len(m.sessionFiles))
+		return m, cmd
 	}
 	return m, nil
 }
@@ -274,6 +444,7 @@ func (m model) handleConversationKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "G":
 		m.convViewport.GotoBottom()
 	case "b", "backspace":
+		m.cancelLoad()
 		m.screen = screenSessions
 		m.status = "Back to sessions"
 	case "r":
@@ -282,83 +453,32 @@ func (m model) handleConversationKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.status = "No session selected"
 			return m, nil
 		}
-		messages, err := parseSessionMessages(session.path)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.messages = messages
-		m.refreshConversationViewport()
-		m.status = fmt.Sprintf("Reloaded %d messages", len(messages))
+		ctx, seq := m.beginLoad("Reloading messages...")
+		return m, m.startLoad(loadMessagesCmd(ctx, seq, m.sessionCursor, session, loadReload))
 	case "l":
 		session, ok := m.currentSession()
 		if !ok {
 			m.status = "No session selected"
 			return m, nil
 		}
-		summary, err := loadSummaryGraph(m.paths.lcmDBPath, session.id)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.summary = summary
-		m.summaryRows = buildSummaryRows(summary)
-		m.summaryCursor = 0
-		m.summarySources = make(map[string][]summarySource)
-		m.summarySourceErr = make(map[string]string)
-		m.loadCurrentSummarySources()
-		m.screen = screenSummaries
-		m.status = fmt.Sprintf("Loaded %d summaries for conversation %d", len(summary.nodes), summary.conversationID)
+		ctx, seq := m.beginLoad("Loading summaries...")
+		return m, m.startLoad(loadSummaryGraphCmd(ctx, seq, m.db, session.id, loadOpen, ""))
 	case "f":
 		session, ok := m.currentSession()
 		if !ok {
 			m.status = "No session selected"
 			return m, nil
 		}
-		files, err := loadLargeFiles(m.paths.lcmDBPath, session.id)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.largeFiles = files
-		m.fileCursor = 0
-		m.screen = screenFiles
-		if len(files) == 0 {
-			m.status = fmt.Sprintf("No large files for session %s", session.id)
-		} else {
-			m.status = fmt.Sprintf("Loaded %d large files", len(files))
-		}
+		ctx, seq := m.beginLoad("Loading large files...")
+		return m, m.startLoad(loadLargeFilesCmd(ctx, seq, m.db, session.id, loadOpen))
 	case "c":
 		session, ok := m.currentSession()
 		if !ok {
 			m.status = "No session selected"
 			return m, nil
 		}
-		items, err := loadContextItems(m.paths.lcmDBPath, session.id)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.contextItems = items
-		m.contextCursor = 0
-		m.screen = screenContext
-		if len(items) == 0 {
-			m.status = "No context items for this session"
-		} else {
-			totalTokens := 0
-			summaryCount := 0
-			messageCount := 0
-			for _, it := range items {
-				totalTokens += it.tokenCount
-				if it.itemType == "summary" {
-					summaryCount++
-				} else {
-					messageCount++
-				}
-			}
-			m.status = fmt.Sprintf("Context: %d summaries + %d messages = %d items, %dk tokens",
-				summaryCount, messageCount, len(items), totalTokens/1000)
-		}
+		ctx, seq := m.beginLoad("Loading context...")
+		return m, m.startLoad(loadContextItemsCmd(ctx, seq, m.db, session.id, loadOpen, ""))
 	}
 	return m, nil
 }
@@ -367,60 +487,72 @@ func (m model) handleSummariesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.pendingDissolve != nil {
 		switch msg.String() {
 		case "y", "enter":
-			m.confirmPendingDissolve()
+			return m, m.confirmPendingDissolve()
 		case "n", "esc", "b", "backspace", "d":
 			m.pendingDissolve = nil
 			m.status = "Dissolve canceled"
 		}
 		return m, nil
 	}
+	if m.pendingUndo != nil {
+		switch msg.String() {
+		case "y", "enter":
+			return m, m.confirmPendingUndo()
+		case "n", "esc", "b", "backspace", "u":
+			m.pendingUndo = nil
+			m.status = "Undo canceled"
+		}
+		return m, nil
+	}
+	if m.summaryConsumers != nil {
+		return m.handleSummaryConsumersKey(msg)
+	}
 
 	switch msg.String() {
 	case "up", "k":
 		m.summaryCursor = clamp(m.summaryCursor-1, 0, len(m.summaryRows)-1)
 		m.summaryDetailScroll = 0
-		m.loadCurrentSummarySources()
+		return m, m.loadCurrentSummarySources()
 	case "down", "j":
 		m.summaryCursor = clamp(m.summaryCursor+1, 0, len(m.summaryRows)-1)
 		m.summaryDetailScroll = 0
-		m.loadCurrentSummarySources()
+		return m, m.loadCurrentSummarySources()
 	case "g":
 		m.summaryCursor = 0
 		m.summaryDetailScroll = 0
-		m.loadCurrentSummarySources()
+		return m, m.loadCurrentSummarySources()
 	case "G":
 		m.summaryCursor = max(0, len(m.summaryRows)-1)
 		m.summaryDetailScroll = 0
-		m.loadCurrentSummarySources()
+		return m, m.loadCurrentSummarySources()
 	case "J":
 		m.summaryDetailScroll++
 	case "K":
 		m.summaryDetailScroll = max(0, m.summaryDetailScroll-1)
 	case "enter", "right", "l", " ":
-		m.expandOrToggleSelectedSummary()
+		return m, m.expandOrToggleSelectedSummary()
 	case "left", "h":
-		m.collapseSelectedSummary()
+		return m, m.collapseSelectedSummary()
 	case "d":
 		m.startPendingDissolve()
+	case "u":
+		m.startPendingUndo()
+	case "o":
+		return m, m.jumpToNextOccurrence()
+	case "c":
+		m.openSummaryConsumers()
+	case "R":
+		return m, m.openRepairReview()
 	case "r":
 		session, ok := m.currentSession()
 		if !ok {
 			m.status = "No session selected"
 			return m, nil
 		}
-		summary, err := loadSummaryGraph(m.paths.lcmDBPath, session.id)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.summary = summary
-		m.summaryRows = buildSummaryRows(summary)
-		m.summaryCursor = clamp(m.summaryCursor, 0, len(m.summaryRows)-1)
-		m.summarySources = make(map[string][]summarySource)
-		m.summarySourceErr = make(map[string]string)
-		m.loadCurrentSummarySources()
-		m.status = fmt.Sprintf("Reloaded %d summaries", len(summary.nodes))
+		ctx, seq := m.beginLoad("Reloading summaries...")
+		return m, m.startLoad(loadSummaryGraphCmd(ctx, seq, m.db, session.id, loadReload, ""))
 	case "b", "backspace":
+		m.cancelLoad()
 		m.screen = screenConversation
 		m.status = "Back to conversation"
 	}
@@ -443,34 +575,18 @@ func (m model) handleFilesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.status = "No session selected"
 			return m, nil
 		}
-		files, err := loadLargeFiles(m.paths.lcmDBPath, session.id)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.largeFiles = files
-		m.fileCursor = clamp(m.fileCursor, 0, len(m.largeFiles)-1)
-		m.status = fmt.Sprintf("Reloaded %d large files", len(files))
+		ctx, seq := m.beginLoad("Reloading large files...")
+		return m, m.startLoad(loadLargeFilesCmd(ctx, seq, m.db, session.id, loadReload))
 	case "f":
 		session, ok := m.currentSession()
 		if !ok {
 			m.status = "No session selected"
 			return m, nil
 		}
-		files, err := loadLargeFiles(m.paths.lcmDBPath, session.id)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.largeFiles = files
-		m.fileCursor = 0
-		m.screen = screenFiles
-		if len(files) == 0 {
-			m.status = "No large files for this session"
-		} else {
-			m.status = fmt.Sprintf("Loaded %d large files", len(files))
-		}
+		ctx, seq := m.beginLoad("Loading large files...")
+		return m, m.startLoad(loadLargeFilesCmd(ctx, seq, m.db, session.id, loadOpen))
 	case "b", "backspace":
+		m.cancelLoad()
 		m.screen = screenConversation
 		m.status = "Back to conversation"
 	}
@@ -478,7 +594,76 @@ func (m model) handleFilesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m model) handleContextKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pendingCondense != nil {
+		return m.handleCondenseKey(msg)
+	}
+	if m.pendingCompact != nil {
+		return m.handleCompactKey(msg)
+	}
+	if m.pendingContextEdit != nil {
+		return m.handleContextEditKey(msg)
+	}
+	if m.transplantPick != nil {
+		return m.handleTransplantPickerKey(msg)
+	}
+	if m.contextBudgetView {
+		switch msg.String() {
+		case "up", "k":
+			m.moveContextBudgetCursor(-1)
+			return m, nil
+		case "down", "j":
+			m.moveContextBudgetCursor(1)
+			return m, nil
+		case "g":
+			m.moveContextBudgetCursor(-len(m.contextItems))
+			return m, nil
+		case "G":
+			m.moveContextBudgetCursor(len(m.contextItems))
+			return m, nil
+		case "+", "=":
+			m.contextLimit = nextContextLimit(m.contextLimit, 1)
+			m.status = "Context limit: " + formatTokenCount(m.contextLimit) + " tokens"
+			return m, nil
+		case "-":
+			m.contextLimit = nextContextLimit(m.contextLimit, -1)
+			m.status = "Context limit: " + formatTokenCount(m.contextLimit) + " tokens"
+			return m, nil
+		}
+	}
 	switch msg.String() {
+	case "v":
+		m.contextBudgetView = !m.contextBudgetView
+	case "m":
+		if m.contextMark == m.contextCursor {
+			m.contextMark = -1
+			m.status = "Mark cleared"
+		} else {
+			m.contextMark = m.contextCursor
+			m.status = "Marked; move to extend the range, c: condense or compact"
+		}
+	case "esc":
+		m.contextMark = -1
+	case "c":
+		if len(m.contextItems) == 0 {
+			m.status = "No context items"
+			return m, nil
+		}
+		lo, _ := m.contextMarkRange()
+		if m.contextItems[lo].itemType == "message" {
+			m.startPendingCompact()
+		} else {
+			m.startPendingCondense()
+		}
+	case "[":
+		m.startContextEdit(contextEditReorder, -1)
+	case "]":
+		m.startContextEdit(contextEditReorder, 1)
+	case "x":
+		m.startContextEdit(contextEditDrop, 0)
+	case "p":
+		m.startContextEdit(contextEditPin, 0)
+	case "t":
+		m.startTransplantPicker()
 	case "up", "k":
 		m.contextCursor = clamp(m.contextCursor-1, 0, len(m.contextItems)-1)
 		m.contextDetailScroll = 0
@@ -501,61 +686,56 @@ func (m model) handleContextKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.status = "No session selected"
 			return m, nil
 		}
-		items, err := loadContextItems(m.paths.lcmDBPath, session.id)
-		if err != nil {
-			m.status = "Error: " + err.Error()
-			return m, nil
-		}
-		m.contextItems = items
-		m.contextCursor = clamp(m.contextCursor, 0, len(m.contextItems)-1)
-		m.status = fmt.Sprintf("Reloaded %d context items", len(items))
+		ctx, seq := m.beginLoad("Reloading context...")
+		return m, m.startLoad(loadContextItemsCmd(ctx, seq, m.db, session.id, loadReload, ""))
 	case "b", "backspace":
+		m.cancelLoad()
 		m.screen = screenConversation
 		m.status = "Back to conversation"
 	}
 	return m, nil
 }
 
-func (m *model) expandOrToggleSelectedSummary() {
+func (m *model) expandOrToggleSelectedSummary() tea.Cmd {
 	id, ok := m.currentSummaryID()
 	if !ok {
 		m.status = "No summary selected"
-		return
+		return nil
 	}
 	node := m.summary.nodes[id]
 	if node == nil {
 		m.status = "Missing summary node"
-		return
+		return nil
 	}
 	if len(node.children) == 0 {
 		m.status = "Summary has no children"
-		return
+		return nil
 	}
 	node.expanded = !node.expanded
 	m.summaryRows = buildSummaryRows(m.summary)
 	m.summaryCursor = clamp(m.summaryCursor, 0, len(m.summaryRows)-1)
-	m.loadCurrentSummarySources()
+	return m.loadCurrentSummarySources()
 }
 
-func (m *model) collapseSelectedSummary() {
+func (m *model) collapseSelectedSummary() tea.Cmd {
 	id, ok := m.currentSummaryID()
 	if !ok {
 		m.status = "No summary selected"
-		return
+		return nil
 	}
 	node := m.summary.nodes[id]
 	if node == nil {
 		m.status = "Missing summary node"
-		return
+		return nil
 	}
 	if node.expanded {
 		node.expanded = false
 		m.summaryRows = buildSummaryRows(m.summary)
 		m.summaryCursor = clamp(m.summaryCursor, 0, len(m.summaryRows)-1)
-		m.loadCurrentSummarySources()
-		return
+		return m.loadCurrentSummarySources()
 	}
 	m.status = "Summary already collapsed"
+	return nil
 }
 
 // startPendingDissolve builds a dry-run dissolve preview for the selected node.
@@ -570,14 +750,12 @@ func (m *model) startPendingDissolve() {
 		return
 	}
 
-	db, err := openLCMDB(m.paths.lcmDBPath)
-	if err != nil {
-		m.status = "Error: " + err.Error()
+	if m.db == nil {
+		m.status = "Error: " + errLCMDBUnavailable.Error()
 		return
 	}
-	defer db.Close()
 
-	plan, err := buildDissolvePlan(context.Background(), db, m.summary.conversationID, summaryID)
+	plan, err := buildDissolvePlan(context.Background(), m.db, m.summary.conversationID, summaryID)
 	if err != nil {
 		m.status = "Error: " + err.Error()
 		return
@@ -587,84 +765,145 @@ func (m *model) startPendingDissolve() {
 	m.status = fmt.Sprintf("Ready to dissolve %s", summaryID)
 }
 
-// confirmPendingDissolve applies the pending dissolve and refreshes the DAG view.
-func (m *model) confirmPendingDissolve() {
-	if m.pendingDissolve == nil {
+// startPendingUndo finds the latest dissolve in the current conversation that
+// has not been undone yet and asks for confirmation.
+func (m *model) startPendingUndo() {
+	if m.summary.conversationID <= 0 {
+		m.status = "Missing conversation ID for current summary graph"
+		return
+	}
+	if m.db == nil {
+		m.status = "Error: " + errLCMDBUnavailable.Error()
 		return
 	}
-	plan := *m.pendingDissolve
 
-	db, err := openLCMDB(m.paths.lcmDBPath)
+	ops, err := loadJournalOps(context.Background(), m.db, "dissolve", m.summary.conversationID, journalListLimit)
 	if err != nil {
-		m.pendingDissolve = nil
 		m.status = "Error: " + err.Error()
 		return
 	}
+	for _, op := range ops {
+		if op.undoneAt.Valid {
+			continue
+		}
+		m.pendingUndo = &op
+		m.status = fmt.Sprintf("Ready to undo op %d (%s)", op.opID, op.description)
+		return
+	}
+	m.status = "No dissolve to undo in this conversation"
+}
+
+// confirmPendingUndo reverts the pending dissolve and refreshes the DAG view.
+func (m *model) confirmPendingUndo() tea.Cmd {
+	if m.pendingUndo == nil {
+		return nil
+	}
+	op := *m.pendingUndo
+	m.pendingUndo = nil
+
+	db, err := openLCMDB(m.paths.lcmDBPath, dbReadWrite)
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return nil
+	}
 	defer db.Close()
 
-	newCount, err := applyDissolvePlan(context.Background(), db, plan, true)
+	backup, err := snapshotLCMDB(context.Background(), db, m.paths.backupsDir, fmt.Sprintf("tui-undo-op%d", op.opID))
 	if err != nil {
-		m.pendingDissolve = nil
+		m.status = "Error: backup before undo: " + err.Error()
+		return nil
+	}
+
+	if _, err := undoJournalOp(context.Background(), db, op.opID); err != nil {
 		m.status = "Error: " + err.Error()
-		return
+		return nil
 	}
 
 	session, ok := m.currentSession()
 	if !ok {
-		m.pendingDissolve = nil
-		m.status = fmt.Sprintf("Dissolved %s, but no session is selected for reload", plan.target.summaryID)
-		return
+		m.status = fmt.Sprintf("Undid op %d, but no session is selected for reload", op.opID)
+		return nil
 	}
 
-	summary, err := loadSummaryGraph(m.paths.lcmDBPath, session.id)
+	note := fmt.Sprintf("Undid op %d (%s). Backup: %s", op.opID, op.description, backup.name)
+	m.status = note
+	ctx, seq := m.beginLoad("Reloading summaries...")
+	return m.startLoad(loadSummaryGraphCmd(ctx, seq, m.db, session.id, loadReload, note))
+}
+
+// confirmPendingDissolve applies the pending dissolve and refreshes the DAG view.
+func (m *model) confirmPendingDissolve() tea.Cmd {
+	if m.pendingDissolve == nil {
+		return nil
+	}
+	plan := *m.pendingDissolve
+	m.pendingDissolve = nil
+
+	db, err := openLCMDB(m.paths.lcmDBPath, dbReadWrite)
 	if err != nil {
-		m.pendingDissolve = nil
-		m.status = fmt.Sprintf("Dissolved %s, but reload failed: %v", plan.target.summaryID, err)
-		return
+		m.status = "Error: " + err.Error()
+		return nil
 	}
+	defer db.Close()
 
-	m.summary = summary
-	m.summaryRows = buildSummaryRows(summary)
-	m.summaryCursor = clamp(m.summaryCursor, 0, len(m.summaryRows)-1)
-	m.summaryDetailScroll = 0
-	m.summarySources = make(map[string][]summarySource)
-	m.summarySourceErr = make(map[string]string)
-	m.loadCurrentSummarySources()
-	m.pendingDissolve = nil
-	m.status = fmt.Sprintf("Dissolved %s: restored %d parents (%dt → %dt, %+dt). Context items: %d",
+	label := fmt.Sprintf("tui-dissolve-conv%d-%s", plan.target.conversationID, plan.target.summaryID)
+	backup, err := snapshotLCMDB(context.Background(), db, m.paths.backupsDir, label)
+	if err != nil {
+		m.status = "Error: backup before dissolve: " + err.Error()
+		return nil
+	}
+
+	newCount, opID, err := applyDissolvePlan(context.Background(), db, plan, true)
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return nil
+	}
+
+	session, ok := m.currentSession()
+	if !ok {
+		m.status = fmt.Sprintf("Dissolved %s, but no session is selected for reload", plan.target.summaryID)
+		return nil
+	}
+
+	note := fmt.Sprintf("Dissolved %s: restored %d parents (%dt → %dt, %+dt). Context items: %d. Backup: %s. Press u to undo (op %d)",
 		plan.target.summaryID,
 		len(plan.parents),
 		plan.target.tokenCount,
 		plan.totalParentTokens,
 		plan.totalParentTokens-plan.target.tokenCount,
-		newCount)
+		newCount,
+		backup.name,
+		opID)
+	m.status = note
+	ctx, seq := m.beginLoad("Reloading summaries...")
+	return m.startLoad(loadSummaryGraphCmd(ctx, seq, m.db, session.id, loadReload, note))
 }
 
-func (m *model) loadCurrentSummarySources() {
+// loadCurrentSummarySources starts an async source load for the selected
+// summary unless it is already cached or in flight.
+func (m *model) loadCurrentSummarySources() tea.Cmd {
 	id, ok := m.currentSummaryID()
 	if !ok {
-		return
+		return nil
 	}
 	if _, exists := m.summarySources[id]; exists {
-		return
+		return nil
 	}
 	if _, exists := m.summarySourceErr[id]; exists {
-		return
+		return nil
 	}
-
-	sources, err := loadSummarySources(m.paths.lcmDBPath, id)
-	if err != nil {
-		m.summarySourceErr[id] = err.Error()
-		return
+	if m.pendingSources[id] {
+		return nil
 	}
-	m.summarySources[id] = sources
+	m.pendingSources[id] = true
+	return tea.Batch(loadSummarySourcesCmd(m.db, id), m.spinner.Tick)
 }
 
 func buildSummaryRows(graph summaryGraph) []summaryRow {
 	rows := make([]summaryRow, 0, len(graph.nodes))
-	var walk func(summaryID string, depth int, path map[string]bool)
+	var walk func(summaryID, parentID string, depth int, path map[string]bool)
 
-	walk = func(summaryID string, depth int, path map[string]bool) {
+	walk = func(summaryID, parentID string, depth int, path map[string]bool) {
 		if path[summaryID] {
 			return
 		}
@@ -672,20 +911,20 @@ func buildSummaryRows(graph summaryGraph) []summaryRow {
 		if node == nil {
 			return
 		}
-		rows = append(rows, summaryRow{summaryID: summaryID, depth: depth})
+		rows = append(rows, summaryRow{summaryID: summaryID, depth: depth, parentID: parentID})
 		if !node.expanded {
 			return
 		}
 
 		path[summaryID] = true
 		for _, childID := range node.children {
-			walk(childID, depth+1, path)
+			walk(childID, summaryID, depth+1, path)
 		}
 		delete(path, summaryID)
 	}
 
 	for _, rootID := range graph.roots {
-		walk(rootID, 0, map[string]bool{})
+		walk(rootID, "", 0, map[string]bool{})
 	}
 	return rows
 }
@@ -697,7 +936,7 @@ func (m model) View() string {
 
 	header := m.renderHeader()
 	body := m.renderBody()
-	footer := helpStyle.Render(m.renderStatus())
+	footer := helpStyle.Render(m.loadingStatus(m.renderStatus()))
 	return header + "\n" + body + "\n" + footer
 }
 
@@ -732,6 +971,10 @@ func (m model) renderHeader() string {
 		if conversationID, ok := m.currentConversationID(); ok {
 			title += fmt.Sprintf(" | conv_id:%d", conversationID)
 		}
+	case screenSearch:
+		title += " | Search"
+	case screenRepair:
+		title += fmt.Sprintf(" | Repair Review | conv_id:%d", m.repairConversationID)
 	}
 
 	help := m.renderHelp()
@@ -741,20 +984,64 @@ func (m model) renderHeader() string {
 func (m model) renderHelp() string {
 	switch m.screen {
 	case screenAgents:
-		return "up/down: move | enter: open agent sessions | r: reload | q: quit"
+		return "up/down: move | enter: open agent sessions | r: reload | /: search | q: quit"
 	case screenSessions:
-		return "up/down: move | enter: open conversation | b: back | r: reload | q: quit"
+		if m.transplantPick != nil {
+			if m.transplantPick.stage == transplantPickTarget {
+				return "Transplant target | up/down: move | enter: preview | b/esc: cancel | q: quit"
+			}
+			return "Transplant preview | y/enter: apply | n/esc: back | q: quit"
+		}
+		return "up/down: move | enter: open conversation | t: transplant context to another session | b: back | r: reload | /: search | q: quit"
 	case screenConversation:
-		return "j/k/up/down: scroll | pgup/pgdown | g/G: top/bottom | r: reload | l: LCM summaries | c: context | f: LCM files | b: back | q: quit"
+		return "j/k/up/down: scroll | pgup/pgdown | g/G: top/bottom | r: reload | l: LCM summaries | c: context | f: LCM files | /: search | b: back | q: quit"
 	case screenSummaries:
 		if m.pendingDissolve != nil {
 			return "Dissolve confirmation | y/enter: confirm | n/esc: cancel | q: quit"
 		}
-		return "up/down: move | enter/right/l: expand-toggle | left/h: collapse | d: dissolve selected condensed node | Shift+J/K: scroll detail | g/G: top/bottom | f: LCM files | r: reload | b: back | q: quit"
+		if m.pendingUndo != nil {
+			return "Undo confirmation | y/enter: confirm | n/esc: cancel | q: quit"
+		}
+		return "up/down: move | enter/right/l: expand-toggle | left/h: collapse | d: dissolve selected condensed node | u: undo last dissolve | o: next occurrence of a shared summary | c: condensed summaries built from it | R: review repairs | Shift+J/K: scroll detail | g/G: top/bottom | f: LCM files | r: reload | /: search | b: back | q: quit"
 	case screenFiles:
-		return "up/down: move | g/G: top/bottom | r: reload | b: back | q: quit"
+		return "up/down: move | g/G: top/bottom | r: reload | /: search | b: back | q: quit"
 	case screenContext:
-		return "up/down: move | g/G: top/bottom | r: reload | b: back | q: quit"
+		if m.pendingCondense != nil {
+			if m.pendingCondense.generated {
+				return "Condense | a: write | g: regenerate | n/esc: cancel | q: quit"
+			}
+			return "Condense preview | g/enter: generate | n/esc: cancel | q: quit"
+		}
+		if m.pendingCompact != nil {
+			if m.pendingCompact.generated {
+				return "Compact | a: write | g: regenerate | n/esc: cancel | q: quit"
+			}
+			return "Compact preview | g/enter: generate | n/esc: cancel | q: quit"
+		}
+		if m.pendingContextEdit != nil {
+			return "Edit preview | y/enter: apply | n/esc: cancel | q: quit"
+		}
+		if m.transplantPick != nil {
+			switch m.transplantPick.stage {
+			case transplantPickSummaries:
+				return "Transplant | up/down: move | space: toggle | a: all/none | enter: choose target | esc: cancel | q: quit"
+			case transplantPickTarget:
+				return "Transplant target | up/down: move | enter: preview | b/esc: back | q: quit"
+			default:
+				return "Transplant preview | y/enter: apply | n/esc: back | q: quit"
+			}
+		}
+		if m.contextBudgetView {
+			return "up/down: move by size | g/G: largest/smallest | +/-: context limit | x: drop | p: pin | v: item list | r: reload | /: search | b: back | q: quit"
+		}
+		return "up/down: move | g/G: top/bottom | m: mark range | c: condense summaries/compact messages | [/]: move item | x: drop | p: pin | t: transplant | v: budget view | r: reload | /: search | b: back | q: quit"
+	case screenSearch:
+		if m.searchInput.Focused() {
+			return "type query | enter: search | esc: cancel"
+		}
+		return "up/down: move | enter: jump to match | /: new search | g/G: top/bottom | esc/b: back | q: quit"
+	case screenRepair:
+		return "up/down: move | g/enter: generate/regenerate | a: accept (writes) | x: reject | b: back | q: quit"
 	default:
 		return "q: quit"
 	}
@@ -774,6 +1061,10 @@ func (m model) renderBody() string {
 		return m.renderFiles()
 	case screenContext:
 		return m.renderContext()
+	case screenSearch:
+		return m.renderSearch()
+	case screenRepair:
+		return m.renderRepair()
 	default:
 		return "Unknown screen"
 	}
@@ -810,6 +1101,9 @@ func (m model) renderAgents() string {
 }
 
 func (m model) renderSessions() string {
+	if m.transplantPick != nil {
+		return m.renderTransplantPicker()
+	}
 	if len(m.sessions) == 0 {
 		return "No session JSONL files found for this agent"
 	}
@@ -856,6 +1150,12 @@ func (m model) renderSummaries() string {
 	if m.pendingDissolve != nil {
 		return m.renderDissolveConfirmation()
 	}
+	if m.pendingUndo != nil {
+		return m.renderUndoConfirmation()
+	}
+	if m.summaryConsumers != nil {
+		return m.renderSummaryConsumers()
+	}
 	if len(m.summaryRows) == 0 {
 		return "Summary graph is empty"
 	}
@@ -886,6 +1186,9 @@ func (m model) renderSummaries() string {
 		if node.kind == "condensed" {
 			kindLabel = fmt.Sprintf("d%d", node.depth)
 		}
+		if shared := m.summary.sharedSummaryLabel(node.id); shared != "" {
+			preview = shared + " " + preview
+		}
 		line := fmt.Sprintf("%s%s %s [%s, %dt] %s", strings.Repeat("  ", row.depth), marker, node.id, kindLabel, node.tokenCount, preview)
 		if idx == m.summaryCursor {
 			line = selectedStyle.Render(line)
@@ -931,6 +1234,25 @@ func (m model) renderDissolveConfirmation() string {
 	return strings.Join(lines, "\n")
 }
 
+// renderUndoConfirmation draws the confirmation overlay for undoing a dissolve.
+func (m model) renderUndoConfirmation() string {
+	if m.pendingUndo == nil {
+		return "No undo confirmation pending"
+	}
+
+	op := m.pendingUndo
+	lines := []string{
+		fmt.Sprintf("Undo op %d: %s", op.opID, op.description),
+		fmt.Sprintf("Recorded: %s in conversation %d (%d journal entries)", op.createdAt, op.conversationID, op.entryCount),
+		"",
+		"The context window and any purged summary are restored to their state before",
+		"the dissolve. Context items added since are kept after the restored window.",
+		"",
+		"Press y or Enter to apply undo. Press n or Esc to cancel.",
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (m *model) renderSummaryDetail(detailHeight int) []string {
 	id, ok := m.currentSummaryID()
 	if !ok {
@@ -945,6 +1267,9 @@ func (m *model) renderSummaryDetail(detailHeight int) []string {
 	var allLines []string
 	allLines = append(allLines, fmt.Sprintf("Summary: %s", id))
 	allLines = append(allLines, fmt.Sprintf("Created: %s  Tokens: %d", formatTimestamp(node.createdAt), node.tokenCount))
+	if others := m.summary.otherDerivedBy(m.summaryRows[m.summaryCursor]); len(others) > 0 {
+		allLines = append(allLines, fmt.Sprintf("Also under: %s  (o: next occurrence, c: list)", strings.Join(others, ", ")))
+	}
 	allLines = append(allLines, "Content:")
 	wrappedContent := wrapText(node.content, max(20, m.width-4))
 	for _, line := range strings.Split(wrappedContent, "\n") {
@@ -1059,6 +1384,21 @@ func (m model) renderFileDetail(detailHeight int) []string {
 }
 
 func (m model) renderContext() string {
+	if m.pendingCondense != nil {
+		return m.renderCondenseConfirmation()
+	}
+	if m.pendingCompact != nil {
+		return m.renderCompactConfirmation()
+	}
+	if m.pendingContextEdit != nil {
+		return m.renderContextEditConfirmation()
+	}
+	if m.transplantPick != nil {
+		return m.renderTransplantPicker()
+	}
+	if m.contextBudgetView {
+		return m.renderContextBudget()
+	}
 	if len(m.contextItems) == 0 {
 		return "No context items found for this session"
 	}
@@ -1067,11 +1407,15 @@ func (m model) renderContext() string {
 	detailHeight := max(7, available/3)
 	listHeight := max(3, available-detailHeight-1)
 
+	markLo, markHi := m.contextMarkRange()
 	listOffsetValue := listOffset(m.contextCursor, len(m.contextItems), listHeight)
 	listLines := make([]string, 0, listHeight)
 	for idx := listOffsetValue; idx < min(len(m.contextItems), listOffsetValue+listHeight); idx++ {
 		item := m.contextItems[idx]
 		line := m.formatContextItemLine(item)
+		if m.contextMark >= 0 && idx >= markLo && idx <= markHi {
+			line = "*" + line[1:]
+		}
 		if idx == m.contextCursor {
 			line = selectedStyle.Render(line)
 		}
@@ -1085,14 +1429,18 @@ func (m model) renderContext() string {
 func (m model) formatContextItemLine(item contextItemEntry) string {
 	maxPreview := max(8, m.width-60)
 	preview := truncateString(item.preview, maxPreview)
+	pin := " "
+	if item.pinned {
+		pin = "^"
+	}
 
 	if item.itemType == "summary" {
 		kindLabel := item.kind
 		if item.kind == "condensed" {
 			kindLabel = fmt.Sprintf("d%d", item.depth)
 		}
-		return fmt.Sprintf("  %3d  %-10s [%s, %dt] %s",
-			item.ordinal, kindLabel, item.summaryID[:min(16, len(item.summaryID))], item.tokenCount, preview)
+		return fmt.Sprintf(" %s%3d  %-10s [%s, %dt] %s",
+			pin, item.ordinal, kindLabel, item.summaryID[:min(16, len(item.summaryID))], item.tokenCount, preview)
 	}
 	// message
 	roleStyle := roleUserStyle
@@ -1104,8 +1452,8 @@ func (m model) formatContextItemLine(item contextItemEntry) string {
 	case "tool":
 		roleStyle = roleToolStyle
 	}
-	return fmt.Sprintf("  %3d  %-10s [msg %d, %dt] %s",
-		item.ordinal, roleStyle.Render(item.kind), item.messageID, item.tokenCount, preview)
+	return fmt.Sprintf(" %s%3d  %-10s [msg %d, %dt] %s",
+		pin, item.ordinal, roleStyle.Render(item.kind), item.messageID, item.tokenCount, preview)
 }
 
 func (m *model) renderContextDetail(detailHeight int) []string {
@@ -1340,46 +1688,30 @@ func max(a, b int) int {
 	return b
 }
 
-func (m *model) loadInitialSessions(agent agentEntry) error {
+// loadInitialSessions discovers an agent's session files and starts loading
+// the first batch. The current list stays visible until the batch arrives.
+func (m *model) loadInitialSessions(agent agentEntry) (tea.Cmd, error) {
 	files, err := discoverSessionFiles(agent)
 	if err != nil {
-		return err
-	}
-	m.sessionFiles = files
-	m.sessionFileCursor = 0
-	m.sessions = nil
-	loaded, err := m.appendSessionBatch(sessionInitialLoadSize)
-	if err != nil {
-		return err
+		return nil, err
 	}
-	m.sessionCursor = clamp(m.sessionCursor, 0, max(0, loaded-1))
-	return nil
+	ctx, seq := m.beginLoad("Loading sessions for " + agent.name + "...")
+	return m.startLoad(loadSessionBatchCmd(ctx, seq, agent.name, files, 0, sessionInitialLoadSize, m.db, true)), nil
 }
 
-func (m *model) appendSessionBatch(limit int) (int, error) {
-	batch, nextCursor, err := loadSessionBatch(m.sessionFiles, m.sessionFileCursor, limit, m.paths.lcmDBPath)
-	if err != nil {
-		return 0, err
-	}
-	m.sessionFileCursor = nextCursor
-	m.sessions = append(m.sessions, batch...)
-	return len(batch), nil
-}
-
-func (m *model) maybeLoadMoreSessions() int {
+// maybeLoadMoreSessions starts loading the next batch when the cursor nears
+// the end of the loaded list. Returns nil when no batch is needed.
+func (m *model) maybeLoadMoreSessions() tea.Cmd {
 	if len(m.sessions)-m.sessionCursor > 3 {
-		return 0
+		return nil
 	}
 	if m.sessionFileCursor >= len(m.sessionFiles) {
-		return 0
-	}
-	loaded, err := m.appendSessionBatch(sessionBatchLoadSize)
-	if err != nil {
-		m.status = "Error: " + err.Error()
-		return 0
+		return nil
 	}
-	if loaded > 0 {
-		m.status = fmt.Sprintf("Loaded %d of %d sessions", len(m.sessions), len(m.sessionFiles))
+	if m.loading != "" {
+		return nil
 	}
-	return loaded
+	agent, _ := m.currentAgent()
+	ctx, seq := m.beginLoad("Loading more sessions...")
+	return m.startLoad(loadSessionBatchCmd(ctx, seq, agent.name, m.sessionFiles, m.sessionFileCursor, sessionBatchLoadSize, m.db, false))
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	leafPromptTemplate      = "leaf.tmpl"
+	condensedPromptTemplate = "condensed.tmpl"
+)
+
+//go:embed prompts/*.tmpl
+var defaultPromptFS embed.FS
+
+// promptData is what repair prompt templates can reference.
+type promptData struct {
+	Text            string // source messages or child summaries
+	PreviousContext string // preceding summary, or "(none)"
+	TargetTokens    int
+	Instructions    string // operator instructions, empty when unset
+}
+
+// repairPrompts renders leaf and condensed repair prompts. Each template
+// comes from the prompt directory when it holds a file of that name and
+// falls back to the built-in default otherwise.
+type repairPrompts struct {
+	leaf         *template.Template
+	condensed    *template.Template
+	dir          string
+	instructions string
+	overridden   []string
+}
+
+// loadRepairPrompts parses the prompt templates. dir may be empty; an
+// explicit dir (from --prompt-dir) must exist, while the default one is
+// optional.
+func loadRepairPrompts(dir string, explicit bool, instructions string) (*repairPrompts, error) {
+	prompts := &repairPrompts{dir: dir, instructions: strings.TrimSpace(instructions)}
+	if dir != "" {
+		info, err := os.Stat(dir)
+		switch {
+		case err == nil && !info.IsDir():
+			return nil, fmt.Errorf("prompt dir %q is not a directory", dir)
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+			prompts.dir = ""
+		case err != nil:
+			return nil, fmt.Errorf("read prompt dir %q: %w", dir, err)
+		}
+	}
+
+	var err error
+	if prompts.leaf, err = prompts.parse(leafPromptTemplate); err != nil {
+		return nil, err
+	}
+	if prompts.condensed, err = prompts.parse(condensedPromptTemplate); err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// resolvePromptDir picks --prompt-dir when given and the default prompts
+// directory otherwise, reporting whether the directory was asked for.
+func resolvePromptDir(flagValue string, paths appDataPaths) (string, bool) {
+	if flagValue != "" {
+		return flagValue, true
+	}
+	return paths.promptsDir, false
+}
+
+// repairPromptsFromEnv loads the TUI's prompts: LCM_TUI_PROMPT_DIR and
+// LCM_TUI_INSTRUCTIONS mirror the repair command's --prompt-dir and
+// --instructions.
+func repairPromptsFromEnv(paths appDataPaths) (*repairPrompts, error) {
+	dir, err := absPromptDir(os.Getenv("LCM_TUI_PROMPT_DIR"))
+	if err != nil {
+		return nil, err
+	}
+	promptDir, explicit := resolvePromptDir(dir, paths)
+	return loadRepairPrompts(promptDir, explicit, os.Getenv("LCM_TUI_INSTRUCTIONS"))
+}
+
+// absPromptDir makes a --prompt-dir value absolute so a resumed run finds
+// the same templates from any working directory.
+func absPromptDir(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(value)
+	if err != nil {
+		return "", fmt.Errorf("resolve --prompt-dir %q: %w", value, err)
+	}
+	return abs, nil
+}
+
+func (p *repairPrompts) parse(name string) (*template.Template, error) {
+	raw, err := defaultPromptFS.ReadFile("prompts/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("read built-in prompt %s: %w", name, err)
+	}
+	source := "built-in " + name
+	if p.dir != "" {
+		path := filepath.Join(p.dir, name)
+		custom, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			raw, source = custom, path
+			p.overridden = append(p.overridden, name)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("read prompt template %q: %w", path, err)
+		}
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt template %s: %w", source, err)
+	}
+	// Render once with placeholder data so a bad field reference fails
+	// before any summary is touched.
+	if err := tmpl.Execute(&bytes.Buffer{}, promptData{Text: "x", PreviousContext: "(none)", TargetTokens: 1}); err != nil {
+		return nil, fmt.Errorf("render prompt template %s: %w", source, err)
+	}
+	return tmpl, nil
+}
+
+// describe summarizes where the templates came from for CLI output.
+func (p *repairPrompts) describe() string {
+	label := "built-in"
+	if len(p.overridden) > 0 {
+		label = fmt.Sprintf("%s from %s", strings.Join(p.overridden, ", "), p.dir)
+	}
+	if p.instructions != "" {
+		label += fmt.Sprintf(", instructions %q", previewForLog(p.instructions, 60))
+	}
+	return label
+}
+
+// build renders the prompt for one summary and returns it with its source
+// text and the target token count the summarizer should aim for.
+func (p *repairPrompts) build(kind, text, previousContext string, inputTokens int) (summaryRequest, error) {
+	req := summaryRequest{kind: "condensed", text: text, targetTokens: condensedTargetTokens}
+	tmpl := p.condensed
+	if strings.EqualFold(kind, "leaf") {
+		tmpl = p.leaf
+		req.kind, req.targetTokens = "leaf", calculateLeafTargetTokens(inputTokens)
+	}
+
+	prev := strings.TrimSpace(previousContext)
+	if prev == "" {
+		prev = "(none)"
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, promptData{
+		Text:            text,
+		PreviousContext: prev,
+		TargetTokens:    req.targetTokens,
+		Instructions:    p.instructions,
+	}); err != nil {
+		return summaryRequest{}, fmt.Errorf("render %s prompt: %w", tmpl.Name(), err)
+	}
+	req.prompt = b.String()
+	return req, nil
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// recountShownChanges caps the per-conversation change list unless --verbose.
+const recountShownChanges = 10
+
+type recountOptions struct {
+	apply     bool
+	all       bool
+	summaries bool
+	messages  bool
+	verbose   bool
+	tokenizer string
+}
+
+// recountTable describes one table whose token_count recount rewrites.
+type recountTable struct {
+	name   string
+	key    string
+	label  string
+	enable func(recountOptions) bool
+}
+
+var recountTables = []recountTable{
+	{name: "summaries", key: "summary_id", label: "summaries", enable: func(o recountOptions) bool { return o.summaries }},
+	{name: "messages", key: "message_id", label: "messages", enable: func(o recountOptions) bool { return o.messages }},
+}
+
+// recountChange is one row whose stored token_count differs from the count.
+type recountChange struct {
+	table   string
+	key     string // summary_id or message_id
+	stored  int
+	counted int
+}
+
+// recountStats totals one table of one conversation.
+type recountStats struct {
+	rows       int
+	storedSum  int
+	countedSum int
+	changes    []recountChange
+}
+
+// runRecountCommand recomputes token_count for summaries and messages.
+func runRecountCommand(args []string) error {
+	opts, conversationID, err := parseRecountArgs(args)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+	counter, err := newTokenCounter(opts.tokenizer, paths)
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	conversationIDs := []int64{conversationID}
+	if opts.all {
+		conversationIDs, err = loadDoctorConversationIDs(ctx, db)
+		if err != nil {
+			return err
+		}
+	} else {
+		exists, err := conversationExists(ctx, db, conversationID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("conversation %d not found", conversationID)
+		}
+	}
+
+	fmt.Printf("Tokenizer: %s\n", counter.describe())
+	var changes []recountChange
+	for _, id := range conversationIDs {
+		fmt.Printf("\nConversation %d:\n", id)
+		for _, table := range recountTables {
+			if !table.enable(opts) {
+				continue
+			}
+			stats, err := recountConversationTable(ctx, db, table, id, counter)
+			if err != nil {
+				return err
+			}
+			printRecountStats(table, stats, opts.verbose)
+			changes = append(changes, stats.changes...)
+		}
+	}
+
+	fmt.Println()
+	if len(changes) == 0 {
+		fmt.Println("All token counts match.")
+		return nil
+	}
+	if !opts.apply {
+		fmt.Printf("%d token counts differ. Run with --apply to update them.\n", len(changes))
+		return nil
+	}
+
+	label := fmt.Sprintf("recount-conv%d", conversationID)
+	if opts.all {
+		label = "recount-all"
+	}
+	if err := backupBeforeApply(ctx, db, paths, label); err != nil {
+		return err
+	}
+	opID, err := applyRecount(ctx, db, conversationID, firstNonEmpty(opts.tokenizer, defaultTokenizer), changes)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Updated %d token counts.\n", len(changes))
+	fmt.Printf("Undo with: lcm-tui undo %d --apply\n", opID)
+	return nil
+}
+
+func parseRecountArgs(args []string) (recountOptions, int64, error) {
+	fs := flag.NewFlagSet("recount", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	apply := fs.Bool("apply", false, "write the recomputed counts")
+	_ = fs.Bool("dry-run", true, "show what would change")
+	all := fs.Bool("all", false, "recount all conversations")
+	summariesOnly := fs.Bool("summaries", false, "only recount summaries")
+	messagesOnly := fs.Bool("messages", false, "only recount messages")
+	verbose := fs.Bool("verbose", false, "list every changed row")
+	tokenizer := fs.String("tokenizer", defaultTokenizer, "heuristic, an encoding name, or a model name")
+
+	normalizedArgs, err := normalizeRecountArgs(args)
+	if err != nil {
+		return recountOptions{}, 0, fmt.Errorf("%w\n%s", err, recountUsageText())
+	}
+	if err := fs.Parse(normalizedArgs); err != nil {
+		return recountOptions{}, 0, fmt.Errorf("%w\n%s", err, recountUsageText())
+	}
+	if *summariesOnly && *messagesOnly {
+		return recountOptions{}, 0, fmt.Errorf("--summaries and --messages cannot be combined\n%s", recountUsageText())
+	}
+
+	opts := recountOptions{
+		apply:     *apply,
+		all:       *all,
+		summaries: !*messagesOnly,
+		messages:  !*summariesOnly,
+		verbose:   *verbose,
+		tokenizer: strings.TrimSpace(*tokenizer),
+	}
+	if opts.all {
+		if fs.NArg() != 0 {
+			return recountOptions{}, 0, fmt.Errorf("conversation ID is not allowed with --all\n%s", recountUsageText())
+		}
+		return opts, 0, nil
+	}
+	if fs.NArg() != 1 {
+		return recountOptions{}, 0, fmt.Errorf("conversation ID is required unless --all is used\n%s", recountUsageText())
+	}
+	conversationID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return recountOptions{}, 0, fmt.Errorf("parse conversation ID %q: %w", fs.Arg(0), err)
+	}
+	return opts, conversationID, nil
+}
+
+func normalizeRecountArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--tokenizer":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"):
+			flags = append(flags, arg)
+		default:
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func recountUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui recount <conversation_id> [--tokenizer <name>] [--summaries|--messages] [--apply]
+  lcm-tui recount --all [--tokenizer <name>] [--apply]
+
+Recomputes token_count for summaries and messages and reports rows whose
+stored count differs. Without --apply nothing is written.
+
+Tokenizers (--tokenizer, default heuristic):
+  heuristic      4 characters per token
+  cl100k_base    OpenAI's published encoding, built in
+  o200k_base     other OpenAI encodings, read from
+                 ~/.openclaw/lcm-tui/tokenizers/<name>.tiktoken
+  <model>        an OpenAI model name such as gpt-4o or gpt-4, mapped to its
+                 encoding; an encoding that is not installed is an error
+
+Claude's tokenizer is not published, so there is no exact count for Claude
+models; use heuristic or cl100k_base as an approximation.
+`)
+}
+
+func recountConversationTable(ctx context.Context, q sqlQueryer, table recountTable, conversationID int64, counter tokenCounter) (recountStats, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT CAST(`+table.key+` AS TEXT), token_count, content
+		FROM `+table.name+`
+		WHERE conversation_id = ?
+		ORDER BY `+table.key+` ASC
+	`, conversationID)
+	if err != nil {
+		return recountStats{}, fmt.Errorf("query %s for conversation %d: %w", table.name, conversationID, err)
+	}
+	defer rows.Close()
+
+	var stats recountStats
+	for rows.Next() {
+		var (
+			key     string
+			stored  sql.NullInt64
+			content sql.NullString
+		)
+		if err := rows.Scan(&key, &stored, &content); err != nil {
+			return recountStats{}, fmt.Errorf("scan %s row: %w", table.name, err)
+		}
+		counted := counter.countTokens(content.String)
+		stats.rows++
+		stats.storedSum += int(stored.Int64)
+		stats.countedSum += counted
+		if !stored.Valid || int(stored.Int64) != counted {
+			stats.changes = append(stats.changes, recountChange{table: table.name, key: key, stored: int(stored.Int64), counted: counted})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return recountStats{}, fmt.Errorf("iterate %s rows: %w", table.name, err)
+	}
+	return stats, nil
+}
+
+func printRecountStats(table recountTable, stats recountStats, verbose bool) {
+	fmt.Printf("  %-9s %d rows, %d differ; total %dt -> %dt\n", table.label, stats.rows, len(stats.changes), stats.storedSum, stats.countedSum)
+
+	shown := append([]recountChange(nil), stats.changes...)
+	sort.SliceStable(shown, func(i, j int) bool {
+		return absInt(shown[i].counted-shown[i].stored) > absInt(shown[j].counted-shown[j].stored)
+	})
+	if !verbose && len(shown) > recountShownChanges {
+		shown = shown[:recountShownChanges]
+	}
+	for _, change := range shown {
+		fmt.Printf("    %-20s %6dt -> %6dt (%+d)\n", change.key, change.stored, change.counted, change.counted-change.stored)
+	}
+	if hidden := len(stats.changes) - len(shown); hidden > 0 {
+		fmt.Printf("    ... %d more (--verbose lists all)\n", hidden)
+	}
+}
+
+// applyRecount writes every change in one transaction, journaled as a
+// single undoable op.
+func applyRecount(ctx context.Context, db *sql.DB, conversationID int64, tokenizer string, changes []recountChange) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin recount transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	j, err := startJournalOp(ctx, tx, "recount", conversationID, fmt.Sprintf("recount %d token counts with %s", len(changes), tokenizer))
+	if err != nil {
+		return 0, err
+	}
+	keys := map[string]string{}
+	for _, table := range recountTables {
+		keys[table.name] = table.key
+	}
+	for _, change := range changes {
+		key := keys[change.table]
+		before, err := captureRows(ctx, tx, change.table, key+" = ?", change.key)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE `+change.table+` SET token_count = ? WHERE `+key+` = ?
+		`, change.counted, change.key); err != nil {
+			return 0, fmt.Errorf("update %s %s token_count: %w", change.table, change.key, err)
+		}
+		if err := j.recordUpdate(ctx, change.table, before); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit recount: %w", err)
+	}
+	rollback = false
+	return j.opID, nil
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -25,16 +25,30 @@ const (
 	corruptedSummaryMarker = "[LCM fallback summary; truncated for context management]"
 	anthropicModel         = "claude-sonnet-4-20250514"
 	anthropicVersion       = "2023-06-01"
+	anthropicBaseURL       = "https://api.anthropic.com"
 	condensedTargetTokens  = 2000
 	defaultHTTPTimeout     = 180 * time.Second
 )
 
 type repairOptions struct {
-	apply     bool
-	dryRun    bool
-	all       bool
-	summaryID string
-	verbose   bool
+	apply         bool
+	dryRun        bool
+	all           bool
+	resume        bool
+	summaryID     string
+	verbose       bool
+	summarizer    summarizerConfig
+	summarizerSet bool // --provider/--model/--base-url given explicitly
+	concurrency   int
+	maxRetries    int
+	detectors     []summaryDetector
+	promptDir     string // --prompt-dir; empty means the default prompts dir
+	instructions  string
+	promptsSet    bool // --prompt-dir/--instructions given explicitly
+	prompts       *repairPrompts
+	tokenizer     string
+	tokenizerSet  bool
+	tokens        tokenCounter
 }
 
 type repairSummary struct {
@@ -48,6 +62,7 @@ type repairSummary struct {
 	childCount        int
 	contextOrdinal    int64
 	hasContextOrdinal bool
+	findings          []string // "detector: reason" for each detector that flagged it
 }
 
 type leafSequenceEntry struct {
@@ -77,8 +92,10 @@ type sqlQueryer interface {
 }
 
 type anthropicClient struct {
-	apiKey string
-	http   *http.Client
+	apiKey  string
+	model   string
+	baseURL string
+	http    *http.Client
 }
 
 type anthropicRequest struct {
@@ -122,50 +139,112 @@ func runRepairCommand(args []string) error {
 		return err
 	}
 
-	db, err := openLCMDB(paths.lcmDBPath)
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
 	ctx := context.Background()
-	conversationIDs, err := resolveRepairConversationIDs(ctx, db, opts, conversationID)
-	if err != nil {
-		return err
+	var (
+		run             *repairRun
+		conversationIDs []int64
+	)
+	if opts.resume {
+		run, err = loadResumableRepairRun(ctx, db)
+		if err != nil {
+			return err
+		}
+		conversationIDs = run.conversationIDs
+		opts.summaryID = run.summaryID
+		opts.detectors = run.detectors
+		if !opts.summarizerSet {
+			opts.summarizer = run.summarizer
+		}
+		if !opts.promptsSet {
+			opts.promptDir, opts.instructions = run.promptDir, run.instructions
+		}
+		if !opts.tokenizerSet {
+			opts.tokenizer = run.tokenizer
+		}
+		fmt.Printf("Resuming repair run %d (started %s, %d summaries already repaired)\n\n", run.runID, run.startedAt, len(run.done))
+	} else {
+		conversationIDs, err = resolveRepairConversationIDs(ctx, db, opts, conversationID)
+		if err != nil {
+			return err
+		}
 	}
 	if len(conversationIDs) == 0 {
 		fmt.Println("No corrupted summaries found.")
 		return nil
 	}
 
-	var client *anthropicClient
+	var client summarizer
 	if opts.apply {
-		apiKey, err := resolveAnthropicAPIKey(paths)
+		client, err = newSummarizer(opts.summarizer, paths)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Summarizer: %s (%d workers, %d retries)\n", client.describe(), opts.concurrency, opts.maxRetries)
+		promptDir, explicit := resolvePromptDir(opts.promptDir, paths)
+		opts.prompts, err = loadRepairPrompts(promptDir, explicit, opts.instructions)
 		if err != nil {
 			return err
 		}
-		client = &anthropicClient{
-			apiKey: apiKey,
-			http:   &http.Client{Timeout: defaultHTTPTimeout},
+		fmt.Printf("Prompts: %s\n", opts.prompts.describe())
+		opts.tokens, err = newTokenCounter(opts.tokenizer, paths)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Tokenizer: %s\n", opts.tokens.describe())
+
+		label := fmt.Sprintf("repair-conv%d", conversationID)
+		switch {
+		case run != nil:
+			label = fmt.Sprintf("repair-resume-run%d", run.runID)
+		case opts.all:
+			label = "repair-all"
+		}
+		if err := backupBeforeApply(ctx, db, paths, label); err != nil {
+			return err
+		}
+		fmt.Println()
+
+		if run == nil {
+			run, err = startRepairRun(ctx, db, conversationIDs, opts)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	totalRepaired := 0
+	var total repairOutcome
 	for i, id := range conversationIDs {
 		if i > 0 {
 			fmt.Println()
 		}
-		repaired, err := runRepairConversation(ctx, db, id, opts, client)
+		outcome, err := runRepairConversation(ctx, db, id, opts, client, run)
 		if err != nil {
 			return err
 		}
-		totalRepaired += repaired
+		total.repaired += outcome.repaired
+		total.failed += outcome.failed
+		total.skipped += outcome.skipped
+	}
+	if !opts.apply {
+		return nil
 	}
 
-	if opts.apply && opts.all {
-		fmt.Printf("\nDone. %d summaries repaired across %d conversations.\n", totalRepaired, len(conversationIDs))
+	if len(conversationIDs) > 1 {
+		fmt.Printf("\nDone. %d summaries repaired across %d conversations.\n", total.repaired, len(conversationIDs))
 	}
-	return nil
+	if total.repaired > 0 {
+		fmt.Printf("Undo with: lcm-tui undo %d --apply\n", run.opID)
+	}
+	if total.failed > 0 {
+		return fmt.Errorf("%d summaries failed and %d were skipped; rerun with `lcm-tui repair --resume --apply`", total.failed, total.skipped)
+	}
+	return run.finish(ctx, db)
 }
 
 func parseRepairArgs(args []string) (repairOptions, int64, error) {
@@ -177,6 +256,16 @@ func parseRepairArgs(args []string) (repairOptions, int64, error) {
 	all := fs.Bool("all", false, "scan all conversations")
 	summaryID := fs.String("summary-id", "", "repair a specific summary ID")
 	verbose := fs.Bool("verbose", false, "include old content hash and preview")
+	resume := fs.Bool("resume", false, "resume the last interrupted --apply run")
+	concurrency := fs.Int("concurrency", defaultRepairWorkers, "summaries to repair in parallel")
+	maxRetries := fs.Int("max-retries", defaultRepairRetries, "retries per summary on 429/5xx/timeouts")
+	detect := fs.String("detect", defaultDetectors, "comma-separated corruption detectors, or all")
+	provider := fs.String("provider", providerAnthropic, "summarizer provider: anthropic, openai, or extractive")
+	model := fs.String("model", "", "model name (default depends on provider)")
+	baseURL := fs.String("base-url", "", "API base URL (default depends on provider)")
+	promptDir := fs.String("prompt-dir", "", "directory with leaf.tmpl/condensed.tmpl prompt overrides")
+	instructions := fs.String("instructions", "", "operator instructions added to every repair prompt")
+	tokenizer := fs.String("tokenizer", defaultTokenizer, "tokenizer for token_count: heuristic, an encoding, or a model name")
 
 	normalizedArgs, err := normalizeRepairArgs(args)
 	if err != nil {
@@ -188,13 +277,57 @@ func parseRepairArgs(args []string) (repairOptions, int64, error) {
 	if *all && *summaryID != "" {
 		return repairOptions{}, 0, fmt.Errorf("--all and --summary-id cannot be combined\n%s", repairUsageText())
 	}
+	if *resume && (*all || *summaryID != "") {
+		return repairOptions{}, 0, fmt.Errorf("--resume reuses the interrupted run's scope and cannot be combined with --all or --summary-id\n%s", repairUsageText())
+	}
+	if *concurrency < 1 {
+		return repairOptions{}, 0, fmt.Errorf("--concurrency must be at least 1\n%s", repairUsageText())
+	}
+	if *maxRetries < 0 {
+		return repairOptions{}, 0, fmt.Errorf("--max-retries must not be negative\n%s", repairUsageText())
+	}
+	summarizerSet, promptsSet, tokenizerSet := false, false, false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "provider", "model", "base-url":
+			summarizerSet = true
+		case "prompt-dir", "instructions":
+			promptsSet = true
+		case "tokenizer":
+			tokenizerSet = true
+		}
+	})
+
+	summarizerConfig, err := parseSummarizerConfig(*provider, *model, *baseURL)
+	if err != nil {
+		return repairOptions{}, 0, fmt.Errorf("%w\n%s", err, repairUsageText())
+	}
+	detectors, err := parseDetectors(*detect)
+	if err != nil {
+		return repairOptions{}, 0, fmt.Errorf("%w\n%s", err, repairUsageText())
+	}
+	promptDirValue, err := absPromptDir(*promptDir)
+	if err != nil {
+		return repairOptions{}, 0, err
+	}
 
 	opts := repairOptions{
-		apply:     *apply,
-		dryRun:    *dryRun,
-		all:       *all,
-		summaryID: strings.TrimSpace(*summaryID),
-		verbose:   *verbose,
+		apply:         *apply,
+		dryRun:        *dryRun,
+		all:           *all,
+		resume:        *resume,
+		summaryID:     strings.TrimSpace(*summaryID),
+		verbose:       *verbose,
+		summarizer:    summarizerConfig,
+		summarizerSet: summarizerSet,
+		concurrency:   *concurrency,
+		maxRetries:    *maxRetries,
+		detectors:     detectors,
+		promptDir:     promptDirValue,
+		instructions:  strings.TrimSpace(*instructions),
+		promptsSet:    promptsSet,
+		tokenizer:     strings.TrimSpace(*tokenizer),
+		tokenizerSet:  tokenizerSet,
 	}
 	if opts.apply {
 		opts.dryRun = false
@@ -203,9 +336,9 @@ func parseRepairArgs(args []string) (repairOptions, int64, error) {
 		opts.dryRun = true
 	}
 
-	if opts.all {
+	if opts.all || opts.resume {
 		if fs.NArg() != 0 {
-			return repairOptions{}, 0, fmt.Errorf("conversation ID is not allowed with --all\n%s", repairUsageText())
+			return repairOptions{}, 0, fmt.Errorf("conversation ID is not allowed with --all or --resume\n%s", repairUsageText())
 		}
 		return opts, 0, nil
 	}
@@ -227,13 +360,13 @@ func normalizeRepairArgs(args []string) ([]string, error) {
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
 		switch {
-		case arg == "--apply" || arg == "--dry-run" || arg == "--all" || arg == "--verbose":
-			flags = append(flags, arg)
-		case strings.HasPrefix(arg, "--summary-id="):
+		case arg == "--apply" || arg == "--dry-run" || arg == "--all" || arg == "--verbose" || arg == "--resume":
 			flags = append(flags, arg)
-		case arg == "--summary-id":
+		case arg == "--summary-id" || arg == "--provider" || arg == "--model" || arg == "--base-url" ||
+			arg == "--concurrency" || arg == "--max-retries" || arg == "--detect" ||
+			arg == "--prompt-dir" || arg == "--instructions" || arg == "--tokenizer":
 			if i+1 >= len(args) {
-				return nil, errors.New("missing value for --summary-id")
+				return nil, fmt.Errorf("missing value for %s", arg)
 			}
 			flags = append(flags, arg, args[i+1])
 			i++
@@ -252,6 +385,40 @@ Usage:
   lcm-tui repair <conversation_id> [--dry-run] [--summary-id <id>]
   lcm-tui repair <conversation_id> --apply [--summary-id <id>]
   lcm-tui repair --all [--dry-run|--apply]
+  lcm-tui repair --resume [--apply]         # continue an interrupted --apply run
+
+Detectors (--detect=marker,empty,... or --detect=all; default marker):
+  marker      contains the LCM fallback truncation marker
+  empty       content is empty or whitespace
+  thinking    content is only echoed thinking blocks
+  oversize    content is far over the prompt's target length
+  transcript  content is a raw role-prefixed transcript dump
+  format      condensed summary is missing required section headings
+
+Each repaired summary is committed as soon as it is written. Independent
+summaries run in parallel (--concurrency, default 4), and 429/5xx responses
+are retried with exponential backoff (--max-retries, default 5).
+
+Summarizer (with --apply):
+  --provider anthropic|openai|extractive   (default anthropic)
+  --model <name>                           (default depends on provider)
+  --base-url <url>                         e.g. http://localhost:11434 for Ollama
+
+The openai provider talks to any OpenAI-compatible /v1/chat/completions
+endpoint and reads OPENAI_API_KEY when set. The extractive provider runs
+offline and keeps leading source lines verbatim.
+
+Prompts (with --apply):
+  --prompt-dir <dir>        leaf.tmpl / condensed.tmpl overrides
+                            (default ~/.openclaw/lcm-tui/prompts)
+  --instructions "<text>"   fills the prompts' operator-instructions slot
+
+Templates are Go text/template files and may use {{.Text}},
+{{.PreviousContext}}, {{.TargetTokens}} and {{.Instructions}}. A missing
+file falls back to the built-in prompt.
+
+--tokenizer <name> picks how token_count is computed for repaired summaries
+(default heuristic); see lcm-tui recount for the accepted values.
 `)
 }
 
@@ -260,12 +427,13 @@ func resolveRepairConversationIDs(ctx context.Context, db *sql.DB, opts repairOp
 		return []int64{conversationID}, nil
 	}
 
+	filter, args := detectorPrefilter(opts.detectors)
 	rows, err := db.QueryContext(ctx, `
-		SELECT DISTINCT conversation_id
-		FROM summaries
-		WHERE content LIKE ?
+		SELECT conversation_id, kind, depth, content
+		FROM summaries s
+		WHERE 1 = 1`+filter+`
 		ORDER BY conversation_id ASC
-	`, "%"+corruptedSummaryMarker+"%")
+	`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query corrupted conversations: %w", err)
 	}
@@ -273,11 +441,16 @@ func resolveRepairConversationIDs(ctx context.Context, db *sql.DB, opts repairOp
 
 	var ids []int64
 	for rows.Next() {
-		var id int64
-		if err := rows.Scan(&id); err != nil {
+		var item repairSummary
+		if err := rows.Scan(&item.conversationID, &item.kind, &item.depth, &item.content); err != nil {
 			return nil, fmt.Errorf("scan corrupted conversation ID: %w", err)
 		}
-		ids = append(ids, id)
+		if len(ids) > 0 && ids[len(ids)-1] == item.conversationID {
+			continue
+		}
+		if len(runDetectors(opts.detectors, item)) > 0 {
+			ids = append(ids, item.conversationID)
+		}
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate corrupted conversations: %w", err)
@@ -285,55 +458,75 @@ func resolveRepairConversationIDs(ctx context.Context, db *sql.DB, opts repairOp
 	return ids, nil
 }
 
-func runRepairConversation(ctx context.Context, db *sql.DB, conversationID int64, opts repairOptions, client *anthropicClient) (int, error) {
+// detectorPrefilter narrows summary scans in SQL when only the marker
+// detector is selected; other detectors need every row's content.
+func detectorPrefilter(detectors []summaryDetector) (string, []any) {
+	if len(detectors) == 1 && detectors[0].name == "marker" {
+		return " AND s.content LIKE ?", []any{"%" + corruptedSummaryMarker + "%"}
+	}
+	return "", nil
+}
+
+func runRepairConversation(ctx context.Context, db *sql.DB, conversationID int64, opts repairOptions, client summarizer, run *repairRun) (repairOutcome, error) {
 	label := "Scanning"
 	if opts.apply {
 		label = "Repairing"
 	}
 	fmt.Printf("%s conversation %d...\n\n", label, conversationID)
 
-	plan, err := buildRepairPlan(ctx, db, conversationID, opts.summaryID)
+	plan, err := buildRepairPlan(ctx, db, conversationID, opts.summaryID, opts.detectors)
 	if err != nil {
-		return 0, err
+		return repairOutcome{}, err
 	}
+	plan = run.skipDone(plan)
 	if len(plan.summaries) == 0 {
 		if opts.summaryID != "" {
 			exists, err := summaryExists(ctx, db, conversationID, opts.summaryID)
 			if err != nil {
-				return 0, err
+				return repairOutcome{}, err
 			}
 			if exists {
 				fmt.Printf("Summary %s is not corrupted.\n", opts.summaryID)
-				return 0, nil
+				return repairOutcome{}, nil
 			}
 			fmt.Printf("Summary %s not found in conversation %d.\n", opts.summaryID, conversationID)
-			return 0, nil
+			return repairOutcome{}, nil
 		}
 		fmt.Println("No corrupted summaries found.")
-		return 0, nil
+		return repairOutcome{}, nil
 	}
 
 	if opts.dryRun {
 		printDryRunReport(plan.summaries, plan.ordered)
-		return 0, nil
+		return repairOutcome{}, nil
 	}
 
-	repaired, err := applyRepairs(ctx, db, plan, opts, client)
+	outcome, err := applyRepairs(ctx, db, plan, opts, client, run)
 	if err != nil {
-		return repaired, err
+		return outcome, err
+	}
+	fmt.Printf("Done. %d summaries repaired. Changes take effect on next conversation turn.\n", outcome.repaired)
+	if outcome.failed > 0 {
+		fmt.Printf("%d failed, %d skipped because they depend on a failed summary.\n", outcome.failed, outcome.skipped)
 	}
-	fmt.Printf("\nDone. %d summaries repaired. Changes take effect on next conversation turn.\n", repaired)
-	return repaired, nil
+	return outcome, nil
 }
 
 // buildRepairPlan computes both the scan output and bottom-up repair order.
 // Leaves are repaired in context_items ordinal order so each repaired leaf can
 // feed previous_context into the next corrupted leaf.
-func buildRepairPlan(ctx context.Context, q sqlQueryer, conversationID int64, summaryID string) (repairPlan, error) {
-	summaries, err := loadCorruptedSummaries(ctx, q, conversationID, summaryID)
+func buildRepairPlan(ctx context.Context, q sqlQueryer, conversationID int64, summaryID string, detectors []summaryDetector) (repairPlan, error) {
+	summaries, err := loadCorruptedSummaries(ctx, q, conversationID, summaryID, detectors)
 	if err != nil {
 		return repairPlan{}, err
 	}
+	return orderRepairPlan(ctx, q, conversationID, summaries)
+}
+
+// orderRepairPlan sorts summaries of one conversation into repair order:
+// leaves in context order, then leaves outside the context, then condensed
+// summaries by depth.
+func orderRepairPlan(ctx context.Context, q sqlQueryer, conversationID int64, summaries []repairSummary) (repairPlan, error) {
 	if len(summaries) == 0 {
 		return repairPlan{}, nil
 	}
@@ -423,7 +616,32 @@ func buildRepairPlan(ctx context.Context, q sqlQueryer, conversationID int64, su
 	}, nil
 }
 
-func loadCorruptedSummaries(ctx context.Context, q sqlQueryer, conversationID int64, summaryID string) ([]repairSummary, error) {
+// loadCorruptedSummaries returns the conversation's summaries flagged by at
+// least one of detectors, with the findings attached.
+func loadCorruptedSummaries(ctx context.Context, q sqlQueryer, conversationID int64, summaryID string, detectors []summaryDetector) ([]repairSummary, error) {
+	filter, args := detectorPrefilter(detectors)
+	if summaryID != "" {
+		filter += " AND s.summary_id = ?"
+		args = append(args, summaryID)
+	}
+	candidates, err := loadRepairSummaries(ctx, q, conversationID, filter, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []repairSummary
+	for _, item := range candidates {
+		item.findings = runDetectors(detectors, item)
+		if len(item.findings) > 0 {
+			summaries = append(summaries, item)
+		}
+	}
+	return summaries, nil
+}
+
+// loadRepairSummaries returns the conversation's summaries matching filter, an
+// SQL fragment starting with AND over the summaries alias s.
+func loadRepairSummaries(ctx context.Context, q sqlQueryer, conversationID int64, filter string, filterArgs ...any) ([]repairSummary, error) {
 	query := `
 		SELECT
 			s.summary_id,
@@ -441,18 +659,14 @@ func loadCorruptedSummaries(ctx context.Context, q sqlQueryer, conversationID in
 			GROUP BY summary_id
 		) spc ON spc.summary_id = s.summary_id
 		WHERE s.conversation_id = ?
-		  AND s.content LIKE ?
 	`
-	args := []any{conversationID, "%" + corruptedSummaryMarker + "%"}
-	if summaryID != "" {
-		query += " AND s.summary_id = ?"
-		args = append(args, summaryID)
-	}
+	args := append([]any{conversationID}, filterArgs...)
+	query += filter
 	query += " ORDER BY s.depth DESC, s.created_at ASC, s.summary_id ASC"
 
 	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query corrupted summaries for conversation %d: %w", conversationID, err)
+		return nil, fmt.Errorf("query summaries for conversation %d: %w", conversationID, err)
 	}
 	defer rows.Close()
 
@@ -469,12 +683,12 @@ func loadCorruptedSummaries(ctx context.Context, q sqlQueryer, conversationID in
 			&item.createdAt,
 			&item.childCount,
 		); err != nil {
-			return nil, fmt.Errorf("scan corrupted summary row: %w", err)
+			return nil, fmt.Errorf("scan summary row: %w", err)
 		}
 		summaries = append(summaries, item)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate corrupted summaries: %w", err)
+		return nil, fmt.Errorf("iterate summaries: %w", err)
 	}
 	return summaries, nil
 }
@@ -536,6 +750,9 @@ func printDryRunReport(summaries []repairSummary, ordered []repairSummary) {
 			line += fmt.Sprintf("  [%d children]", item.childCount)
 		}
 		fmt.Println(line)
+		for _, finding := range item.findings {
+			fmt.Printf("      flagged by %s\n", finding)
+		}
 	}
 	fmt.Println()
 	fmt.Println("Repair order (bottom-up):")
@@ -560,73 +777,119 @@ func printDryRunReport(summaries []repairSummary, ordered []repairSummary) {
 	fmt.Println("Run with --apply to execute repairs.")
 }
 
-func applyRepairs(ctx context.Context, db *sql.DB, plan repairPlan, opts repairOptions, client *anthropicClient) (int, error) {
+func applyRepairs(ctx context.Context, db *sql.DB, plan repairPlan, opts repairOptions, client summarizer, run *repairRun) (repairOutcome, error) {
 	if client == nil {
-		return 0, errors.New("missing Anthropic client")
+		return repairOutcome{}, errors.New("missing summarizer")
+	}
+	if run == nil {
+		return repairOutcome{}, errors.New("missing repair run checkpoint")
 	}
 
-	tx, err := db.BeginTx(ctx, nil)
+	deps, err := buildRepairDependencies(ctx, db, plan)
 	if err != nil {
-		return 0, fmt.Errorf("begin repair transaction: %w", err)
+		return repairOutcome{}, err
 	}
-
-	repaired := 0
-	rollbackNeeded := true
-	defer func() {
-		if rollbackNeeded {
-			_ = tx.Rollback()
+	return scheduleRepairs(ctx, plan.ordered, deps, opts.concurrency, func(item repairSummary) (string, error) {
+		log, err := repairOneSummary(ctx, db, item, opts, client, run)
+		if err != nil {
+			if markErr := run.markFailed(ctx, db, item.summaryID, err); markErr != nil {
+				err = errors.Join(err, markErr)
+			}
 		}
-	}()
+		return log, err
+	}), nil
+}
 
-	for i, item := range plan.ordered {
-		fmt.Printf("[%d/%d] %s (%s, d%d)\n", i+1, len(plan.ordered), item.summaryID, item.kind, item.depth)
+// repairOneSummary regenerates one summary and commits it together with its
+// journal entry and checkpoint row. The returned log is printed as one block
+// so concurrent workers do not interleave output.
+func repairOneSummary(ctx context.Context, db *sql.DB, item repairSummary, opts repairOptions, client summarizer, run *repairRun) (string, error) {
+	var log strings.Builder
+	logf := func(format string, args ...any) {
+		fmt.Fprintf(&log, format, args...)
+	}
 
-		source, err := buildSummaryRepairSource(ctx, tx, item)
-		if err != nil {
-			return repaired, err
-		}
-		fmt.Printf("  Sources: %d %s (%d tokens)\n", source.itemCount, source.label, source.estimatedTokens)
+	source, err := buildSummaryRepairSource(ctx, db, item)
+	if err != nil {
+		return log.String(), err
+	}
+	logf("  Sources: %d %s (%d tokens)\n", source.itemCount, source.label, source.estimatedTokens)
 
-		oldDescriptor := "existing content"
-		if strings.Contains(item.content, corruptedSummaryMarker) {
-			oldDescriptor = "truncated garbage"
-		}
-		fmt.Printf("  Old: %d chars / %d tokens (%s)\n", len(item.content), item.tokenCount, oldDescriptor)
-		if opts.verbose {
-			fmt.Printf("  Old hash: %s | Preview: %q\n", shortSHA256(item.content), previewForLog(item.content, 100))
-		}
+	oldDescriptor := "existing content"
+	if strings.Contains(item.content, corruptedSummaryMarker) {
+		oldDescriptor = "truncated garbage"
+	} else if len(item.findings) > 0 {
+		oldDescriptor = "flagged by " + detectorLabels(item.findings)
+	}
+	logf("  Old: %d chars / %d tokens (%s)\n", len(item.content), item.tokenCount, oldDescriptor)
+	if opts.verbose {
+		logf("  Old hash: %s | Preview: %q\n", shortSHA256(item.content), previewForLog(item.content, 100))
+	}
 
-		previousContext, err := resolvePreviousContext(ctx, tx, item)
-		if err != nil {
-			return repaired, err
-		}
-		prompt, targetTokens := buildRepairPrompt(item.kind, source.text, previousContext, source.estimatedTokens)
-		newContent, err := client.summarize(ctx, prompt, targetTokens)
-		if err != nil {
-			return repaired, fmt.Errorf("summarize %s: %w", item.summaryID, err)
-		}
+	previousContext, err := resolvePreviousContext(ctx, db, item)
+	if err != nil {
+		return log.String(), err
+	}
+	req, err := opts.prompts.build(item.kind, source.text, previousContext, source.estimatedTokens)
+	if err != nil {
+		return log.String(), err
+	}
+	newContent, err := summarizeWithRetry(ctx, client, req, opts.maxRetries, logf)
+	if err != nil {
+		return log.String(), fmt.Errorf("summarize %s: %w", item.summaryID, err)
+	}
 
-		newTokens := estimateTokenCount(newContent)
-		if newTokens == 0 && strings.TrimSpace(newContent) != "" {
-			newTokens = 1
-		}
+	newTokens := opts.tokens.countTokens(newContent)
 
-		if _, err := tx.ExecContext(ctx, `
-			UPDATE summaries
-			SET content = ?, token_count = ?
-			WHERE summary_id = ?
-		`, newContent, newTokens, item.summaryID); err != nil {
-			return repaired, fmt.Errorf("update summary %s: %w", item.summaryID, err)
+	if err := commitRepairedSummary(ctx, db, run, item.summaryID, newContent, newTokens); err != nil {
+		return log.String(), err
+	}
+	logf("  New: %d chars / %d tokens ✓\n", len(newContent), newTokens)
+	return log.String(), nil
+}
+
+func commitRepairedSummary(ctx context.Context, db *sql.DB, run *repairRun, summaryID, content string, tokenCount int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin repair transaction for %s: %w", summaryID, err)
+	}
+	rollbackNeeded := true
+	defer func() {
+		if rollbackNeeded {
+			_ = tx.Rollback()
 		}
-		fmt.Printf("  New: %d chars / %d tokens ✓\n\n", len(newContent), newTokens)
-		repaired++
+	}()
+
+	if err := writeRepairedSummary(ctx, tx, run.opID, summaryID, content, tokenCount); err != nil {
+		return err
+	}
+	if err := run.markDone(ctx, tx, summaryID); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return repaired, fmt.Errorf("commit repair transaction: %w", err)
+		return fmt.Errorf("commit repair of %s: %w", summaryID, err)
 	}
 	rollbackNeeded = false
-	return repaired, nil
+	return nil
+}
+
+// writeRepairedSummary replaces a summary's content and journals the change
+// under opID.
+func writeRepairedSummary(ctx context.Context, q sqlQueryer, opID int64, summaryID, content string, tokenCount int) error {
+	before, err := captureRows(ctx, q, "summaries", "summary_id = ?", summaryID)
+	if err != nil {
+		return err
+	}
+	if _, err := q.ExecContext(ctx, `
+		UPDATE summaries
+		SET content = ?, token_count = ?
+		WHERE summary_id = ?
+	`, content, tokenCount, summaryID); err != nil {
+		return fmt.Errorf("update summary %s: %w", summaryID, err)
+	}
+	j := &journal{q: q, opID: opID}
+	return j.recordUpdate(ctx, "summaries", before)
 }
 
 func buildSummaryRepairSource(ctx context.Context, q sqlQueryer, item repairSummary) (repairSource, error) {
@@ -640,7 +903,6 @@ func buildSummaryRepairSource(ctx context.Context, q sqlQueryer, item repairSumm
 func buildLeafRepairSource(ctx context.Context, q sqlQueryer, summaryID string) (repairSource, error) {
 	rows, err := q.QueryContext(ctx, `
 		SELECT
-			sm.ordinal,
 			m.message_id,
 			m.role,
 			m.content,
@@ -660,6 +922,28 @@ func buildLeafRepairSource(ctx context.Context, q sqlQueryer, summaryID string)
 	}
 	defer rows.Close()
 
+	lines, err := renderSourceMessages(rows)
+	if err != nil {
+		return repairSource{}, err
+	}
+	if len(lines) == 0 {
+		return repairSource{}, fmt.Errorf("no source messages linked to summary %s", summaryID)
+	}
+
+	text := strings.Join(lines, "\n")
+	return repairSource{
+		text:            text,
+		itemCount:       len(lines),
+		estimatedTokens: estimateTokenCount(text),
+		label:           "messages",
+	}, nil
+}
+
+// renderSourceMessages renders rows of (message_id, role, content,
+// part ordinal, part_type, text_content), ordered by message then part, as
+// one "[role] body" line per message. Text parts replace the message content;
+// tool parts without text show as placeholders.
+func renderSourceMessages(rows *sql.Rows) ([]string, error) {
 	type messageChunk struct {
 		role     string
 		fallback string
@@ -693,16 +977,15 @@ func buildLeafRepairSource(ctx context.Context, q sqlQueryer, summaryID string)
 
 	for rows.Next() {
 		var (
-			summaryOrdinal int64
-			messageID      int64
-			role           string
-			content        string
-			partOrdinal    sql.NullInt64
-			partType       sql.NullString
-			partTextValue  sql.NullString
+			messageID     int64
+			role          string
+			content       string
+			partOrdinal   sql.NullInt64
+			partType      sql.NullString
+			partTextValue sql.NullString
 		)
-		if err := rows.Scan(&summaryOrdinal, &messageID, &role, &content, &partOrdinal, &partType, &partTextValue); err != nil {
-			return repairSource{}, fmt.Errorf("scan summary message row: %w", err)
+		if err := rows.Scan(&messageID, &role, &content, &partOrdinal, &partType, &partTextValue); err != nil {
+			return nil, fmt.Errorf("scan source message row: %w", err)
 		}
 
 		if !active || currentMessageID != messageID {
@@ -724,21 +1007,10 @@ func buildLeafRepairSource(ctx context.Context, q sqlQueryer, summaryID string)
 		}
 	}
 	if err := rows.Err(); err != nil {
-		return repairSource{}, fmt.Errorf("iterate summary message rows: %w", err)
+		return nil, fmt.Errorf("iterate source message rows: %w", err)
 	}
 	flushCurrent()
-
-	if len(lines) == 0 {
-		return repairSource{}, fmt.Errorf("no source messages linked to summary %s", summaryID)
-	}
-
-	text := strings.Join(lines, "\n")
-	return repairSource{
-		text:            text,
-		itemCount:       len(lines),
-		estimatedTokens: estimateTokenCount(text),
-		label:           "messages",
-	}, nil
+	return lines, nil
 }
 
 func buildCondensedRepairSource(ctx context.Context, q sqlQueryer, summaryID string) (repairSource, error) {
@@ -844,14 +1116,6 @@ func resolveCondensedPreviousContext(ctx context.Context, q sqlQueryer, item rep
 	return content, nil
 }
 
-func buildRepairPrompt(kind, text, previousContext string, inputTokens int) (string, int) {
-	if strings.EqualFold(kind, "leaf") {
-		targetTokens := calculateLeafTargetTokens(inputTokens)
-		return buildLeafSummaryPrompt(text, previousContext, targetTokens), targetTokens
-	}
-	return buildCondensedSummaryPrompt(text, previousContext, condensedTargetTokens), condensedTargetTokens
-}
-
 func calculateLeafTargetTokens(inputTokens int) int {
 	target := int(math.Floor(float64(inputTokens) * 0.35))
 	if target < 192 {
@@ -863,86 +1127,25 @@ func calculateLeafTargetTokens(inputTokens int) int {
 	return target
 }
 
-func buildLeafSummaryPrompt(text, previousContext string, targetTokens int) string {
-	prev := strings.TrimSpace(previousContext)
-	if prev == "" {
-		prev = "(none)"
-	}
-	return fmt.Sprintf(`You summarize a SEGMENT of an OpenClaw conversation for future model turns.
-Treat this as incremental memory compaction input, not a full-conversation summary.
-
-Normal summary policy:
-- Preserve key decisions, rationale, constraints, and active tasks.
-- Keep essential technical details needed to continue work safely.
-- Remove obvious repetition and conversational filler.
-
-Operator instructions: (none)
-
-Output requirements:
-- Plain text only.
-- No preamble, headings, or markdown formatting.
-- Keep it concise while preserving required details.
-- Track file operations (created, modified, deleted, renamed) with file paths and current status.
-- If no file operations appear, include exactly: "Files: none".
-- Target length: about %d tokens or less.
-
-<previous_context>
-%s
-</previous_context>
-
-<conversation_segment>
-%s
-</conversation_segment>
-`, targetTokens, prev, text)
-}
-
-func buildCondensedSummaryPrompt(text, previousContext string, targetTokens int) string {
-	prev := strings.TrimSpace(previousContext)
-	if prev == "" {
-		prev = "(none)"
-	}
-	return fmt.Sprintf(`You produce a Pi-inspired condensed OpenClaw memory summary for long-context handoff.
-Capture only durable facts that matter for future execution and safe continuation.
-
-Operator instructions: (none)
-
-Output requirements:
-- Use plain text.
-- Use these exact section headings in this exact order:
-Goals & Context
-Key Decisions
-Progress
-Constraints
-Critical Details
-Files
-- Under Files, list file operations (created, modified, deleted, renamed) with path and current status.
-- If no file operations are present, set Files to: none.
-- Target length: about %d tokens.
-
-<previous_context>
-%s
-</previous_context>
-
-<conversation_to_condense>
-%s
-</conversation_to_condense>
-`, targetTokens, prev, text)
+func (c *anthropicClient) describe() string {
+	return fmt.Sprintf("%s (%s at %s)", providerAnthropic, c.model, c.baseURL)
 }
 
-func (c *anthropicClient) summarize(ctx context.Context, prompt string, targetTokens int) (string, error) {
+func (c *anthropicClient) summarize(ctx context.Context, request summaryRequest) (string, error) {
 	if strings.TrimSpace(c.apiKey) == "" {
 		return "", errors.New("missing Anthropic API key")
 	}
+	targetTokens := request.targetTokens
 	if targetTokens <= 0 {
 		targetTokens = condensedTargetTokens
 	}
 
 	reqBody := anthropicRequest{
-		Model:       anthropicModel,
+		Model:       c.model,
 		MaxTokens:   targetTokens,
 		Temperature: 0,
 		Messages: []anthropicRequestMessage{
-			{Role: "user", Content: prompt},
+			{Role: "user", Content: request.prompt},
 		},
 	}
 	payload, err := json.Marshal(reqBody)
@@ -950,7 +1153,7 @@ func (c *anthropicClient) summarize(ctx context.Context, prompt string, targetTo
 		return "", fmt.Errorf("marshal Anthropic request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.anthropic.com/v1/messages", bytes.NewReader(payload))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiEndpoint(c.baseURL, "messages"), bytes.NewReader(payload))
 	if err != nil {
 		return "", fmt.Errorf("build Anthropic request: %w", err)
 	}
@@ -970,11 +1173,12 @@ func (c *anthropicClient) summarize(ctx context.Context, prompt string, targetTo
 	}
 
 	if resp.StatusCode >= 300 {
-		var apiErr anthropicErrorEnvelope
-		if json.Unmarshal(body, &apiErr) == nil && strings.TrimSpace(apiErr.Error.Message) != "" {
-			return "", fmt.Errorf("Anthropic API %d %s: %s", resp.StatusCode, apiErr.Error.Type, apiErr.Error.Message)
+		apiErr := &summarizerAPIError{api: "Anthropic API", status: resp.StatusCode, message: strings.TrimSpace(string(body)), retryAfter: parseRetryAfter(resp.Header)}
+		var envelope anthropicErrorEnvelope
+		if json.Unmarshal(body, &envelope) == nil && strings.TrimSpace(envelope.Error.Message) != "" {
+			apiErr.errType, apiErr.message = envelope.Error.Type, envelope.Error.Message
 		}
-		return "", fmt.Errorf("Anthropic API %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return "", apiErr
 	}
 
 	var parsed anthropicResponse
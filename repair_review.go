@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// repairCandidateStatus tracks one corrupted summary through review.
+type repairCandidateStatus int
+
+const (
+	repairPending repairCandidateStatus = iota
+	repairGenerated
+	repairAccepted
+	repairRejected
+)
+
+func (s repairCandidateStatus) String() string {
+	switch s {
+	case repairGenerated:
+		return "review"
+	case repairAccepted:
+		return "accepted"
+	case repairRejected:
+		return "rejected"
+	default:
+		return "pending"
+	}
+}
+
+// repairCandidate pairs a corrupted summary with its proposed replacement.
+// Nothing is written until the candidate is accepted.
+type repairCandidate struct {
+	item           repairSummary
+	proposed       string
+	proposedTokens int
+	status         repairCandidateStatus
+	err            string
+}
+
+// repairPlanLoadedMsg carries the repair order for the review screen.
+type repairPlanLoadedMsg struct {
+	seq            int
+	conversationID int64
+	plan           repairPlan
+	err            error
+}
+
+// repairCandidateMsg carries a generated replacement for one summary.
+type repairCandidateMsg struct {
+	seq       int
+	summaryID string
+	content   string
+	tokens    int
+	err       error
+}
+
+func loadRepairPlanCmd(ctx context.Context, seq int, db *sql.DB, conversationID int64) tea.Cmd {
+	return func() tea.Msg {
+		if db == nil {
+			return repairPlanLoadedMsg{seq: seq, conversationID: conversationID, err: errLCMDBUnavailable}
+		}
+		detectors, err := parseDetectors(defaultDetectors)
+		if err != nil {
+			return repairPlanLoadedMsg{seq: seq, conversationID: conversationID, err: err}
+		}
+		plan, err := buildRepairPlan(ctx, db, conversationID, "", detectors)
+		return repairPlanLoadedMsg{seq: seq, conversationID: conversationID, plan: plan, err: err}
+	}
+}
+
+// generateRepairCandidateCmd builds the repair prompt from the current DB
+// state, so previous_context reflects candidates accepted earlier.
+func generateRepairCandidateCmd(ctx context.Context, seq int, db *sql.DB, client summarizer, prompts *repairPrompts, tokens tokenCounter, item repairSummary) tea.Cmd {
+	return func() tea.Msg {
+		result := repairCandidateMsg{seq: seq, summaryID: item.summaryID}
+		if db == nil {
+			result.err = errLCMDBUnavailable
+			return result
+		}
+		source, err := buildSummaryRepairSource(ctx, db, item)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		previousContext, err := resolvePreviousContext(ctx, db, item)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		req, err := prompts.build(item.kind, source.text, previousContext, source.estimatedTokens)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		content, err := summarizeWithRetry(ctx, client, req, defaultRepairRetries, func(string, ...any) {})
+		if err != nil {
+			result.err = err
+			return result
+		}
+		result.content = content
+		result.tokens = tokens.countTokens(content)
+		return result
+	}
+}
+
+// openRepairReview scans the current conversation for corrupted summaries
+// and switches to the review screen once the plan is loaded.
+func (m *model) openRepairReview() tea.Cmd {
+	if m.summary.conversationID <= 0 {
+		m.status = "Missing conversation ID for current summary graph"
+		return nil
+	}
+	ctx, seq := m.beginLoad("Scanning for corrupted summaries...")
+	return m.startLoad(loadRepairPlanCmd(ctx, seq, m.db, m.summary.conversationID))
+}
+
+func (m model) handleRepairPlanLoaded(msg repairPlanLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+	if len(msg.plan.ordered) == 0 {
+		m.status = fmt.Sprintf("No corrupted summaries in conversation %d", msg.conversationID)
+		return m, nil
+	}
+
+	m.repairCandidates = make([]repairCandidate, 0, len(msg.plan.ordered))
+	for _, item := range msg.plan.ordered {
+		m.repairCandidates = append(m.repairCandidates, repairCandidate{item: item})
+	}
+	m.repairConversationID = msg.conversationID
+	m.repairCursor = 0
+	m.repairOpID = 0
+	m.screen = screenRepair
+	m.status = fmt.Sprintf("%d corrupted summaries. Press g to generate a replacement.", len(m.repairCandidates))
+	return m, nil
+}
+
+func (m model) handleRepairCandidate(msg repairCandidateMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	candidate := m.findRepairCandidate(msg.summaryID)
+	if candidate == nil {
+		return m, nil
+	}
+	if msg.err != nil {
+		candidate.err = msg.err.Error()
+		m.status = fmt.Sprintf("Error generating %s: %v", msg.summaryID, msg.err)
+		return m, nil
+	}
+	candidate.proposed = msg.content
+	candidate.proposedTokens = msg.tokens
+	candidate.status = repairGenerated
+	candidate.err = ""
+	m.status = fmt.Sprintf("Generated %s (%dt). a: accept | x: reject | g: regenerate", msg.summaryID, msg.tokens)
+	return m, nil
+}
+
+func (m *model) findRepairCandidate(summaryID string) *repairCandidate {
+	for i := range m.repairCandidates {
+		if m.repairCandidates[i].item.summaryID == summaryID {
+			return &m.repairCandidates[i]
+		}
+	}
+	return nil
+}
+
+func (m *model) currentRepairCandidate() (*repairCandidate, bool) {
+	if len(m.repairCandidates) == 0 {
+		return nil, false
+	}
+	m.repairCursor = clamp(m.repairCursor, 0, len(m.repairCandidates)-1)
+	return &m.repairCandidates[m.repairCursor], true
+}
+
+func (m model) handleRepairKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.repairCursor = clamp(m.repairCursor-1, 0, len(m.repairCandidates)-1)
+	case "down", "j":
+		m.repairCursor = clamp(m.repairCursor+1, 0, len(m.repairCandidates)-1)
+	case "g", "enter":
+		return m, m.generateRepairCandidate()
+	case "a":
+		return m, m.acceptRepairCandidate()
+	case "x":
+		candidate, ok := m.currentRepairCandidate()
+		if !ok || candidate.status == repairAccepted {
+			return m, nil
+		}
+		candidate.status = repairRejected
+		m.status = fmt.Sprintf("Rejected %s; nothing written", candidate.item.summaryID)
+	case "b", "backspace", "esc":
+		m.cancelLoad()
+		m.screen = screenSummaries
+		m.status = "Back to summaries"
+		if m.repairOpID > 0 {
+			return m, m.reloadSummariesAfterRepair()
+		}
+	}
+	return m, nil
+}
+
+func (m *model) generateRepairCandidate() tea.Cmd {
+	candidate, ok := m.currentRepairCandidate()
+	if !ok {
+		return nil
+	}
+	if candidate.status == repairAccepted {
+		m.status = candidate.item.summaryID + " is already accepted"
+		return nil
+	}
+	if err := m.ensureSummarizer(); err != nil {
+		m.status = "Error: " + err.Error()
+		return nil
+	}
+	ctx, seq := m.beginLoad(fmt.Sprintf("Generating %s with %s...", candidate.item.summaryID, m.repairClient.describe()))
+	return m.startLoad(generateRepairCandidateCmd(ctx, seq, m.db, m.repairClient, m.repairPrompts, m.repairTokens, candidate.item))
+}
+
+// ensureSummarizer sets up the summarizer, prompts and token counter from
+// the LCM_TUI_* environment on first use.
+func (m *model) ensureSummarizer() error {
+	if m.repairClient == nil {
+		cfg, err := summarizerConfigFromEnv()
+		if err != nil {
+			return err
+		}
+		client, err := newSummarizer(cfg, m.paths)
+		if err != nil {
+			return err
+		}
+		m.repairClient = client
+	}
+	if m.repairPrompts == nil {
+		prompts, err := repairPromptsFromEnv(m.paths)
+		if err != nil {
+			return err
+		}
+		m.repairPrompts = prompts
+	}
+	if m.repairTokens == nil {
+		tokens, err := newTokenCounter(os.Getenv("LCM_TUI_TOKENIZER"), m.paths)
+		if err != nil {
+			return err
+		}
+		m.repairTokens = tokens
+	}
+	return nil
+}
+
+// acceptRepairCandidate writes the selected replacement. The first accept of
+// a review snapshots lcm.db and opens a journal op that later accepts share.
+func (m *model) acceptRepairCandidate() tea.Cmd {
+	candidate, ok := m.currentRepairCandidate()
+	if !ok {
+		return nil
+	}
+	if candidate.status != repairGenerated {
+		m.status = "Generate a replacement with g before accepting"
+		return nil
+	}
+
+	db, err := openLCMDB(m.paths.lcmDBPath, dbReadWrite)
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return nil
+	}
+	defer db.Close()
+
+	// The first accept snapshots the DB and opens the review's journal op in
+	// the same transaction as its write, so a failed write leaves no empty op
+	// behind; later accepts attach to that op.
+	ctx := context.Background()
+	opID, backupName := m.repairOpID, m.repairBackup
+	if opID == 0 {
+		label := fmt.Sprintf("tui-repair-conv%d", m.repairConversationID)
+		backup, err := snapshotLCMDB(ctx, db, m.paths.backupsDir, label)
+		if err != nil {
+			m.status = "Error: backup before repair: " + err.Error()
+			return nil
+		}
+		backupName = backup.name
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return nil
+	}
+	if opID == 0 {
+		j, err := startJournalOp(ctx, tx, "repair", m.repairConversationID, fmt.Sprintf("repair review of conversation %d", m.repairConversationID))
+		if err != nil {
+			_ = tx.Rollback()
+			m.status = "Error: " + err.Error()
+			return nil
+		}
+		opID = j.opID
+	}
+	if err := writeRepairedSummary(ctx, tx, opID, candidate.item.summaryID, candidate.proposed, candidate.proposedTokens); err != nil {
+		_ = tx.Rollback()
+		m.status = "Error: " + err.Error()
+		return nil
+	}
+	if err := tx.Commit(); err != nil {
+		m.status = "Error: commit repair: " + err.Error()
+		return nil
+	}
+	m.repairOpID, m.repairBackup = opID, backupName
+
+	candidate.status = repairAccepted
+	m.status = fmt.Sprintf("Wrote %s (%dt). Undo with: lcm-tui undo %d. Backup: %s",
+		candidate.item.summaryID, candidate.proposedTokens, m.repairOpID, m.repairBackup)
+	if m.repairCursor < len(m.repairCandidates)-1 {
+		m.repairCursor++
+	}
+	return nil
+}
+
+func (m *model) reloadSummariesAfterRepair() tea.Cmd {
+	session, ok := m.currentSession()
+	if !ok {
+		return nil
+	}
+	accepted := 0
+	for _, candidate := range m.repairCandidates {
+		if candidate.status == repairAccepted {
+			accepted++
+		}
+	}
+	note := fmt.Sprintf("Repaired %d of %d summaries (undo op %d)", accepted, len(m.repairCandidates), m.repairOpID)
+	m.status = note
+	ctx, seq := m.beginLoad("Reloading summaries...")
+	return m.startLoad(loadSummaryGraphCmd(ctx, seq, m.db, session.id, loadReload, note))
+}
+
+func (m model) renderRepair() string {
+	if len(m.repairCandidates) == 0 {
+		return "No corrupted summaries"
+	}
+
+	available := max(4, m.height-4)
+	listHeight := min(len(m.repairCandidates), max(3, available/4))
+	detailHeight := max(3, available-listHeight-1)
+
+	offset := listOffset(m.repairCursor, len(m.repairCandidates), listHeight)
+	listLines := make([]string, 0, listHeight)
+	for idx := offset; idx < min(len(m.repairCandidates), offset+listHeight); idx++ {
+		candidate := m.repairCandidates[idx]
+		item := candidate.item
+		kindLabel := item.kind
+		if item.depth > 0 {
+			kindLabel = fmt.Sprintf("d%d", item.depth)
+		}
+		status := candidate.status.String()
+		if candidate.err != "" {
+			status = "error"
+		}
+		line := fmt.Sprintf("%2d. %-9s %s [%s, %dt]", idx+1, "["+status+"]", item.summaryID, kindLabel, item.tokenCount)
+		if idx == m.repairCursor {
+			line = selectedStyle.Render(line)
+		}
+		listLines = append(listLines, line)
+	}
+
+	candidate := m.repairCandidates[clamp(m.repairCursor, 0, len(m.repairCandidates)-1)]
+	columnWidth := max(20, (m.width-3)/2)
+	oldLines := strings.Split(wrapText(candidate.item.content, columnWidth), "\n")
+	newText := candidate.proposed
+	switch {
+	case candidate.err != "":
+		newText = "Error: " + candidate.err
+	case candidate.status == repairPending:
+		newText = "(press g to generate)"
+	}
+	newLines := strings.Split(wrapText(newText, columnWidth), "\n")
+
+	detailLines := []string{
+		padRight(fmt.Sprintf("Old (%dt)", candidate.item.tokenCount), columnWidth) + " | " + fmt.Sprintf("New (%dt)", candidate.proposedTokens),
+	}
+	for i := 0; i < detailHeight-1; i++ {
+		left, right := "", ""
+		if i < len(oldLines) {
+			left = oldLines[i]
+		}
+		if i < len(newLines) {
+			right = newLines[i]
+		}
+		if left == "" && right == "" && i >= max(len(oldLines), len(newLines)) {
+			break
+		}
+		detailLines = append(detailLines, padRight(left, columnWidth)+" | "+right)
+	}
+
+	return strings.Join(listLines, "\n") + "\n" + helpStyle.Render(strings.Repeat("-", max(20, m.width-1))) + "\n" + strings.Join(detailLines, "\n")
+}
+
+// padRight pads s with spaces to width display cells.
+func padRight(s string, width int) string {
+	gap := width - lipgloss.Width(s)
+	if gap <= 0 {
+		return s
+	}
+	return s + strings.Repeat(" ", gap)
+}
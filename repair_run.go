@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	repairRunsTable      = "lcm_tui_repair_runs"
+	repairProgressTable  = "lcm_tui_repair_progress"
+	defaultRepairWorkers = 4
+	defaultRepairRetries = 5
+	repairRetryBaseDelay = 2 * time.Second
+	repairRetryMaxDelay  = 60 * time.Second
+)
+
+// repairRun is the checkpoint for one `repair --apply` invocation. Every
+// repaired summary is committed together with a progress row, so a crashed or
+// partially failed run can be picked up with --resume. All repairs of a run
+// share one journal op and can be undone together.
+type repairRun struct {
+	runID           int64
+	opID            int64
+	conversationIDs []int64
+	summaryID       string
+	summarizer      summarizerConfig
+	detectors       []summaryDetector
+	promptDir       string
+	instructions    string
+	tokenizer       string
+	startedAt       string
+	done            map[string]bool
+}
+
+// repairOutcome counts what happened to the summaries of one plan.
+type repairOutcome struct {
+	repaired int
+	failed   int
+	skipped  int
+}
+
+func ensureRepairRunTables(ctx context.Context, q sqlQueryer) error {
+	if _, err := q.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+repairRunsTable+` (
+			run_id INTEGER PRIMARY KEY AUTOINCREMENT,
+			op_id INTEGER NOT NULL,
+			conversation_ids TEXT NOT NULL,
+			summary_id TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL DEFAULT '',
+			base_url TEXT NOT NULL DEFAULT '',
+			detectors TEXT NOT NULL DEFAULT '`+defaultDetectors+`',
+			prompt_dir TEXT NOT NULL DEFAULT '',
+			instructions TEXT NOT NULL DEFAULT '',
+			tokenizer TEXT NOT NULL DEFAULT '',
+			started_at TEXT NOT NULL DEFAULT (datetime('now')),
+			finished_at TEXT
+		)
+	`); err != nil {
+		return fmt.Errorf("create %s: %w", repairRunsTable, err)
+	}
+	if _, err := q.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+repairProgressTable+` (
+			run_id INTEGER NOT NULL REFERENCES `+repairRunsTable+`(run_id) ON DELETE CASCADE,
+			summary_id TEXT NOT NULL,
+			status TEXT NOT NULL CHECK (status IN ('done', 'failed')),
+			error TEXT,
+			updated_at TEXT NOT NULL DEFAULT (datetime('now')),
+			PRIMARY KEY (run_id, summary_id)
+		)
+	`); err != nil {
+		return fmt.Errorf("create %s: %w", repairProgressTable, err)
+	}
+	return nil
+}
+
+// startRepairRun records a new run and the journal op its repairs attach to.
+func startRepairRun(ctx context.Context, db *sql.DB, conversationIDs []int64, opts repairOptions) (*repairRun, error) {
+	if err := ensureRepairRunTables(ctx, db); err != nil {
+		return nil, err
+	}
+
+	journalConversationID := int64(0)
+	if len(conversationIDs) == 1 {
+		journalConversationID = conversationIDs[0]
+	}
+	description := fmt.Sprintf("repair %d conversation(s)", len(conversationIDs))
+	if opts.summaryID != "" {
+		description = "repair " + opts.summaryID
+	}
+	j, err := startJournalOp(ctx, db, "repair", journalConversationID, description)
+	if err != nil {
+		return nil, err
+	}
+
+	idsJSON, err := json.Marshal(conversationIDs)
+	if err != nil {
+		return nil, fmt.Errorf("encode repair conversation IDs: %w", err)
+	}
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO `+repairRunsTable+` (op_id, conversation_ids, summary_id, provider, model, base_url, detectors, prompt_dir, instructions, tokenizer)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, j.opID, string(idsJSON), opts.summaryID, opts.summarizer.provider, opts.summarizer.model, opts.summarizer.baseURL,
+		strings.Join(detectorNames(opts.detectors), ","), opts.promptDir, opts.instructions, opts.tokenizer)
+	if err != nil {
+		return nil, fmt.Errorf("insert repair run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("read repair run ID: %w", err)
+	}
+	return &repairRun{
+		runID:           runID,
+		opID:            j.opID,
+		conversationIDs: conversationIDs,
+		summaryID:       opts.summaryID,
+		summarizer:      opts.summarizer,
+		detectors:       opts.detectors,
+		promptDir:       opts.promptDir,
+		instructions:    opts.instructions,
+		tokenizer:       opts.tokenizer,
+		done:            map[string]bool{},
+	}, nil
+}
+
+// loadResumableRepairRun returns the newest run that has not finished.
+func loadResumableRepairRun(ctx context.Context, q sqlQueryer) (*repairRun, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type = 'table' AND name IN (?, ?)
+	`, repairRunsTable, repairProgressTable).Scan(&count); err != nil {
+		return nil, fmt.Errorf("check repair run tables: %w", err)
+	}
+	if count != 2 {
+		return nil, errors.New("no interrupted repair run to resume")
+	}
+
+	var (
+		run           repairRun
+		idsJSON       string
+		detectorsSpec string
+	)
+	err := q.QueryRowContext(ctx, `
+		SELECT run_id, op_id, conversation_ids, summary_id, provider, model, base_url, detectors, prompt_dir, instructions, tokenizer, started_at
+		FROM `+repairRunsTable+`
+		WHERE finished_at IS NULL
+		ORDER BY run_id DESC
+		LIMIT 1
+	`).Scan(&run.runID, &run.opID, &idsJSON, &run.summaryID, &run.summarizer.provider, &run.summarizer.model, &run.summarizer.baseURL,
+		&detectorsSpec, &run.promptDir, &run.instructions, &run.tokenizer, &run.startedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("no interrupted repair run to resume")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query interrupted repair run: %w", err)
+	}
+	if err := json.Unmarshal([]byte(idsJSON), &run.conversationIDs); err != nil {
+		return nil, fmt.Errorf("decode conversation IDs for repair run %d: %w", run.runID, err)
+	}
+	if run.detectors, err = parseDetectors(detectorsSpec); err != nil {
+		return nil, fmt.Errorf("detectors for repair run %d: %w", run.runID, err)
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT summary_id
+		FROM `+repairProgressTable+`
+		WHERE run_id = ? AND status = 'done'
+	`, run.runID)
+	if err != nil {
+		return nil, fmt.Errorf("query progress for repair run %d: %w", run.runID, err)
+	}
+	defer rows.Close()
+
+	run.done = map[string]bool{}
+	for rows.Next() {
+		var summaryID string
+		if err := rows.Scan(&summaryID); err != nil {
+			return nil, fmt.Errorf("scan progress for repair run %d: %w", run.runID, err)
+		}
+		run.done[summaryID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate progress for repair run %d: %w", run.runID, err)
+	}
+	return &run, nil
+}
+
+// markDone and markFailed are no-ops for runs without a checkpoint row, such
+// as resummarize runs, which only journal their changes.
+func (r *repairRun) markDone(ctx context.Context, q sqlQueryer, summaryID string) error {
+	if r.runID == 0 {
+		return nil
+	}
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO `+repairProgressTable+` (run_id, summary_id, status, error, updated_at)
+		VALUES (?, ?, 'done', NULL, datetime('now'))
+		ON CONFLICT (run_id, summary_id) DO UPDATE SET status = 'done', error = NULL, updated_at = excluded.updated_at
+	`, r.runID, summaryID); err != nil {
+		return fmt.Errorf("checkpoint %s: %w", summaryID, err)
+	}
+	return nil
+}
+
+func (r *repairRun) markFailed(ctx context.Context, q sqlQueryer, summaryID string, cause error) error {
+	if r.runID == 0 {
+		return nil
+	}
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO `+repairProgressTable+` (run_id, summary_id, status, error, updated_at)
+		VALUES (?, ?, 'failed', ?, datetime('now'))
+		ON CONFLICT (run_id, summary_id) DO UPDATE SET status = 'failed', error = excluded.error, updated_at = excluded.updated_at
+	`, r.runID, summaryID, cause.Error()); err != nil {
+		return fmt.Errorf("checkpoint failure of %s: %w", summaryID, err)
+	}
+	return nil
+}
+
+func (r *repairRun) finish(ctx context.Context, q sqlQueryer) error {
+	if _, err := q.ExecContext(ctx, `
+		UPDATE `+repairRunsTable+` SET finished_at = datetime('now') WHERE run_id = ?
+	`, r.runID); err != nil {
+		return fmt.Errorf("finish repair run %d: %w", r.runID, err)
+	}
+	return nil
+}
+
+// skipDone drops summaries an earlier attempt of this run already repaired.
+func (r *repairRun) skipDone(plan repairPlan) repairPlan {
+	if r == nil || len(r.done) == 0 {
+		return plan
+	}
+	keep := func(items []repairSummary) []repairSummary {
+		kept := make([]repairSummary, 0, len(items))
+		for _, item := range items {
+			if !r.done[item.summaryID] {
+				kept = append(kept, item)
+			}
+		}
+		return kept
+	}
+	plan.summaries = keep(plan.summaries)
+	plan.ordered = keep(plan.ordered)
+	return plan
+}
+
+// buildRepairDependencies maps each planned summary to the planned summaries
+// whose repaired content it reads: a leaf reads the preceding leaf in the
+// context window as previous_context, and a condensed summary reads its child
+// summaries as source plus the previous summary at its depth. Summaries with
+// no planned dependencies can be repaired in parallel.
+func buildRepairDependencies(ctx context.Context, q sqlQueryer, plan repairPlan) (map[string][]string, error) {
+	planned := make(map[string]bool, len(plan.ordered))
+	for _, item := range plan.ordered {
+		planned[item.summaryID] = true
+	}
+
+	deps := make(map[string][]string, len(plan.ordered))
+	add := func(summaryID, dependsOn string) {
+		if dependsOn != "" && dependsOn != summaryID && planned[dependsOn] {
+			deps[summaryID] = append(deps[summaryID], dependsOn)
+		}
+	}
+
+	for _, item := range plan.ordered {
+		if item.depth == 0 || strings.EqualFold(item.kind, "leaf") {
+			if !item.hasContextOrdinal {
+				continue
+			}
+			previous := ""
+			for _, leaf := range plan.leafSequence {
+				if leaf.ordinal >= item.contextOrdinal {
+					break
+				}
+				previous = leaf.summaryID
+			}
+			add(item.summaryID, previous)
+			continue
+		}
+
+		rows, err := q.QueryContext(ctx, `
+			SELECT parent_summary_id
+			FROM summary_parents
+			WHERE summary_id = ?
+		`, item.summaryID)
+		if err != nil {
+			return nil, fmt.Errorf("query child summaries for %s: %w", item.summaryID, err)
+		}
+		for rows.Next() {
+			var childID string
+			if err := rows.Scan(&childID); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan child summary for %s: %w", item.summaryID, err)
+			}
+			add(item.summaryID, childID)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("iterate child summaries for %s: %w", item.summaryID, err)
+		}
+		rows.Close()
+
+		var previous string
+		err = q.QueryRowContext(ctx, `
+			SELECT summary_id
+			FROM summaries
+			WHERE conversation_id = ?
+			  AND depth = ?
+			  AND (created_at < ? OR (created_at = ? AND summary_id < ?))
+			ORDER BY created_at DESC, summary_id DESC
+			LIMIT 1
+		`, item.conversationID, item.depth, item.createdAt, item.createdAt, item.summaryID).Scan(&previous)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("query previous condensed summary for %s: %w", item.summaryID, err)
+		}
+		add(item.summaryID, previous)
+	}
+	return deps, nil
+}
+
+type repairResult struct {
+	item repairSummary
+	log  string
+	err  error
+}
+
+// scheduleRepairs runs work for every summary with at most workers in flight,
+// starting a summary only after all of its dependencies succeeded. When a
+// summary fails, everything that depends on it is skipped. Results print in
+// completion order as whole blocks.
+func scheduleRepairs(ctx context.Context, ordered []repairSummary, deps map[string][]string, workers int, work func(repairSummary) (string, error)) repairOutcome {
+	if workers < 1 {
+		workers = 1
+	}
+
+	waiting := make(map[string]int, len(ordered))
+	dependents := make(map[string][]string, len(ordered))
+	byID := make(map[string]repairSummary, len(ordered))
+	var ready []repairSummary
+	for _, item := range ordered {
+		byID[item.summaryID] = item
+		waiting[item.summaryID] = len(deps[item.summaryID])
+		for _, dep := range deps[item.summaryID] {
+			dependents[dep] = append(dependents[dep], item.summaryID)
+		}
+		if len(deps[item.summaryID]) == 0 {
+			ready = append(ready, item)
+		}
+	}
+
+	results := make(chan repairResult)
+	var wg sync.WaitGroup
+	var outcome repairOutcome
+	running, finished := 0, 0
+	skipped := make(map[string]bool)
+
+	var skip func(summaryID string)
+	skip = func(summaryID string) {
+		for _, dependent := range dependents[summaryID] {
+			if skipped[dependent] {
+				continue
+			}
+			skipped[dependent] = true
+			outcome.skipped++
+			fmt.Printf("Skipping %s: depends on failed %s\n\n", dependent, summaryID)
+			skip(dependent)
+		}
+	}
+
+	for {
+		for running < workers && len(ready) > 0 && ctx.Err() == nil {
+			item := ready[0]
+			ready = ready[1:]
+			running++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				log, err := work(item)
+				results <- repairResult{item: item, log: log, err: err}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+		finished++
+		item := result.item
+		fmt.Printf("[%d/%d] %s (%s, d%d)\n%s", finished, len(ordered), item.summaryID, item.kind, item.depth, result.log)
+		if result.err != nil {
+			fmt.Printf("  Failed: %v\n\n", result.err)
+			outcome.failed++
+			skip(item.summaryID)
+			continue
+		}
+		fmt.Println()
+		outcome.repaired++
+		for _, dependent := range dependents[item.summaryID] {
+			waiting[dependent]--
+			if waiting[dependent] == 0 && !skipped[dependent] {
+				ready = append(ready, byID[dependent])
+			}
+		}
+	}
+	wg.Wait()
+	return outcome
+}
+
+// summarizeWithRetry calls the summarizer, retrying rate limits, server
+// errors and network timeouts with exponential backoff and jitter.
+func summarizeWithRetry(ctx context.Context, client summarizer, req summaryRequest, maxRetries int, logf func(string, ...any)) (string, error) {
+	for attempt := 0; ; attempt++ {
+		content, err := client.summarize(ctx, req)
+		if err == nil || attempt >= maxRetries || !retryableSummarizerError(err) {
+			return content, err
+		}
+
+		delay := repairRetryDelay(attempt)
+		var apiErr *summarizerAPIError
+		if errors.As(err, &apiErr) && apiErr.retryAfter > delay {
+			delay = apiErr.retryAfter
+		}
+		logf("  Retry %d/%d in %s: %v\n", attempt+1, maxRetries, delay.Round(100*time.Millisecond), err)
+
+		if err := repairRetryWait(ctx, delay); err != nil {
+			return "", err
+		}
+	}
+}
+
+// repairRetryDelay is the backoff after failed attempt: the base delay doubled
+// per attempt up to repairRetryMaxDelay, plus up to 25% jitter. Attempts past
+// the cap skip the shift, which would otherwise overflow.
+func repairRetryDelay(attempt int) time.Duration {
+	delay := repairRetryMaxDelay
+	if attempt < 8 {
+		if shifted := repairRetryBaseDelay << attempt; shifted < delay {
+			delay = shifted
+		}
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/4))
+}
+
+// repairRetryWait sleeps for delay unless ctx ends first. Tests replace it to
+// retry without sleeping.
+var repairRetryWait = func(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryableSummarizerError(err error) bool {
+	var apiErr *summarizerAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.status == 429 || apiErr.status >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSummarizeWithRetryPastBackoffCap(t *testing.T) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var delays []time.Duration
+	wait := repairRetryWait
+	repairRetryWait = func(_ context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+	t.Cleanup(func() { repairRetryWait = wait })
+
+	client := &openAIClient{apiKey: "test", model: "test", baseURL: server.URL, http: server.Client()}
+	const maxRetries = 70
+	_, err := summarizeWithRetry(context.Background(), client, summaryRequest{prompt: "prompt", targetTokens: 100}, maxRetries, func(string, ...any) {})
+
+	var apiErr *summarizerAPIError
+	if !errors.As(err, &apiErr) || apiErr.status != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want the final 503", err)
+	}
+	if got := hits.Load(); got != maxRetries+1 {
+		t.Fatalf("server saw %d requests, want %d", got, maxRetries+1)
+	}
+	if len(delays) != maxRetries {
+		t.Fatalf("waited %d times, want %d", len(delays), maxRetries)
+	}
+	for attempt, delay := range delays {
+		if delay < repairRetryBaseDelay || delay > repairRetryMaxDelay*5/4 {
+			t.Fatalf("delay after attempt %d = %s, want within [%s, %s]", attempt, delay, repairRetryBaseDelay, repairRetryMaxDelay*5/4)
+		}
+	}
+	if last := delays[len(delays)-1]; last < repairRetryMaxDelay {
+		t.Fatalf("delay after attempt %d = %s, want the %s cap", len(delays)-1, last, repairRetryMaxDelay)
+	}
+}
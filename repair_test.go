@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// repairTestSchema is the subset of the LCM schema the repair path reads and
+// writes.
+const repairTestSchema = `
+CREATE TABLE conversations (
+	conversation_id INTEGER PRIMARY KEY,
+	session_id TEXT NOT NULL,
+	created_at TEXT NOT NULL DEFAULT (datetime('now')),
+	updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+CREATE TABLE messages (
+	message_id INTEGER PRIMARY KEY,
+	conversation_id INTEGER NOT NULL REFERENCES conversations(conversation_id),
+	seq INTEGER NOT NULL,
+	role TEXT NOT NULL,
+	content TEXT NOT NULL,
+	token_count INTEGER NOT NULL,
+	created_at TEXT NOT NULL DEFAULT (datetime('now')),
+	UNIQUE (conversation_id, seq)
+);
+CREATE TABLE message_parts (
+	part_id TEXT PRIMARY KEY,
+	message_id INTEGER NOT NULL REFERENCES messages(message_id) ON DELETE CASCADE,
+	session_id TEXT NOT NULL,
+	part_type TEXT NOT NULL,
+	ordinal INTEGER NOT NULL,
+	text_content TEXT,
+	is_ignored INTEGER
+);
+CREATE TABLE summaries (
+	summary_id TEXT PRIMARY KEY,
+	conversation_id INTEGER NOT NULL REFERENCES conversations(conversation_id),
+	kind TEXT NOT NULL,
+	depth INTEGER NOT NULL DEFAULT 0,
+	content TEXT NOT NULL,
+	token_count INTEGER NOT NULL,
+	created_at TEXT NOT NULL DEFAULT (datetime('now')),
+	file_ids TEXT NOT NULL DEFAULT '[]'
+);
+CREATE TABLE summary_messages (
+	summary_id TEXT NOT NULL REFERENCES summaries(summary_id) ON DELETE CASCADE,
+	message_id INTEGER NOT NULL REFERENCES messages(message_id),
+	ordinal INTEGER NOT NULL,
+	PRIMARY KEY (summary_id, message_id)
+);
+CREATE TABLE summary_parents (
+	summary_id TEXT NOT NULL REFERENCES summaries(summary_id) ON DELETE CASCADE,
+	parent_summary_id TEXT NOT NULL REFERENCES summaries(summary_id),
+	ordinal INTEGER NOT NULL,
+	PRIMARY KEY (summary_id, parent_summary_id)
+);
+CREATE TABLE context_items (
+	conversation_id INTEGER NOT NULL REFERENCES conversations(conversation_id) ON DELETE CASCADE,
+	ordinal INTEGER NOT NULL,
+	item_type TEXT NOT NULL,
+	message_id INTEGER REFERENCES messages(message_id),
+	summary_id TEXT REFERENCES summaries(summary_id),
+	created_at TEXT NOT NULL DEFAULT (datetime('now')),
+	PRIMARY KEY (conversation_id, ordinal)
+);
+`
+
+// newRepairTestDB writes an lcm.db with one conversation whose two leaves and
+// the condensed summary above them all carry the fallback marker. Each leaf
+// covers one message made of a single line of two-byte runes longer than the
+// extractive summarizer's budget, offset by one byte in the second message
+// so one of the two cuts lands inside a rune whatever the budget.
+func newRepairTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lcm.db")
+	create, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := create.Exec(repairTestSchema); err != nil {
+		t.Fatal(err)
+	}
+	if err := create.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := openLCMDB(path, dbReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	long := strings.Repeat("é", condensedTargetTokens*4)
+	garbage := corruptedSummaryMarker + " garbage"
+	stmts := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO conversations (conversation_id, session_id) VALUES (1, 'sess-1')`, nil},
+		{`INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count) VALUES (1, 1, 0, 'user', ?, 1)`, []any{long}},
+		{`INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count) VALUES (2, 1, 1, 'assistant', ?, 1)`, []any{"x" + long}},
+		{`INSERT INTO summaries (summary_id, conversation_id, kind, depth, content, token_count, created_at) VALUES ('sum_a', 1, 'leaf', 0, ?, 1, '2026-01-01 00:01:00')`, []any{garbage}},
+		{`INSERT INTO summaries (summary_id, conversation_id, kind, depth, content, token_count, created_at) VALUES ('sum_b', 1, 'leaf', 0, ?, 1, '2026-01-01 00:02:00')`, []any{garbage}},
+		{`INSERT INTO summaries (summary_id, conversation_id, kind, depth, content, token_count, created_at) VALUES ('sum_c', 1, 'condensed', 1, ?, 1, '2026-01-01 00:03:00')`, []any{garbage}},
+		{`INSERT INTO summary_messages VALUES ('sum_a', 1, 0), ('sum_b', 2, 0)`, nil},
+		{`INSERT INTO summary_parents VALUES ('sum_c', 'sum_a', 0), ('sum_c', 'sum_b', 1)`, nil},
+		{`INSERT INTO context_items (conversation_id, ordinal, item_type, summary_id) VALUES (1, 0, 'summary', 'sum_c')`, nil},
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt.query, stmt.args...); err != nil {
+			t.Fatalf("%s: %v", stmt.query, err)
+		}
+	}
+	return db
+}
+
+func newRepairTestOptions(t *testing.T) repairOptions {
+	t.Helper()
+	detectors, err := parseDetectors(defaultDetectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompts, err := loadRepairPrompts("", false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repairOptions{
+		apply:       true,
+		summarizer:  summarizerConfig{provider: providerExtractive},
+		concurrency: 2,
+		maxRetries:  1,
+		detectors:   detectors,
+		prompts:     prompts,
+		tokenizer:   tokenizerHeuristic,
+		tokens:      heuristicCounter{},
+	}
+}
+
+func TestApplyRepairsExtractive(t *testing.T) {
+	ctx := context.Background()
+	db := newRepairTestDB(t)
+	opts := newRepairTestOptions(t)
+	client, err := newSummarizer(opts.summarizer, appDataPaths{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := buildRepairPlan(ctx, db, 1, "", opts.detectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.ordered) != 3 {
+		t.Fatalf("plan has %d summaries, want 3", len(plan.ordered))
+	}
+	run, err := startRepairRun(ctx, db, []int64{1}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome, err := applyRepairs(ctx, db, plan, opts, client, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.repaired != 3 || outcome.failed != 0 || outcome.skipped != 0 {
+		t.Fatalf("outcome = %+v, want 3 repaired", outcome)
+	}
+
+	rows, err := db.Query(`SELECT summary_id, content, token_count FROM summaries ORDER BY summary_id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, content string
+		var tokens int
+		if err := rows.Scan(&id, &content, &tokens); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(content, corruptedSummaryMarker) {
+			t.Errorf("%s still carries the fallback marker", id)
+		}
+		if !utf8.ValidString(content) {
+			t.Errorf("%s content is not valid UTF-8", id)
+		}
+		if want := (heuristicCounter{}).countTokens(content); tokens != want {
+			t.Errorf("%s token_count = %d, want %d", id, tokens, want)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var updates int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM `+journalEntriesTable+`
+		WHERE op_id = ? AND table_name = 'summaries' AND action = ?
+	`, run.opID, journalActionUpdate).Scan(&updates); err != nil {
+		t.Fatal(err)
+	}
+	if updates != 3 {
+		t.Fatalf("journal has %d summary updates for op %d, want 3", updates, run.opID)
+	}
+
+	var done int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM `+repairProgressTable+`
+		WHERE run_id = ? AND status = 'done'
+	`, run.runID).Scan(&done); err != nil {
+		t.Fatal(err)
+	}
+	if done != 3 {
+		t.Fatalf("run %d has %d done checkpoints, want 3", run.runID, done)
+	}
+
+	resumed, err := loadResumableRepairRun(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.runID != run.runID || len(resumed.done) != 3 {
+		t.Fatalf("resumable run = %d with %d done, want %d with 3", resumed.runID, len(resumed.done), run.runID)
+	}
+	if left := resumed.skipDone(plan); len(left.ordered) != 0 {
+		t.Fatalf("resume would repair %d summaries again", len(left.ordered))
+	}
+	if err := run.finish(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadResumableRepairRun(ctx, db); err == nil {
+		t.Fatal("finished run is still resumable")
+	}
+
+	if _, err := undoJournalOp(ctx, db, run.opID); err != nil {
+		t.Fatal(err)
+	}
+	var marked int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM summaries WHERE content LIKE ?`, corruptedSummaryMarker+"%").Scan(&marked); err != nil {
+		t.Fatal(err)
+	}
+	if marked != 3 {
+		t.Fatalf("undo restored %d marked summaries, want 3", marked)
+	}
+}
+
+func TestExtractiveSummarizerCutsOnRuneBoundary(t *testing.T) {
+	for _, prefix := range []string{"", "x"} {
+		line := prefix + strings.Repeat("é", 100)
+		got, err := extractiveSummarizer{}.summarize(context.Background(), summaryRequest{kind: "leaf", text: line, targetTokens: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !utf8.ValidString(got) {
+			t.Fatalf("summary of %q-prefixed line is not valid UTF-8: %q", prefix, got)
+		}
+	}
+}
+
+func TestExtractiveSummarizerIgnoresCustomTemplates(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{leafPromptTemplate, condensedPromptTemplate} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("Summarize this:\n{{.Text}}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prompts, err := loadRepairPrompts(dir, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range []string{"leaf", "condensed"} {
+		req, err := prompts.build(kind, "deploy the pipeline\npromote to production", "", 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := extractiveSummarizer{}.summarize(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if !strings.Contains(got, "deploy the pipeline") || strings.Contains(got, "Summarize this") {
+			t.Fatalf("%s summary = %q, want the source lines without the template text", kind, got)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type resummarizeOptions struct {
+	apply        bool
+	summaryID    string
+	depth        int // -1 when --depth is not given
+	before       string
+	cascade      bool
+	verbose      bool
+	summarizer   summarizerConfig
+	concurrency  int
+	maxRetries   int
+	promptDir    string
+	instructions string
+	tokenizer    string
+}
+
+// resummarizeTimeLayouts are the accepted --before formats. Dates without a
+// zone are taken as UTC, matching the summaries.created_at column.
+var resummarizeTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// runResummarizeCommand regenerates selected summaries, healthy or not, using
+// the same ordering, sources and previous context as repair.
+func runResummarizeCommand(args []string) error {
+	opts, conversationID, err := parseResummarizeArgs(args)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.apply))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	plan, cascaded, err := buildResummarizePlan(ctx, db, conversationID, opts)
+	if err != nil {
+		return err
+	}
+	if len(plan.ordered) == 0 {
+		fmt.Printf("No summaries in conversation %d match the selection.\n", conversationID)
+		return nil
+	}
+
+	if !opts.apply {
+		printResummarizePlan(plan, cascaded)
+		return nil
+	}
+
+	client, err := newSummarizer(opts.summarizer, paths)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Summarizer: %s (%d workers, %d retries)\n", client.describe(), opts.concurrency, opts.maxRetries)
+	promptDir, explicit := resolvePromptDir(opts.promptDir, paths)
+	prompts, err := loadRepairPrompts(promptDir, explicit, opts.instructions)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Prompts: %s\n", prompts.describe())
+	tokens, err := newTokenCounter(opts.tokenizer, paths)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Tokenizer: %s\n", tokens.describe())
+	if err := backupBeforeApply(ctx, db, paths, fmt.Sprintf("resummarize-conv%d", conversationID)); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	description := fmt.Sprintf("resummarize %d summaries", len(plan.ordered))
+	if opts.summaryID != "" && len(plan.ordered) == 1 {
+		description = "resummarize " + opts.summaryID
+	}
+	j, err := startJournalOp(ctx, db, "resummarize", conversationID, description)
+	if err != nil {
+		return err
+	}
+	run := &repairRun{opID: j.opID, conversationIDs: []int64{conversationID}, summarizer: opts.summarizer}
+
+	fmt.Printf("Resummarizing conversation %d...\n\n", conversationID)
+	outcome, err := applyRepairs(ctx, db, plan, repairOptions{
+		apply:       true,
+		verbose:     opts.verbose,
+		summarizer:  opts.summarizer,
+		concurrency: opts.concurrency,
+		maxRetries:  opts.maxRetries,
+		prompts:     prompts,
+		tokens:      tokens,
+	}, client, run)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Done. %d summaries regenerated. Changes take effect on next conversation turn.\n", outcome.repaired)
+	if outcome.repaired > 0 {
+		fmt.Printf("Undo with: lcm-tui undo %d --apply\n", j.opID)
+	}
+	if outcome.failed > 0 {
+		return fmt.Errorf("%d summaries failed and %d were skipped", outcome.failed, outcome.skipped)
+	}
+	return nil
+}
+
+func parseResummarizeArgs(args []string) (resummarizeOptions, int64, error) {
+	fs := flag.NewFlagSet("resummarize", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	apply := fs.Bool("apply", false, "regenerate the selected summaries")
+	_ = fs.Bool("dry-run", true, "show what would be regenerated")
+	summaryID := fs.String("summary-id", "", "select one summary ID")
+	depth := fs.Int("depth", -1, "select summaries at this depth")
+	before := fs.String("before", "", "select summaries created before this date")
+	cascade := fs.Bool("cascade", false, "also regenerate every condensed ancestor")
+	verbose := fs.Bool("verbose", false, "include old content hash and preview")
+	concurrency := fs.Int("concurrency", defaultRepairWorkers, "summaries to regenerate in parallel")
+	maxRetries := fs.Int("max-retries", defaultRepairRetries, "retries per summary on 429/5xx/timeouts")
+	provider := fs.String("provider", providerAnthropic, "summarizer provider: anthropic, openai, or extractive")
+	model := fs.String("model", "", "model name (default depends on provider)")
+	baseURL := fs.String("base-url", "", "API base URL (default depends on provider)")
+	promptDir := fs.String("prompt-dir", "", "directory with leaf.tmpl/condensed.tmpl prompt overrides")
+	instructions := fs.String("instructions", "", "operator instructions added to every prompt")
+	tokenizer := fs.String("tokenizer", defaultTokenizer, "tokenizer for token_count: heuristic, an encoding, or a model name")
+
+	normalizedArgs, err := normalizeResummarizeArgs(args)
+	if err != nil {
+		return resummarizeOptions{}, 0, fmt.Errorf("%w\n%s", err, resummarizeUsageText())
+	}
+	if err := fs.Parse(normalizedArgs); err != nil {
+		return resummarizeOptions{}, 0, fmt.Errorf("%w\n%s", err, resummarizeUsageText())
+	}
+	if fs.NArg() != 1 {
+		return resummarizeOptions{}, 0, fmt.Errorf("conversation ID is required\n%s", resummarizeUsageText())
+	}
+	conversationID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return resummarizeOptions{}, 0, fmt.Errorf("parse conversation ID %q: %w", fs.Arg(0), err)
+	}
+
+	depthSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "depth" {
+			depthSet = true
+		}
+	})
+	if depthSet && *depth < 0 {
+		return resummarizeOptions{}, 0, fmt.Errorf("--depth must not be negative\n%s", resummarizeUsageText())
+	}
+	if strings.TrimSpace(*summaryID) == "" && !depthSet && strings.TrimSpace(*before) == "" {
+		return resummarizeOptions{}, 0, fmt.Errorf("select summaries with --summary-id, --depth, or --before\n%s", resummarizeUsageText())
+	}
+	if *concurrency < 1 {
+		return resummarizeOptions{}, 0, fmt.Errorf("--concurrency must be at least 1\n%s", resummarizeUsageText())
+	}
+	if *maxRetries < 0 {
+		return resummarizeOptions{}, 0, fmt.Errorf("--max-retries must not be negative\n%s", resummarizeUsageText())
+	}
+
+	beforeValue := ""
+	if strings.TrimSpace(*before) != "" {
+		beforeValue, err = parseResummarizeBefore(*before)
+		if err != nil {
+			return resummarizeOptions{}, 0, fmt.Errorf("%w\n%s", err, resummarizeUsageText())
+		}
+	}
+	summarizerConfig, err := parseSummarizerConfig(*provider, *model, *baseURL)
+	if err != nil {
+		return resummarizeOptions{}, 0, fmt.Errorf("%w\n%s", err, resummarizeUsageText())
+	}
+	promptDirValue, err := absPromptDir(*promptDir)
+	if err != nil {
+		return resummarizeOptions{}, 0, err
+	}
+
+	return resummarizeOptions{
+		apply:        *apply,
+		summaryID:    strings.TrimSpace(*summaryID),
+		depth:        *depth,
+		before:       beforeValue,
+		cascade:      *cascade,
+		verbose:      *verbose,
+		summarizer:   summarizerConfig,
+		concurrency:  *concurrency,
+		maxRetries:   *maxRetries,
+		promptDir:    promptDirValue,
+		instructions: strings.TrimSpace(*instructions),
+		tokenizer:    strings.TrimSpace(*tokenizer),
+	}, conversationID, nil
+}
+
+func normalizeResummarizeArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, 1)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--apply" || arg == "--dry-run" || arg == "--cascade" || arg == "--verbose":
+			flags = append(flags, arg)
+		case arg == "--summary-id" || arg == "--depth" || arg == "--before" || arg == "--provider" ||
+			arg == "--model" || arg == "--base-url" || arg == "--concurrency" || arg == "--max-retries" ||
+			arg == "--prompt-dir" || arg == "--instructions" || arg == "--tokenizer":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"):
+			flags = append(flags, arg)
+		default:
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func resummarizeUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui resummarize <conversation_id> --summary-id <id> [--cascade] [--apply]
+  lcm-tui resummarize <conversation_id> --depth <n> [--before <date>] [--cascade] [--apply]
+  lcm-tui resummarize <conversation_id> --before <date> [--cascade] [--apply]
+
+Selectors combine: --depth 0 --before 2026-01-01 picks leaves created before
+that date. --before accepts YYYY-MM-DD, "YYYY-MM-DD HH:MM[:SS]" (UTC) or
+RFC 3339. --cascade also regenerates every condensed summary above the
+selection, so higher levels reflect the new content.
+
+Without --apply the command only prints the selection and regeneration order.
+Regeneration reuses repair's ordering, sources and previous context, and
+accepts the same summarizer and prompt flags: --provider, --model,
+--base-url, --concurrency, --max-retries, --prompt-dir, --instructions,
+--tokenizer.
+`)
+}
+
+// parseResummarizeBefore normalizes a --before value to SQLite's datetime
+// format in UTC.
+func parseResummarizeBefore(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range resummarizeTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed.UTC().Format("2006-01-02 15:04:05"), nil
+		}
+	}
+	return "", fmt.Errorf("parse --before %q: want YYYY-MM-DD, YYYY-MM-DD HH:MM:SS, or RFC 3339", value)
+}
+
+// buildResummarizePlan selects summaries matching every given selector and,
+// with --cascade, their condensed ancestors, then orders them the way repair
+// does. cascaded holds the IDs added only as ancestors.
+func buildResummarizePlan(ctx context.Context, q sqlQueryer, conversationID int64, opts resummarizeOptions) (repairPlan, map[string]bool, error) {
+	var (
+		filter string
+		args   []any
+	)
+	if opts.summaryID != "" {
+		filter += " AND s.summary_id = ?"
+		args = append(args, opts.summaryID)
+	}
+	if opts.depth >= 0 {
+		filter += " AND s.depth = ?"
+		args = append(args, opts.depth)
+	}
+	if opts.before != "" {
+		filter += " AND julianday(s.created_at) < julianday(?)"
+		args = append(args, opts.before)
+	}
+	selected, err := loadRepairSummaries(ctx, q, conversationID, filter, args...)
+	if err != nil {
+		return repairPlan{}, nil, err
+	}
+
+	cascaded := map[string]bool{}
+	if opts.cascade && len(selected) > 0 {
+		ancestors, err := loadSummaryAncestors(ctx, q, conversationID, selected)
+		if err != nil {
+			return repairPlan{}, nil, err
+		}
+		for _, item := range ancestors {
+			cascaded[item.summaryID] = true
+		}
+		selected = append(selected, ancestors...)
+	}
+
+	plan, err := orderRepairPlan(ctx, q, conversationID, selected)
+	if err != nil {
+		return repairPlan{}, nil, err
+	}
+	return plan, cascaded, nil
+}
+
+// loadSummaryAncestors walks summary_parents upward from selected and returns
+// every condensed summary in the conversation that covers one of them,
+// excluding the selection itself.
+func loadSummaryAncestors(ctx context.Context, q sqlQueryer, conversationID int64, selected []repairSummary) ([]repairSummary, error) {
+	seen := make(map[string]bool, len(selected))
+	frontier := make([]string, 0, len(selected))
+	for _, item := range selected {
+		seen[item.summaryID] = true
+		frontier = append(frontier, item.summaryID)
+	}
+
+	var ancestorIDs []string
+	for len(frontier) > 0 {
+		childID := frontier[0]
+		frontier = frontier[1:]
+
+		rows, err := q.QueryContext(ctx, `
+			SELECT sp.summary_id
+			FROM summary_parents sp
+			JOIN summaries s ON s.summary_id = sp.summary_id
+			WHERE sp.parent_summary_id = ? AND s.conversation_id = ?
+		`, childID, conversationID)
+		if err != nil {
+			return nil, fmt.Errorf("query ancestors of %s: %w", childID, err)
+		}
+		var found []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan ancestor of %s: %w", childID, err)
+			}
+			found = append(found, id)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("iterate ancestors of %s: %w", childID, err)
+		}
+		rows.Close()
+
+		for _, id := range found {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			ancestorIDs = append(ancestorIDs, id)
+			frontier = append(frontier, id)
+		}
+	}
+	if len(ancestorIDs) == 0 {
+		return nil, nil
+	}
+
+	sort.Strings(ancestorIDs)
+	args := make([]any, len(ancestorIDs))
+	for i, id := range ancestorIDs {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ancestorIDs)), ", ")
+	return loadRepairSummaries(ctx, q, conversationID, " AND s.summary_id IN ("+placeholders+")", args...)
+}
+
+func printResummarizePlan(plan repairPlan, cascaded map[string]bool) {
+	selectedCount := len(plan.ordered) - len(cascaded)
+	fmt.Printf("Selected %d summaries", selectedCount)
+	if len(cascaded) > 0 {
+		fmt.Printf(" plus %d ancestors (--cascade)", len(cascaded))
+	}
+	fmt.Println()
+	fmt.Println()
+	fmt.Println("Regeneration order (bottom-up):")
+	for i, item := range plan.ordered {
+		line := fmt.Sprintf("  %d. %s  %-9s d%d  %dt  created %s", i+1, item.summaryID, item.kind, item.depth, item.tokenCount, item.createdAt)
+		if cascaded[item.summaryID] {
+			line += "  [ancestor]"
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+	fmt.Println("Run with --apply to regenerate.")
+}
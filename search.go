@@ -0,0 +1,965 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	searchIndexTable      = "lcm_tui_search"
+	searchIndexMarksTable = "lcm_tui_search_marks"
+	searchDefaultLimit    = 50
+	searchSnippetRadius   = 60
+	searchMatchStart      = "\uE000"
+	searchMatchEnd        = "\uE001"
+)
+
+type searchOptions struct {
+	conversationID int64
+	limit          int
+	buildIndex     bool
+}
+
+// searchSources maps each searchable source to the table, ID expression, and
+// text column it reads. Rows are tracked by rowid for the index's high-water
+// marks.
+var searchSources = []struct {
+	source string
+	table  string
+	refID  string
+	column string
+}{
+	{"summary", "summaries", "summary_id", "content"},
+	{"message", "messages", "CAST(message_id AS TEXT)", "content"},
+	{"file", "large_files", "file_id", "exploration_summary"},
+}
+
+// searchResult is the hit list plus how many rows were newer than the FTS5
+// index and had to be substring-scanned instead.
+type searchResult struct {
+	hits      []searchHit
+	unindexed int
+}
+
+// searchHit is one match from summaries, messages, or large-file explorations.
+type searchHit struct {
+	source         string // "summary", "message", or "file"
+	conversationID int64
+	sessionID      string
+	summaryID      string // set when source == "summary"
+	messageID      int64  // set when source == "message"
+	fileID         string // set when source == "file"
+	kind           string // summary kind, message role, or file name
+	depth          int
+	snippet        string // single-line excerpt with match markers
+}
+
+// refLabel returns the summary/message/file identifier for list display.
+func (h searchHit) refLabel() string {
+	switch h.source {
+	case "summary":
+		return h.summaryID
+	case "message":
+		return fmt.Sprintf("msg #%d", h.messageID)
+	default:
+		return h.fileID
+	}
+}
+
+// kindLabel mirrors the kind/depth labels used by the summary and context lists.
+func (h searchHit) kindLabel() string {
+	switch h.source {
+	case "summary":
+		if h.kind == "condensed" {
+			return fmt.Sprintf("d%d", h.depth)
+		}
+		return h.kind
+	case "message":
+		return h.kind
+	default:
+		return "file"
+	}
+}
+
+// runSearchCommand executes the standalone search CLI path.
+func runSearchCommand(args []string) error {
+	opts, query, err := parseSearchArgs(args)
+	if err != nil {
+		return err
+	}
+
+	paths, err := resolveDataPaths()
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(paths.lcmDBPath, accessFor(opts.buildIndex))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if opts.buildIndex {
+		count, err := buildSearchIndex(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Indexed %d rows into %s.\n", count, searchIndexTable)
+		if query == "" {
+			return nil
+		}
+		fmt.Println()
+	}
+
+	result, err := searchLCM(ctx, db, query, opts)
+	if err != nil {
+		return err
+	}
+	if result.unindexed > 0 {
+		fmt.Printf("Note: %d rows are newer than the search index and were substring-scanned;\n", result.unindexed)
+		fmt.Println("edits to older rows are not seen until the index is rebuilt with --build-index.")
+		fmt.Println()
+	}
+	if len(result.hits) == 0 {
+		fmt.Printf("No matches for %q.\n", query)
+		return nil
+	}
+
+	fmt.Printf("Found %d matches for %q:\n", len(result.hits), query)
+	for _, hit := range result.hits {
+		fmt.Printf("  conv %-5d %-8s %-22s %-9s %s\n",
+			hit.conversationID, hit.source, hit.refLabel(), hit.kindLabel(), highlightSnippet(hit.snippet, "[", "]"))
+	}
+	return nil
+}
+
+func parseSearchArgs(args []string) (searchOptions, string, error) {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	conversationID := fs.Int64("conversation", 0, "restrict matches to one conversation ID")
+	limit := fs.Int("limit", searchDefaultLimit, "maximum number of matches")
+	buildIndex := fs.Bool("build-index", false, "(re)build the FTS5 search index before querying")
+
+	normalized, err := normalizeSearchArgs(args)
+	if err != nil {
+		return searchOptions{}, "", fmt.Errorf("%w\n%s", err, searchUsageText())
+	}
+	if err := fs.Parse(normalized); err != nil {
+		return searchOptions{}, "", fmt.Errorf("%w\n%s", err, searchUsageText())
+	}
+
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if query == "" && !*buildIndex {
+		return searchOptions{}, "", fmt.Errorf("search query is required\n%s", searchUsageText())
+	}
+	if *limit <= 0 {
+		return searchOptions{}, "", fmt.Errorf("--limit must be positive\n%s", searchUsageText())
+	}
+
+	return searchOptions{
+		conversationID: *conversationID,
+		limit:          *limit,
+		buildIndex:     *buildIndex,
+	}, query, nil
+}
+
+func normalizeSearchArgs(args []string) ([]string, error) {
+	flags := make([]string, 0, len(args))
+	positionals := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--build-index":
+			flags = append(flags, arg)
+		case arg == "--conversation" || arg == "--limit":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"):
+			flags = append(flags, arg)
+		default:
+			positionals = append(positionals, arg)
+		}
+	}
+	return append(flags, positionals...), nil
+}
+
+func searchUsageText() string {
+	return strings.TrimSpace(`
+Usage:
+  lcm-tui search <query> [--conversation <id>] [--limit <n>]
+  lcm-tui search --build-index [<query>]
+
+Search summaries, messages, and large-file exploration summaries.
+Uses the FTS5 index when it has been built; otherwise falls back to a
+substring scan. Rows added after the index was built are substring-scanned
+alongside it, but edits to indexed rows (repairs, rewrites) are not seen
+until the index is rebuilt.
+
+Flags:
+  --conversation <id>   Only match rows from this conversation
+  --limit <n>           Maximum matches to show (default 50)
+  --build-index         (Re)build the FTS5 index before searching
+`)
+}
+
+// buildSearchIndex drops and recreates the FTS5 index from the three
+// searchable tables and records each table's highest rowid, so later searches
+// can find rows added since. Returns the number of indexed rows.
+func buildSearchIndex(ctx context.Context, db *sql.DB) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin search index transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	statements := []string{
+		`DROP TABLE IF EXISTS ` + searchIndexTable,
+		`CREATE VIRTUAL TABLE ` + searchIndexTable + ` USING fts5(
+			source UNINDEXED,
+			ref_id UNINDEXED,
+			conversation_id UNINDEXED,
+			body
+		)`,
+		`INSERT INTO ` + searchIndexTable + ` (source, ref_id, conversation_id, body)
+			SELECT 'summary', summary_id, conversation_id, content FROM summaries`,
+		`INSERT INTO ` + searchIndexTable + ` (source, ref_id, conversation_id, body)
+			SELECT 'message', CAST(message_id AS TEXT), conversation_id, content FROM messages`,
+		`INSERT INTO ` + searchIndexTable + ` (source, ref_id, conversation_id, body)
+			SELECT 'file', file_id, conversation_id, exploration_summary
+			FROM large_files
+			WHERE exploration_summary IS NOT NULL AND exploration_summary != ''`,
+		`DROP TABLE IF EXISTS ` + searchIndexMarksTable,
+		`CREATE TABLE ` + searchIndexMarksTable + ` (
+			source TEXT PRIMARY KEY,
+			max_rowid INTEGER NOT NULL
+		)`,
+	}
+	for _, src := range searchSources {
+		statements = append(statements, `INSERT INTO `+searchIndexMarksTable+` (source, max_rowid)
+			SELECT '`+src.source+`', COALESCE(MAX(rowid), 0) FROM `+src.table)
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return 0, fmt.Errorf("build search index: %w", err)
+		}
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+searchIndexTable).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count search index rows: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit search index: %w", err)
+	}
+	rollback = false
+	return count, nil
+}
+
+func searchIndexExists(ctx context.Context, q sqlQueryer) (bool, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type = 'table' AND name = ?
+	`, searchIndexTable).Scan(&count); err != nil {
+		return false, fmt.Errorf("check search index: %w", err)
+	}
+	return count > 0, nil
+}
+
+// loadSearchMarks returns the highest rowid of each source table at the time
+// the index was built. An index built before marks were recorded has none,
+// so every row counts as newer than it.
+func loadSearchMarks(ctx context.Context, q sqlQueryer) (map[string]int64, error) {
+	marks := make(map[string]int64, len(searchSources))
+	for _, src := range searchSources {
+		marks[src.source] = 0
+	}
+
+	var count int
+	if err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type = 'table' AND name = ?
+	`, searchIndexMarksTable).Scan(&count); err != nil {
+		return nil, fmt.Errorf("check search index marks: %w", err)
+	}
+	if count == 0 {
+		return marks, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT source, max_rowid FROM `+searchIndexMarksTable)
+	if err != nil {
+		return nil, fmt.Errorf("load search index marks: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source string
+		var mark int64
+		if err := rows.Scan(&source, &mark); err != nil {
+			return nil, fmt.Errorf("scan search index mark: %w", err)
+		}
+		marks[source] = mark
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate search index marks: %w", err)
+	}
+	return marks, nil
+}
+
+// countUnindexedRows counts searchable rows above the index's marks.
+func countUnindexedRows(ctx context.Context, q sqlQueryer, marks map[string]int64) (int, error) {
+	total := 0
+	for _, src := range searchSources {
+		var count int
+		if err := q.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM `+src.table+`
+			WHERE rowid > ? AND `+src.column+` IS NOT NULL AND `+src.column+` != ''
+		`, marks[src.source]).Scan(&count); err != nil {
+			return 0, fmt.Errorf("count unindexed %s rows: %w", src.source, err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// searchLCM runs query against the FTS5 index when present and falls back to
+// a LIKE scan otherwise. With an index, rows added after it was built are
+// LIKE-scanned too and ranked after the indexed hits. Hits are annotated with
+// kind/depth and session ID.
+func searchLCM(ctx context.Context, q sqlQueryer, query string, opts searchOptions) (searchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return searchResult{}, errors.New("empty search query")
+	}
+	if opts.limit <= 0 {
+		opts.limit = searchDefaultLimit
+	}
+
+	indexed, err := searchIndexExists(ctx, q)
+	if err != nil {
+		return searchResult{}, err
+	}
+
+	var result searchResult
+	if indexed {
+		marks, err := loadSearchMarks(ctx, q)
+		if err != nil {
+			return searchResult{}, err
+		}
+		if result.unindexed, err = countUnindexedRows(ctx, q, marks); err != nil {
+			return searchResult{}, err
+		}
+		if result.hits, err = searchFTS(ctx, q, query, opts); err != nil {
+			return searchResult{}, err
+		}
+		if result.unindexed > 0 && len(result.hits) < opts.limit {
+			rest := opts
+			rest.limit -= len(result.hits)
+			newer, err := searchLike(ctx, q, query, rest, marks)
+			if err != nil {
+				return searchResult{}, err
+			}
+			result.hits = append(result.hits, newer...)
+		}
+	} else if result.hits, err = searchLike(ctx, q, query, opts, nil); err != nil {
+		return searchResult{}, err
+	}
+	if err := annotateSearchHits(ctx, q, result.hits); err != nil {
+		return searchResult{}, err
+	}
+	return result, nil
+}
+
+func searchFTS(ctx context.Context, q sqlQueryer, query string, opts searchOptions) ([]searchHit, error) {
+	sqlText := `
+		SELECT source, ref_id, CAST(conversation_id AS INTEGER),
+			snippet(` + searchIndexTable + `, 3, ?, ?, '…', 16)
+		FROM ` + searchIndexTable + `
+		WHERE ` + searchIndexTable + ` MATCH ?`
+	args := []any{searchMatchStart, searchMatchEnd, ftsQuery(query)}
+	if opts.conversationID > 0 {
+		sqlText += " AND CAST(conversation_id AS INTEGER) = ?"
+		args = append(args, opts.conversationID)
+	}
+	sqlText += " ORDER BY rank LIMIT ?"
+	args = append(args, opts.limit)
+
+	rows, err := q.QueryContext(ctx, sqlText, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query search index: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []searchHit
+	for rows.Next() {
+		var hit searchHit
+		var refID string
+		if err := rows.Scan(&hit.source, &refID, &hit.conversationID, &hit.snippet); err != nil {
+			return nil, fmt.Errorf("scan search index row: %w", err)
+		}
+		switch hit.source {
+		case "summary":
+			hit.summaryID = refID
+		case "message":
+			hit.messageID, _ = strconv.ParseInt(refID, 10, 64)
+		default:
+			hit.fileID = refID
+		}
+		hit.snippet = oneLine(sanitizeForTerminal(hit.snippet))
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate search index rows: %w", err)
+	}
+	return hits, nil
+}
+
+// ftsQuery quotes each whitespace-separated term so punctuation in user input
+// is never parsed as FTS5 query syntax. Terms are implicitly ANDed.
+func ftsQuery(query string) string {
+	terms := strings.Fields(query)
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// searchLike substring-scans the searchable tables. When after is non-nil
+// only rows above each source's rowid mark are scanned.
+func searchLike(ctx context.Context, q sqlQueryer, query string, opts searchOptions, after map[string]int64) ([]searchHit, error) {
+	pattern := "%" + escapeLike(query) + "%"
+
+	var hits []searchHit
+	for _, src := range searchSources {
+		if len(hits) >= opts.limit {
+			break
+		}
+		sqlText := `SELECT ` + src.refID + `, conversation_id, ` + src.column + `
+			FROM ` + src.table + `
+			WHERE ` + src.column + ` LIKE ? ESCAPE '\'`
+		args := []any{pattern}
+		if after != nil {
+			sqlText += " AND rowid > ?"
+			args = append(args, after[src.source])
+		}
+		if opts.conversationID > 0 {
+			sqlText += " AND conversation_id = ?"
+			args = append(args, opts.conversationID)
+		}
+		sqlText += " ORDER BY conversation_id ASC LIMIT ?"
+		args = append(args, opts.limit-len(hits))
+
+		rows, err := q.QueryContext(ctx, sqlText, args...)
+		if err != nil {
+			return nil, fmt.Errorf("search %s rows: %w", src.source, err)
+		}
+		for rows.Next() {
+			var refID, content string
+			hit := searchHit{source: src.source}
+			if err := rows.Scan(&refID, &hit.conversationID, &content); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan %s search row: %w", src.source, err)
+			}
+			switch src.source {
+			case "summary":
+				hit.summaryID = refID
+			case "message":
+				hit.messageID, _ = strconv.ParseInt(refID, 10, 64)
+			default:
+				hit.fileID = refID
+			}
+			hit.snippet = likeSnippet(oneLine(sanitizeForTerminal(content)), query)
+			hits = append(hits, hit)
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("iterate %s search rows: %w", src.source, err)
+		}
+		rows.Close()
+	}
+	return hits, nil
+}
+
+// escapeLike backslash-escapes LIKE wildcards so user input matches
+// literally; queries using it must declare ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// likeSnippet cuts a window around the first case-insensitive match and wraps
+// the match in the same markers FTS5 snippet() produces. Matching and slicing
+// both work on content's own runes, so case folding that changes a rune's
+// byte length cannot push the offsets out of range.
+func likeSnippet(content, query string) string {
+	runes := []rune(content)
+	width := utf8.RuneCountInString(query)
+	idx := -1
+	for i := 0; width > 0 && i+width <= len(runes); i++ {
+		if strings.EqualFold(string(runes[i:i+width]), query) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return truncateString(content, 2*searchSnippetRadius)
+	}
+	start := max(0, idx-searchSnippetRadius)
+	end := min(len(runes), idx+width+searchSnippetRadius)
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	b.WriteString(string(runes[start:idx]))
+	b.WriteString(searchMatchStart)
+	b.WriteString(string(runes[idx : idx+width]))
+	b.WriteString(searchMatchEnd)
+	b.WriteString(string(runes[idx+width : end]))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// annotateSearchHits fills session IDs and summary/message kinds for display
+// and for jumping to the hit in the TUI.
+func annotateSearchHits(ctx context.Context, q sqlQueryer, hits []searchHit) error {
+	sessionIDs := make(map[int64]string)
+	for i := range hits {
+		hit := &hits[i]
+		if _, ok := sessionIDs[hit.conversationID]; !ok {
+			var sessionID sql.NullString
+			err := q.QueryRowContext(ctx, `
+				SELECT session_id FROM conversations WHERE conversation_id = ?
+			`, hit.conversationID).Scan(&sessionID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("lookup session for conversation %d: %w", hit.conversationID, err)
+			}
+			sessionIDs[hit.conversationID] = sessionID.String
+		}
+		hit.sessionID = sessionIDs[hit.conversationID]
+
+		var err error
+		switch hit.source {
+		case "summary":
+			err = q.QueryRowContext(ctx, `
+				SELECT kind, COALESCE(depth, 0) FROM summaries WHERE summary_id = ?
+			`, hit.summaryID).Scan(&hit.kind, &hit.depth)
+		case "message":
+			err = q.QueryRowContext(ctx, `
+				SELECT role FROM messages WHERE message_id = ?
+			`, hit.messageID).Scan(&hit.kind)
+		default:
+			var fileName sql.NullString
+			err = q.QueryRowContext(ctx, `
+				SELECT file_name FROM large_files WHERE file_id = ?
+			`, hit.fileID).Scan(&fileName)
+			hit.kind = fileName.String
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("annotate %s hit %s: %w", hit.source, hit.refLabel(), err)
+		}
+	}
+	return nil
+}
+
+// highlightSnippet replaces match markers with the given open/close strings.
+func highlightSnippet(snippet, open, close string) string {
+	snippet = strings.ReplaceAll(snippet, searchMatchStart, open)
+	return strings.ReplaceAll(snippet, searchMatchEnd, close)
+}
+
+var searchMatchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
+
+// renderSnippet styles matched segments and truncates to width visible runes.
+func renderSnippet(snippet string, width int, selected bool) string {
+	plain := highlightSnippet(snippet, "", "")
+	if len([]rune(plain)) > width {
+		snippet = truncateMarkedSnippet(snippet, width)
+	}
+	if selected {
+		return highlightSnippet(snippet, "", "")
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(snippet, searchMatchStart)
+		if start < 0 {
+			b.WriteString(snippet)
+			break
+		}
+		b.WriteString(snippet[:start])
+		rest := snippet[start+len(searchMatchStart):]
+		end := strings.Index(rest, searchMatchEnd)
+		if end < 0 {
+			b.WriteString(searchMatchStyle.Render(rest))
+			break
+		}
+		b.WriteString(searchMatchStyle.Render(rest[:end]))
+		snippet = rest[end+len(searchMatchEnd):]
+	}
+	return b.String()
+}
+
+func truncateMarkedSnippet(snippet string, width int) string {
+	var b strings.Builder
+	visible := 0
+	for _, r := range snippet {
+		if string(r) == searchMatchStart || string(r) == searchMatchEnd {
+			b.WriteRune(r)
+			continue
+		}
+		if visible >= width-3 {
+			b.WriteString("...")
+			break
+		}
+		b.WriteRune(r)
+		visible++
+	}
+	return b.String()
+}
+
+// startSearch switches to the search screen with the query input focused.
+func (m *model) startSearch() tea.Cmd {
+	if m.screen != screenSearch {
+		m.searchReturn = m.screen
+	}
+	m.screen = screenSearch
+	m.searchInput.SetValue(m.searchQuery)
+	m.searchInput.CursorEnd()
+	m.status = "Type a query and press Enter"
+	return m.searchInput.Focus()
+}
+
+func (m model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searchInput.Focused() {
+		switch msg.String() {
+		case "enter":
+			m.searchInput.Blur()
+			return m, m.runSearch(m.searchInput.Value())
+		case "esc":
+			m.searchInput.Blur()
+			if len(m.searchHits) == 0 {
+				m.screen = m.searchReturn
+				m.status = "Search canceled"
+			}
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		m.searchCursor = clamp(m.searchCursor-1, 0, len(m.searchHits)-1)
+	case "down", "j":
+		m.searchCursor = clamp(m.searchCursor+1, 0, len(m.searchHits)-1)
+	case "g":
+		m.searchCursor = 0
+	case "G":
+		m.searchCursor = max(0, len(m.searchHits)-1)
+	case "/":
+		return m, m.startSearch()
+	case "enter":
+		if m.searchCursor < 0 || m.searchCursor >= len(m.searchHits) {
+			m.status = "No match selected"
+			return m, nil
+		}
+		hit := m.searchHits[m.searchCursor]
+		ctx, seq := m.beginLoad("Opening " + hit.refLabel() + "...")
+		return m, m.startLoad(openSearchHitCmd(ctx, seq, m.agents, m.db, hit))
+	case "esc", "b", "backspace":
+		m.cancelLoad()
+		m.screen = m.searchReturn
+		m.status = "Back from search"
+	}
+	return m, nil
+}
+
+// searchResultsMsg carries the hits for one query run from the TUI.
+type searchResultsMsg struct {
+	seq       int
+	query     string
+	hits      []searchHit
+	unindexed int
+	err       error
+}
+
+// runSearch starts the query in the background; the result replaces the hit
+// list once it arrives.
+func (m *model) runSearch(query string) tea.Cmd {
+	query = strings.TrimSpace(query)
+	m.searchQuery = query
+	if query == "" {
+		m.status = "Empty search query"
+		return nil
+	}
+
+	if m.db == nil {
+		m.status = "Error: " + errLCMDBUnavailable.Error()
+		return nil
+	}
+
+	ctx, seq := m.beginLoad(fmt.Sprintf("Searching for %q...", query))
+	return m.startLoad(searchCmd(ctx, seq, m.db, query))
+}
+
+func searchCmd(ctx context.Context, seq int, db *sql.DB, query string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := searchLCM(ctx, db, query, searchOptions{limit: searchDefaultLimit * 4})
+		return searchResultsMsg{seq: seq, query: query, hits: result.hits, unindexed: result.unindexed, err: err}
+	}
+}
+
+func (m model) handleSearchResults(msg searchResultsMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+	m.searchHits = msg.hits
+	m.searchCursor = 0
+	if len(msg.hits) == 0 {
+		m.status = fmt.Sprintf("No matches for %q", msg.query)
+	} else {
+		m.status = fmt.Sprintf("%d matches for %q", len(msg.hits), msg.query)
+	}
+	if msg.unindexed > 0 {
+		m.status += fmt.Sprintf(" (index stale: %d newer rows substring-scanned)", msg.unindexed)
+	}
+	return m, nil
+}
+
+// searchJumpLoadedMsg carries everything needed to land on a search hit with
+// the usual agent -> session -> screen navigation stack in place.
+type searchJumpLoadedMsg struct {
+	seq        int
+	hit        searchHit
+	agentIndex int
+	files      []sessionFileEntry
+	sessions   []sessionEntry
+	nextCursor int
+	fileIndex  int
+	messages   []sessionMessage
+	graph      summaryGraph
+	items      []contextItemEntry
+	largeFiles []largeFileEntry
+	err        error
+}
+
+// openSearchHitCmd loads the agent's sessions up to the hit's session, the
+// session transcript, and the destination screen's data.
+func openSearchHitCmd(ctx context.Context, seq int, agents []agentEntry, db *sql.DB, hit searchHit) tea.Cmd {
+	return func() tea.Msg {
+		result := searchJumpLoadedMsg{seq: seq, hit: hit}
+		fail := func(err error) tea.Msg {
+			result.err = err
+			return result
+		}
+
+		if hit.sessionID == "" {
+			return fail(fmt.Errorf("conversation %d has no session ID", hit.conversationID))
+		}
+		agentIdx, ok := findAgentForSession(agents, hit.sessionID)
+		if !ok {
+			return fail(fmt.Errorf("no agent session file found for session %s", hit.sessionID))
+		}
+		result.agentIndex = agentIdx
+
+		files, err := discoverSessionFiles(agents[agentIdx])
+		if err != nil {
+			return fail(err)
+		}
+		result.files = files
+		result.fileIndex = -1
+		for i, file := range files {
+			if strings.TrimSuffix(file.filename, ".jsonl") == hit.sessionID {
+				result.fileIndex = i
+				break
+			}
+		}
+		if result.fileIndex < 0 {
+			return fail(fmt.Errorf("session %s not found for agent %s", hit.sessionID, agents[agentIdx].name))
+		}
+
+		// Load whole batches so later incremental loading continues seamlessly.
+		limit := sessionInitialLoadSize
+		for limit <= result.fileIndex {
+			limit += sessionBatchLoadSize
+		}
+		result.sessions, result.nextCursor, err = loadSessionBatch(ctx, files, 0, limit, db)
+		if err != nil {
+			return fail(err)
+		}
+		session := result.sessions[result.fileIndex]
+
+		result.messages, err = parseSessionMessages(ctx, session.path)
+		if err != nil {
+			return fail(err)
+		}
+
+		switch hit.source {
+		case "summary":
+			result.graph, err = loadSummaryGraph(ctx, db, session.id)
+		case "message":
+			result.items, err = loadContextItems(ctx, db, session.id)
+		default:
+			result.largeFiles, err = loadLargeFiles(ctx, db, session.id)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		return result
+	}
+}
+
+func (m model) handleSearchJumpLoaded(msg searchJumpLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.finishLoad(msg.seq) {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.status = "Error: " + msg.err.Error()
+		return m, nil
+	}
+
+	hit := msg.hit
+	m.agentCursor = msg.agentIndex
+	m.sessionFiles = msg.files
+	m.sessions = msg.sessions
+	m.sessionFileCursor = msg.nextCursor
+	m.sessionCursor = msg.fileIndex
+	m.messages = msg.messages
+	m.refreshConversationViewport()
+
+	switch hit.source {
+	case "summary":
+		m.summary = msg.graph
+		m.summarySources = make(map[string][]summarySource)
+		m.summarySourceErr = make(map[string]string)
+		m.summaryDetailScroll = 0
+		m.screen = screenSummaries
+		if !m.revealSummary(hit.summaryID) {
+			m.summaryRows = buildSummaryRows(msg.graph)
+			m.summaryCursor = 0
+			m.status = fmt.Sprintf("Summary %s is not in the latest conversation for this session", hit.summaryID)
+			return m, m.loadCurrentSummarySources()
+		}
+		m.status = fmt.Sprintf("Jumped to %s in conversation %d", hit.summaryID, msg.graph.conversationID)
+		return m, m.loadCurrentSummarySources()
+	case "message":
+		m.contextItems = msg.items
+		m.contextMark = -1
+		m.contextCursor = 0
+		m.contextDetailScroll = 0
+		m.screen = screenContext
+		for i, item := range msg.items {
+			if item.itemType == "message" && item.messageID == hit.messageID {
+				m.contextCursor = i
+				m.status = fmt.Sprintf("Jumped to message #%d at context ordinal %d", hit.messageID, item.ordinal)
+				return m, nil
+			}
+		}
+		m.status = fmt.Sprintf("Message #%d is not in the active context (it has been compacted)", hit.messageID)
+	default:
+		m.largeFiles = msg.largeFiles
+		m.fileCursor = 0
+		m.screen = screenFiles
+		for i, f := range msg.largeFiles {
+			if f.fileID == hit.fileID {
+				m.fileCursor = i
+				break
+			}
+		}
+		m.status = fmt.Sprintf("Jumped to large file %s", hit.fileID)
+	}
+	return m, nil
+}
+
+// revealSummary expands every ancestor of summaryID along its first derived
+// path and moves the cursor onto it. Returns false when the node is missing.
+func (m *model) revealSummary(summaryID string) bool {
+	if m.summary.nodes[summaryID] == nil {
+		return false
+	}
+	derivedBy := make(map[string]string, len(m.summary.nodes))
+	for _, id := range sortedNodeIDs(m.summary.nodes) {
+		for _, childID := range m.summary.nodes[id].children {
+			if _, exists := derivedBy[childID]; !exists {
+				derivedBy[childID] = id
+			}
+		}
+	}
+	seen := map[string]bool{summaryID: true}
+	for current := derivedBy[summaryID]; current != "" && !seen[current]; current = derivedBy[current] {
+		seen[current] = true
+		m.summary.nodes[current].expanded = true
+	}
+
+	m.summaryRows = buildSummaryRows(m.summary)
+	for i, row := range m.summaryRows {
+		if row.summaryID == summaryID {
+			m.summaryCursor = i
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNodeIDs(nodes map[string]*summaryNode) []string {
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sortSummaryIDs(ids, nodes)
+	return ids
+}
+
+func (m model) renderSearch() string {
+	lines := []string{"Search: " + m.searchInput.View(), ""}
+	if len(m.searchHits) == 0 {
+		if m.searchQuery != "" {
+			lines = append(lines, fmt.Sprintf("No matches for %q", m.searchQuery))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	visible := max(1, m.height-6)
+	offset := listOffset(m.searchCursor, len(m.searchHits), visible)
+	for idx := offset; idx < min(len(m.searchHits), offset+visible); idx++ {
+		hit := m.searchHits[idx]
+		prefix := fmt.Sprintf("conv %-5d %-7s %-22s %-9s ", hit.conversationID, hit.source, hit.refLabel(), truncateString(hit.kindLabel(), 9))
+		snippet := renderSnippet(hit.snippet, max(8, m.width-len(prefix)-4), idx == m.searchCursor)
+		if idx == m.searchCursor {
+			lines = append(lines, selectedStyle.Render("> "+prefix+snippet))
+			continue
+		}
+		lines = append(lines, "  "+prefix+snippet)
+	}
+	return strings.Join(lines, "\n")
+}
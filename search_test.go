@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSearchTestDB writes an lcm.db with one conversation whose messages and
+// summary contain no LIKE wildcard characters.
+func newSearchTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lcm.db")
+	create, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := create.Exec(repairTestSchema + `
+		CREATE TABLE large_files (
+			file_id TEXT PRIMARY KEY,
+			conversation_id INTEGER NOT NULL,
+			file_name TEXT,
+			storage_uri TEXT NOT NULL,
+			exploration_summary TEXT
+		);
+		INSERT INTO conversations (conversation_id, session_id) VALUES (1, 'sess-1');
+		INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count)
+			VALUES (1, 1, 0, 'user', 'deploy the pipeline', 4), (2, 1, 1, 'assistant', 'rolled back at 10 am', 5);
+		INSERT INTO summaries (summary_id, conversation_id, kind, content, token_count)
+			VALUES ('sum_a', 1, 'leaf', 'deploy rolled back', 4);
+		INSERT INTO large_files (file_id, conversation_id, storage_uri, exploration_summary)
+			VALUES ('file_a', 1, 'file:///tmp/a', 'build log');
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if err := create.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := openLCMDB(path, dbReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSearchLikeMatchesWildcardsLiterally(t *testing.T) {
+	ctx := context.Background()
+	db := newSearchTestDB(t)
+	for _, query := range []string{"%", "_", `\`, "deploy%back", "rolled_back"} {
+		result, err := searchLCM(ctx, db, query, searchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.hits) != 0 {
+			t.Errorf("search %q matched %d rows, want none", query, len(result.hits))
+		}
+	}
+
+	if _, err := db.Exec(`INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count)
+		VALUES (3, 1, 2, 'user', 'coverage at 100% for snake_case\path', 6)`); err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{"100%", "snake_case", `case\path`} {
+		result, err := searchLCM(ctx, db, query, searchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.hits) != 1 || result.hits[0].messageID != 3 {
+			t.Errorf("search %q = %+v, want message 3 only", query, result.hits)
+		}
+	}
+}
+
+func TestSearchFindsRowsAddedAfterIndexBuild(t *testing.T) {
+	ctx := context.Background()
+	db := newSearchTestDB(t)
+	if _, err := buildSearchIndex(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := searchLCM(ctx, db, "deploy", searchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.hits) != 2 || result.unindexed != 0 {
+		t.Fatalf("fresh index: %d hits, %d unindexed; want 2 hits, 0 unindexed", len(result.hits), result.unindexed)
+	}
+
+	if _, err := db.Exec(`
+		INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count)
+			VALUES (3, 1, 2, 'user', 'deploy again', 2);
+		INSERT INTO summaries (summary_id, conversation_id, kind, content, token_count)
+			VALUES ('sum_b', 1, 'leaf', 'second deploy done', 3);
+		INSERT INTO large_files (file_id, conversation_id, storage_uri, exploration_summary)
+			VALUES ('file_b', 1, 'file:///tmp/b', 'deploy manifest');
+	`); err != nil {
+		t.Fatal(err)
+	}
+	result, err = searchLCM(ctx, db, "deploy", searchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.unindexed != 3 {
+		t.Fatalf("unindexed = %d, want 3", result.unindexed)
+	}
+	if len(result.hits) != 5 {
+		t.Fatalf("stale index: %d hits, want 5", len(result.hits))
+	}
+	for _, hit := range result.hits[2:] {
+		if hit.summaryID != "sum_b" && hit.messageID != 3 && hit.fileID != "file_b" {
+			t.Errorf("hit %s ranked after the indexed hits is not a new row", hit.refLabel())
+		}
+	}
+
+	result, err = searchLCM(ctx, db, "deploy", searchOptions{limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.hits) != 3 {
+		t.Fatalf("limit 3 returned %d hits", len(result.hits))
+	}
+
+	if _, err := buildSearchIndex(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	result, err = searchLCM(ctx, db, "deploy", searchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.hits) != 5 || result.unindexed != 0 {
+		t.Fatalf("rebuilt index: %d hits, %d unindexed; want 5 hits, 0 unindexed", len(result.hits), result.unindexed)
+	}
+}
+
+func TestLikeSnippetNonASCII(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		query   string
+		want    string
+	}{
+		{
+			name:    "lowercase grows",
+			content: "ȺȺȺȺabc",
+			query:   "abc",
+			want:    "ȺȺȺȺ" + searchMatchStart + "abc" + searchMatchEnd,
+		},
+		{
+			name:    "match is non-ASCII",
+			content: "grüße aus KÖLN und mehr",
+			query:   "köln",
+			want:    "grüße aus " + searchMatchStart + "KÖLN" + searchMatchEnd + " und mehr",
+		},
+		{
+			name:    "query folds differently",
+			content: "ⱥⱥ Ⱥbc",
+			query:   "ⱥBC",
+			want:    "ⱥⱥ " + searchMatchStart + "Ⱥbc" + searchMatchEnd,
+		},
+		{
+			name:    "no match",
+			content: "日本語のテキスト",
+			query:   "abc",
+			want:    "日本語のテキスト",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := likeSnippet(tt.content, tt.query); got != tt.want {
+				t.Fatalf("likeSnippet(%q, %q) = %q, want %q", tt.content, tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLikeSnippetWindowKeepsRunes(t *testing.T) {
+	content := strings.Repeat("é", 100) + "needle" + strings.Repeat("ß", 100)
+	got := likeSnippet(content, "NEEDLE")
+	want := "…" + strings.Repeat("é", searchSnippetRadius) + searchMatchStart + "needle" + searchMatchEnd +
+		strings.Repeat("ß", searchSnippetRadius) + "…"
+	if got != want {
+		t.Fatalf("likeSnippet window = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	providerAnthropic  = "anthropic"
+	providerOpenAI     = "openai"
+	providerExtractive = "extractive"
+
+	openAIModel   = "gpt-4o-mini"
+	openAIBaseURL = "https://api.openai.com"
+)
+
+// summarizer turns a summary request into replacement summary text.
+type summarizer interface {
+	summarize(ctx context.Context, req summaryRequest) (string, error)
+	describe() string
+}
+
+// summaryRequest is a rendered prompt along with the source text it was
+// rendered from. Model-backed summarizers send prompt; the extractive one
+// reads text directly, so custom templates cannot hide the source from it.
+type summaryRequest struct {
+	kind         string // "leaf" or "condensed"
+	prompt       string
+	text         string
+	targetTokens int
+}
+
+// summarizerConfig selects and configures a summarizer from CLI flags.
+type summarizerConfig struct {
+	provider string
+	model    string
+	baseURL  string
+}
+
+func parseSummarizerConfig(provider, model, baseURL string) (summarizerConfig, error) {
+	cfg := summarizerConfig{
+		provider: strings.ToLower(strings.TrimSpace(provider)),
+		model:    strings.TrimSpace(model),
+		baseURL:  strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+	}
+	switch cfg.provider {
+	case providerAnthropic, providerOpenAI:
+	case providerExtractive:
+		if cfg.model != "" || cfg.baseURL != "" {
+			return summarizerConfig{}, errors.New("--model and --base-url do not apply to the extractive provider")
+		}
+	default:
+		return summarizerConfig{}, fmt.Errorf("unknown provider %q (want anthropic, openai, or extractive)", provider)
+	}
+	return cfg, nil
+}
+
+// summarizerConfigFromEnv configures the TUI's summarizer, which has no
+// flags: LCM_TUI_PROVIDER, LCM_TUI_MODEL and LCM_TUI_BASE_URL mirror the
+// repair command's --provider, --model and --base-url.
+func summarizerConfigFromEnv() (summarizerConfig, error) {
+	provider := firstNonEmpty(strings.TrimSpace(os.Getenv("LCM_TUI_PROVIDER")), providerAnthropic)
+	return parseSummarizerConfig(provider, os.Getenv("LCM_TUI_MODEL"), os.Getenv("LCM_TUI_BASE_URL"))
+}
+
+// newSummarizer builds the configured summarizer, resolving API keys only
+// for providers that need them.
+func newSummarizer(cfg summarizerConfig, paths appDataPaths) (summarizer, error) {
+	httpClient := &http.Client{Timeout: defaultHTTPTimeout}
+	switch cfg.provider {
+	case providerAnthropic, "":
+		apiKey, err := resolveAnthropicAPIKey(paths)
+		if err != nil {
+			return nil, err
+		}
+		return &anthropicClient{
+			apiKey:  apiKey,
+			model:   firstNonEmpty(cfg.model, anthropicModel),
+			baseURL: firstNonEmpty(cfg.baseURL, anthropicBaseURL),
+			http:    httpClient,
+		}, nil
+	case providerOpenAI:
+		return &openAIClient{
+			apiKey:  strings.TrimSpace(os.Getenv("OPENAI_API_KEY")),
+			model:   firstNonEmpty(cfg.model, openAIModel),
+			baseURL: firstNonEmpty(cfg.baseURL, openAIBaseURL),
+			http:    httpClient,
+		}, nil
+	case providerExtractive:
+		return extractiveSummarizer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", cfg.provider)
+	}
+}
+
+// summarizerAPIError is a non-2xx response from a summarizer HTTP API.
+type summarizerAPIError struct {
+	api        string
+	status     int
+	errType    string
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *summarizerAPIError) Error() string {
+	if e.errType != "" {
+		return fmt.Sprintf("%s %d %s: %s", e.api, e.status, e.errType, e.message)
+	}
+	return fmt.Sprintf("%s %d: %s", e.api, e.status, e.message)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds.
+func parseRetryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header.Get("Retry-After")))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// apiEndpoint joins a provider base URL with a /v1 path. Base URLs may be
+// given with or without the trailing /v1.
+func apiEndpoint(baseURL, path string) string {
+	base := strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(base, "/v1") {
+		base += "/v1"
+	}
+	return base + "/" + path
+}
+
+// openAIClient calls an OpenAI-compatible chat completions endpoint, which
+// also covers local llama.cpp and Ollama servers.
+type openAIClient struct {
+	apiKey  string
+	model   string
+	baseURL string
+	http    *http.Client
+}
+
+type openAIRequest struct {
+	Model       string                 `json:"model"`
+	MaxTokens   int                    `json:"max_tokens"`
+	Temperature float64                `json:"temperature"`
+	Messages    []openAIRequestMessage `json:"messages"`
+}
+
+type openAIRequestMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+type openAIErrorEnvelope struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *openAIClient) describe() string {
+	return fmt.Sprintf("%s (%s at %s)", providerOpenAI, c.model, c.baseURL)
+}
+
+func (c *openAIClient) summarize(ctx context.Context, request summaryRequest) (string, error) {
+	targetTokens := request.targetTokens
+	if targetTokens <= 0 {
+		targetTokens = condensedTargetTokens
+	}
+
+	reqBody := openAIRequest{
+		Model:       c.model,
+		MaxTokens:   targetTokens,
+		Temperature: 0,
+		Messages: []openAIRequestMessage{
+			{Role: "user", Content: request.prompt},
+		},
+	}
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal chat completions request: %w", err)
+	}
+
+	endpoint := apiEndpoint(c.baseURL, "chat/completions")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("build chat completions request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("call %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read chat completions response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		apiErr := &summarizerAPIError{api: "chat completions API", status: resp.StatusCode, message: strings.TrimSpace(string(body)), retryAfter: parseRetryAfter(resp.Header)}
+		var envelope openAIErrorEnvelope
+		if json.Unmarshal(body, &envelope) == nil && strings.TrimSpace(envelope.Error.Message) != "" {
+			apiErr.errType, apiErr.message = envelope.Error.Type, envelope.Error.Message
+		}
+		return "", apiErr
+	}
+
+	var parsed openAIResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("decode chat completions response: %w", err)
+	}
+	if len(parsed.Choices) == 0 {
+		return "", errors.New("chat completions response did not include any choices")
+	}
+	result := strings.TrimSpace(parsed.Choices[0].Message.Content)
+	if result == "" {
+		return "", errors.New("chat completions response did not include text content")
+	}
+	return result, nil
+}
+
+// extractiveSummarizer builds a summary offline by keeping the leading
+// non-empty lines of the prompt's source section until the token target is
+// reached. Output is deterministic, which makes it suitable for tests and
+// air-gapped repairs.
+type extractiveSummarizer struct{}
+
+// condensedSummaryHeadings mirrors the sections required by the built-in
+// condensed prompt template.
+var condensedSummaryHeadings = []string{
+	"Goals & Context",
+	"Key Decisions",
+	"Progress",
+	"Constraints",
+	"Critical Details",
+	"Files",
+}
+
+func (extractiveSummarizer) describe() string {
+	return providerExtractive + " (offline)"
+}
+
+func (extractiveSummarizer) summarize(_ context.Context, req summaryRequest) (string, error) {
+	targetTokens := req.targetTokens
+	if targetTokens <= 0 {
+		targetTokens = condensedTargetTokens
+	}
+
+	source, condensed := req.text, req.kind != "leaf"
+	if strings.TrimSpace(source) == "" {
+		return "", errors.New("extractive summarizer got no source text")
+	}
+
+	budget := targetTokens * 4
+	var lines []string
+	used := 0
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if used > 0 && used+len(line) > budget {
+			break
+		}
+		if len(line) > budget {
+			line = cutAtRuneBoundary(line, budget)
+		}
+		lines = append(lines, line)
+		used += len(line) + 1
+	}
+	extract := strings.Join(lines, "\n")
+
+	if !condensed {
+		return extract + "\nFiles: none", nil
+	}
+	var b strings.Builder
+	for i, heading := range condensedSummaryHeadings {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(heading + "\n")
+		switch heading {
+		case "Goals & Context":
+			b.WriteString(extract)
+		case "Files":
+			b.WriteString("none")
+		default:
+			b.WriteString("(not extracted)")
+		}
+	}
+	return b.String(), nil
+}
+
+// cutAtRuneBoundary returns the longest prefix of s that fits in limit bytes
+// without splitting a UTF-8 sequence.
+func cutAtRuneBoundary(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// summaryConsumerList is the overlay listing every condensed summary built
+// from one summary, opened with c in the summary DAG.
+type summaryConsumerList struct {
+	summaryID string
+	consumers []string
+	cursor    int
+}
+
+// sharedSummaryLabel marks a summary listed under more than one condensed
+// summary; empty otherwise.
+func (g summaryGraph) sharedSummaryLabel(summaryID string) string {
+	if count := len(g.derivedBy[summaryID]); count > 1 {
+		return fmt.Sprintf("(shared x%d)", count)
+	}
+	return ""
+}
+
+// otherDerivedBy returns the condensed summaries built from summaryID other
+// than the one the row is listed under.
+func (g summaryGraph) otherDerivedBy(row summaryRow) []string {
+	var others []string
+	for _, id := range g.derivedBy[row.summaryID] {
+		if id != row.parentID {
+			others = append(others, id)
+		}
+	}
+	return others
+}
+
+// jumpToNextOccurrence moves the cursor to the selected summary's row under
+// the next condensed summary that consumed it, expanding the tree as needed.
+func (m *model) jumpToNextOccurrence() tea.Cmd {
+	if len(m.summaryRows) == 0 || m.summaryCursor < 0 || m.summaryCursor >= len(m.summaryRows) {
+		m.status = "No summary selected"
+		return nil
+	}
+	row := m.summaryRows[m.summaryCursor]
+	consumers := m.summary.derivedBy[row.summaryID]
+	if len(consumers) < 2 {
+		m.status = fmt.Sprintf("%s appears only once in the DAG", row.summaryID)
+		return nil
+	}
+	next := 0
+	for i, id := range consumers {
+		if id == row.parentID {
+			next = (i + 1) % len(consumers)
+			break
+		}
+	}
+	if !m.revealSummaryUnder(row.summaryID, consumers[next]) {
+		m.status = fmt.Sprintf("Could not reveal %s under %s", row.summaryID, consumers[next])
+		return nil
+	}
+	m.summaryDetailScroll = 0
+	m.status = fmt.Sprintf("%s under %s (%d of %d)", row.summaryID, consumers[next], next+1, len(consumers))
+	return m.loadCurrentSummarySources()
+}
+
+// revealSummaryUnder expands parentID and its ancestors, then moves the
+// cursor to summaryID's row beneath it.
+func (m *model) revealSummaryUnder(summaryID, parentID string) bool {
+	parent := m.summary.nodes[parentID]
+	if parent == nil {
+		return false
+	}
+	parent.expanded = true
+	if !m.revealSummary(parentID) {
+		return false
+	}
+	for i := m.summaryCursor + 1; i < len(m.summaryRows); i++ {
+		row := m.summaryRows[i]
+		if row.summaryID == summaryID && row.parentID == parentID {
+			m.summaryCursor = i
+			return true
+		}
+	}
+	return false
+}
+
+// openSummaryConsumers lists the condensed summaries built from the selected
+// summary.
+func (m *model) openSummaryConsumers() {
+	id, ok := m.currentSummaryID()
+	if !ok {
+		m.status = "No summary selected"
+		return
+	}
+	consumers := m.summary.derivedBy[id]
+	if len(consumers) == 0 {
+		m.status = fmt.Sprintf("No condensed summary was built from %s", id)
+		return
+	}
+	list := &summaryConsumerList{summaryID: id, consumers: consumers}
+	for i, consumer := range consumers {
+		if consumer == m.summaryRows[m.summaryCursor].parentID {
+			list.cursor = i
+		}
+	}
+	m.summaryConsumers = list
+	m.status = ""
+}
+
+func (m model) handleSummaryConsumersKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	list := m.summaryConsumers
+	switch msg.String() {
+	case "up", "k":
+		list.cursor = clamp(list.cursor-1, 0, len(list.consumers)-1)
+	case "down", "j":
+		list.cursor = clamp(list.cursor+1, 0, len(list.consumers)-1)
+	case "enter":
+		m.summaryConsumers = nil
+		consumer := list.consumers[list.cursor]
+		if !m.revealSummaryUnder(list.summaryID, consumer) {
+			m.status = fmt.Sprintf("Could not reveal %s under %s", list.summaryID, consumer)
+			return m, nil
+		}
+		m.summaryDetailScroll = 0
+		m.status = fmt.Sprintf("%s under %s", list.summaryID, consumer)
+		return m, m.loadCurrentSummarySources()
+	case "esc", "b", "backspace", "c":
+		m.summaryConsumers = nil
+	}
+	return m, nil
+}
+
+func (m model) renderSummaryConsumers() string {
+	list := m.summaryConsumers
+	lines := []string{
+		fmt.Sprintf("Condensed summaries built from %s (%d):", list.summaryID, len(list.consumers)),
+		"",
+	}
+	for i, id := range list.consumers {
+		node := m.summary.nodes[id]
+		if node == nil {
+			continue
+		}
+		preview := truncateString(oneLine(node.content), max(8, m.width-50))
+		line := fmt.Sprintf("  %s [d%d, %dt] %s", id, node.depth, node.tokenCount, preview)
+		if parents := m.summary.derivedBy[id]; len(parents) > 0 {
+			line += helpStyle.Render("  under " + strings.Join(parents, ", "))
+		}
+		if i == list.cursor {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", "Press Enter to jump to the summary under the selected node. Press Esc or c to close.")
+	return strings.Join(lines, "\n")
+}
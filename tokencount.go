@@ -0,0 +1,394 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	tokenizerHeuristic = "heuristic"
+	tokenizerCl100k    = "cl100k_base"
+	tokenizerO200k     = "o200k_base"
+	defaultTokenizer   = tokenizerHeuristic
+
+	// cl100kSHA256 is the digest of OpenAI's published cl100k_base.tiktoken,
+	// the same one tiktoken checks after downloading it.
+	cl100kSHA256 = "223921b76ee99bde995b7ff738513eef100fb51d18c93597a113bcffe865b2a7"
+
+	// bpeCacheLimit caps memoized pre-token counts; conversations repeat
+	// the same words constantly.
+	bpeCacheLimit = 1 << 16
+)
+
+// builtinCl100kRanks is OpenAI's cl100k_base encoding exactly as published
+// at openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken
+// (base64 token, space, rank per line), gzipped. The uncompressed bytes must
+// hash to cl100kSHA256.
+//
+//go:embed tokenizers/cl100k_base.tiktoken.gz
+var builtinCl100kRanks []byte
+
+// tokenCounter counts tokens the way a model's tokenizer would.
+type tokenCounter interface {
+	countTokens(text string) int
+	describe() string
+}
+
+// heuristicCounter is the original four-characters-per-token estimate,
+// counting any non-empty text as at least one token.
+type heuristicCounter struct{}
+
+func (heuristicCounter) countTokens(text string) int {
+	if n := estimateTokenCount(text); n > 0 || strings.TrimSpace(text) == "" {
+		return n
+	}
+	return 1
+}
+
+func (heuristicCounter) describe() string { return tokenizerHeuristic + " (4 chars/token)" }
+
+// bpeCounter is a tiktoken-compatible byte-level BPE tokenizer: text is
+// split into pre-tokens, and each pre-token's bytes are merged pairwise by
+// lowest rank until no ranked pair remains.
+type bpeCounter struct {
+	name   string
+	source string
+	ranks  map[string]int
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+// modelTokenizers maps model name prefixes to the encoding their provider
+// uses. Models without a published tokenizer (Claude) are not listed; count
+// them with the heuristic. Longer prefixes are matched first.
+var modelTokenizers = map[string]string{
+	"gpt-4o":         tokenizerO200k,
+	"gpt-4.1":        tokenizerO200k,
+	"gpt-4.5":        tokenizerO200k,
+	"gpt-5":          tokenizerO200k,
+	"o1":             tokenizerO200k,
+	"o3":             tokenizerO200k,
+	"o4":             tokenizerO200k,
+	"gpt-4":          tokenizerCl100k,
+	"gpt-3.5":        tokenizerCl100k,
+	"text-embedding": tokenizerCl100k,
+}
+
+var (
+	loadedCountersMu sync.Mutex
+	loadedCounters   = map[string]tokenCounter{}
+)
+
+// newTokenCounter resolves a --tokenizer value: "heuristic", an encoding name
+// (cl100k_base is built in; others need a rank file in the tokenizers dir),
+// or a model name mapped through modelTokenizers. An encoding that is not
+// installed is an error rather than a silent substitute.
+func newTokenCounter(spec string, paths appDataPaths) (tokenCounter, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = defaultTokenizer
+	}
+
+	loadedCountersMu.Lock()
+	defer loadedCountersMu.Unlock()
+	if counter, ok := loadedCounters[spec]; ok {
+		return counter, nil
+	}
+
+	counter, err := resolveTokenCounter(spec, paths)
+	if err != nil {
+		return nil, err
+	}
+	loadedCounters[spec] = counter
+	return counter, nil
+}
+
+func resolveTokenCounter(spec string, paths appDataPaths) (tokenCounter, error) {
+	if spec == tokenizerHeuristic {
+		return heuristicCounter{}, nil
+	}
+
+	encoding := spec
+	if mapped, ok := tokenizerForModel(spec); ok {
+		encoding = mapped
+	}
+	counter, err := loadRankFileCounter(encoding, paths.tokenizersDir)
+	if err == nil {
+		return counter, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	switch {
+	case encoding == tokenizerCl100k:
+		return loadBuiltinCl100k()
+	case encoding == tokenizerO200k || encoding != spec:
+		return nil, fmt.Errorf("tokenizer %q needs %s.tiktoken in %s", spec, encoding, paths.tokenizersDir)
+	}
+	return nil, fmt.Errorf("unknown tokenizer %q (want %s, %s, an installed encoding, or a model name)", spec, tokenizerHeuristic, tokenizerCl100k)
+}
+
+// tokenizerForModel returns the encoding for a model name, matching the
+// longest known prefix.
+func tokenizerForModel(model string) (string, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return "", false
+	}
+	prefixes := make([]string, 0, len(modelTokenizers))
+	for prefix := range modelTokenizers {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(model, prefix) {
+			return modelTokenizers[prefix], true
+		}
+	}
+	return "", false
+}
+
+// loadBuiltinCl100k decodes the embedded cl100k_base ranks after checking
+// them against the published digest.
+func loadBuiltinCl100k() (*bpeCounter, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(builtinCl100kRanks))
+	if err != nil {
+		return nil, fmt.Errorf("open built-in %s ranks: %w", tokenizerCl100k, err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("read built-in %s ranks: %w", tokenizerCl100k, err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != cl100kSHA256 {
+		return nil, fmt.Errorf("built-in %s ranks do not match the published digest", tokenizerCl100k)
+	}
+	ranks, err := parseBPERanks(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("built-in %s ranks: %w", tokenizerCl100k, err)
+	}
+	return newBPECounter(tokenizerCl100k, "built-in", ranks), nil
+}
+
+// loadRankFileCounter loads <dir>/<name>.tiktoken, the format OpenAI
+// publishes its encodings in.
+func loadRankFileCounter(name, dir string) (*bpeCounter, error) {
+	if dir == "" || name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fs.ErrNotExist
+	}
+	path := filepath.Join(dir, name+".tiktoken")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	ranks, err := parseBPERanks(file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return newBPECounter(name, path, ranks), nil
+}
+
+func parseBPERanks(r io.Reader) (map[string]int, error) {
+	ranks := make(map[string]int, 1<<17)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		encoded, rankText, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want \"<base64> <rank>\"", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: decode token: %w", line, err)
+		}
+		rank, err := strconv.Atoi(rankText)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: parse rank: %w", line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("rank file is missing single byte 0x%02x", b)
+		}
+	}
+	return ranks, nil
+}
+
+func newBPECounter(name, source string, ranks map[string]int) *bpeCounter {
+	return &bpeCounter{name: name, source: source, ranks: ranks, cache: make(map[string]int)}
+}
+
+func (c *bpeCounter) describe() string {
+	if c.source == "built-in" {
+		return fmt.Sprintf("%s (built-in, %d ranks)", c.name, len(c.ranks))
+	}
+	return fmt.Sprintf("%s (%s)", c.name, c.source)
+}
+
+func (c *bpeCounter) countTokens(text string) int {
+	total := 0
+	for _, piece := range splitPretokens(text) {
+		total += c.countPiece(piece)
+	}
+	return total
+}
+
+func (c *bpeCounter) countPiece(piece string) int {
+	if _, ok := c.ranks[piece]; ok {
+		return 1
+	}
+	c.mu.Lock()
+	n, ok := c.cache[piece]
+	c.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = len(bpeMerge(c.ranks, piece))
+	c.mu.Lock()
+	if len(c.cache) >= bpeCacheLimit {
+		c.cache = make(map[string]int)
+	}
+	c.cache[piece] = n
+	c.mu.Unlock()
+	return n
+}
+
+// bpeMerge splits piece into ranked tokens by repeatedly merging the
+// adjacent pair with the lowest rank, as tiktoken does.
+func bpeMerge(ranks map[string]int, piece string) []string {
+	parts := make([]string, 0, len(piece))
+	for i := 0; i < len(piece); i++ {
+		parts = append(parts, piece[i:i+1])
+	}
+	for len(parts) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i+1 < len(parts); i++ {
+			rank, ok := ranks[parts[i]+parts[i+1]]
+			if ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
+
+// splitPretokens splits text the way cl100k_base's pattern does:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	 ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+// Go's regexp has no lookahead, so the alternatives are matched by hand in
+// the same order. Installed o200k_base ranks are split with this pattern too,
+// which differs from o200k's own only around case changes and apostrophes.
+func splitPretokens(text string) []string {
+	var pieces []string
+	for i := 0; i < len(text); {
+		n := matchPretoken(text[i:])
+		pieces = append(pieces, text[i:i+n])
+		i += n
+	}
+	return pieces
+}
+
+func matchPretoken(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+
+	if r == '\'' {
+		rest := strings.ToLower(s[size:min(len(s), size+2)])
+		for _, suffix := range []string{"re", "ve", "ll", "s", "t", "m", "d"} {
+			if strings.HasPrefix(rest, suffix) {
+				return size + len(suffix)
+			}
+		}
+	}
+
+	if unicode.IsLetter(r) {
+		return size + spanRunes(s[size:], unicode.IsLetter, -1)
+	}
+	if r != '\r' && r != '\n' && !unicode.IsNumber(r) {
+		if next := spanRunes(s[size:], unicode.IsLetter, -1); next > 0 {
+			return size + next
+		}
+	}
+
+	if unicode.IsNumber(r) {
+		return size + spanRunes(s[size:], unicode.IsNumber, 2)
+	}
+
+	isPunct := func(r rune) bool { return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r) }
+	start := 0
+	if r == ' ' {
+		start = size
+	}
+	if punct := spanRunes(s[start:], isPunct, -1); punct > 0 {
+		end := start + punct
+		return end + spanRunes(s[end:], func(r rune) bool { return r == '\r' || r == '\n' }, -1)
+	}
+
+	if unicode.IsSpace(r) {
+		run := spanRunes(s, unicode.IsSpace, -1)
+		if lastNewline := strings.LastIndexAny(s[:run], "\r\n"); lastNewline >= 0 {
+			return lastNewline + 1
+		}
+		if run == len(s) {
+			return run
+		}
+		// Leave the last space to prefix the following word.
+		_, lastSize := utf8.DecodeLastRuneInString(s[:run])
+		if run-lastSize > 0 {
+			return run - lastSize
+		}
+		return run
+	}
+
+	return size
+}
+
+// spanRunes returns the byte length of the leading runes of s that satisfy
+// match, stopping after limit runes when limit is non-negative.
+func spanRunes(s string, match func(rune) bool, limit int) int {
+	n, count := 0, 0
+	for n < len(s) && (limit < 0 || count < limit) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !match(r) {
+			break
+		}
+		n += size
+		count++
+	}
+	return n
+}
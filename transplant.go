@@ -10,14 +10,55 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 )
 
 type transplantOptions struct {
-	apply  bool
-	dryRun bool
+	apply     bool
+	dryRun    bool
+	selection transplantSelection
+
+	// Each side is given as a conversation ID or a session ID; the target
+	// can also be the agent's newest other conversation (--to latest).
+	sourceConversationID int64
+	targetConversationID int64
+	fromSession          string
+	toSession            string
+	toLatest             bool
+
+	// sourceDB and targetDB name the lcm.db files on each side; empty means
+	// the default database.
+	sourceDB string
+	targetDB string
+}
+
+// transplantSelection narrows which source context summaries are carried
+// over. The zero value selects all of them.
+type transplantSelection struct {
+	summaryIDs []string
+	minDepth   int
+	maxTokens  int // 0 means no budget
+}
+
+func (s transplantSelection) active() bool {
+	return len(s.summaryIDs) > 0 || s.minDepth > 0 || s.maxTokens > 0
+}
+
+func (s transplantSelection) describe() string {
+	var parts []string
+	if len(s.summaryIDs) > 0 {
+		parts = append(parts, fmt.Sprintf("%d summary IDs", len(s.summaryIDs)))
+	}
+	if s.minDepth > 0 {
+		parts = append(parts, fmt.Sprintf("depth >= %d", s.minDepth))
+	}
+	if s.maxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("budget %dt", s.maxTokens))
+	}
+	return strings.Join(parts, ", ")
 }
 
 type transplantContextSummary struct {
@@ -63,11 +104,19 @@ type transplantPlan struct {
 	targetContext        transplantContextStats
 	contextTokenOverhead int
 	duplicates           []transplantDuplicate
+	selection            transplantSelection
+	// skippedContext holds the source context summaries the selection left
+	// out; coverage maps context summary IDs to the messages they cover when
+	// a token budget ranked them.
+	skippedContext []transplantContextSummary
+	coverage       map[string]int
+	// crossDB is set when source and target are different lcm.db files.
+	crossDB *transplantCrossDB
 }
 
 // runTransplantCommand executes the standalone transplant CLI path.
 func runTransplantCommand(args []string) error {
-	opts, sourceConversationID, targetConversationID, err := parseTransplantArgs(args)
+	opts, err := parseTransplantArgs(args)
 	if err != nil {
 		return err
 	}
@@ -77,18 +126,51 @@ func runTransplantCommand(args []string) error {
 		return err
 	}
 
-	db, err := openLCMDB(paths.lcmDBPath)
+	sourcePath, targetPath := opts.sourceDB, opts.targetDB
+	if sourcePath == "" {
+		sourcePath = paths.lcmDBPath
+	}
+	if targetPath == "" {
+		targetPath = paths.lcmDBPath
+	}
+	sameDB, err := sameDBFile(sourcePath, targetPath)
+	if err != nil {
+		return err
+	}
+
+	db, err := openLCMDB(targetPath, accessFor(opts.apply))
 	if err != nil {
 		return err
 	}
 	defer db.Close()
+	source := db
+	if !sameDB {
+		source, err = openLCMDB(sourcePath, dbReadOnly)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+	}
 
 	ctx := context.Background()
-	plan, err := buildTransplantPlan(ctx, db, sourceConversationID, targetConversationID)
+	if err := resolveTransplantEndpoints(ctx, source, db, paths, &opts); err != nil {
+		return err
+	}
+	sourceConversationID, targetConversationID := opts.sourceConversationID, opts.targetConversationID
+	var plan transplantPlan
+	if sameDB {
+		plan, err = buildTransplantPlan(ctx, db, sourceConversationID, targetConversationID, opts.selection)
+	} else {
+		plan, err = buildCrossDBTransplantPlan(ctx, source, db, sourcePath, targetPath, sourceConversationID, targetConversationID, opts.selection)
+	}
 	if err != nil {
 		return err
 	}
 	if len(plan.sourceContext) == 0 {
+		if len(plan.skippedContext) > 0 {
+			fmt.Printf("No source context summaries match the selection (%s). Nothing to transplant.\n", opts.selection.describe())
+			return nil
+		}
 		fmt.Printf("Source conversation %d has no summary context items. Nothing to transplant.\n", sourceConversationID)
 		return nil
 	}
@@ -104,45 +186,115 @@ func runTransplantCommand(args []string) error {
 		return nil
 	}
 
-	copied, err := applyTransplant(ctx, db, plan)
+	fmt.Println()
+	targetPaths, err := pathsForDB(paths, targetPath)
+	if err != nil {
+		return err
+	}
+	if err := backupBeforeApply(ctx, db, targetPaths, fmt.Sprintf("transplant-conv%d-to-conv%d", sourceConversationID, targetConversationID)); err != nil {
+		return err
+	}
+	var copied int
+	var opID int64
+	if plan.crossDB != nil {
+		copied, opID, err = applyCrossDBTransplant(ctx, db, plan, os.Stdout)
+	} else {
+		copied, opID, err = applyTransplant(ctx, db, plan, os.Stdout)
+	}
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("\nDone. %d summaries copied. %d context items prepended to conversation %d.\n", copied, len(plan.sourceContext), targetConversationID)
+	fmt.Printf("Undo with: %s\n", undoCommandFor(paths, targetPaths.lcmDBPath, opID))
 	return nil
 }
 
-func parseTransplantArgs(args []string) (transplantOptions, int64, int64, error) {
+func parseTransplantArgs(args []string) (transplantOptions, error) {
 	fs := flag.NewFlagSet("transplant", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
 
 	apply := fs.Bool("apply", false, "apply transplant to the DB")
 	dryRun := fs.Bool("dry-run", true, "show what would be transplanted")
+	summaryIDs := fs.String("summary-id", "", "comma-separated context summary IDs to transplant")
+	minDepth := fs.Int("min-depth", 0, "only transplant context summaries at or above this depth")
+	maxTokens := fs.String("max-tokens", "", "token budget for the transplanted context summaries (e.g. 20000, 20k)")
+	fromSession := fs.String("from-session", "", "source session ID")
+	toSession := fs.String("to-session", "", "target session ID")
+	to := fs.String("to", "", "target conversation ID, or latest for the agent's newest other conversation")
+	sourceDB := fs.String("source-db", "", "lcm.db to transplant from (default: ~/.openclaw/lcm.db)")
+	targetDB := fs.String("target-db", "", "lcm.db to transplant into (default: ~/.openclaw/lcm.db)")
 
 	normalizedArgs, err := normalizeTransplantArgs(args)
 	if err != nil {
-		return transplantOptions{}, 0, 0, fmt.Errorf("%w\n%s", err, transplantUsageText())
+		return transplantOptions{}, fmt.Errorf("%w\n%s", err, transplantUsageText())
 	}
 	if err := fs.Parse(normalizedArgs); err != nil {
-		return transplantOptions{}, 0, 0, fmt.Errorf("%w\n%s", err, transplantUsageText())
+		return transplantOptions{}, fmt.Errorf("%w\n%s", err, transplantUsageText())
 	}
-	if fs.NArg() != 2 {
-		return transplantOptions{}, 0, 0, fmt.Errorf("source and target conversation IDs are required\n%s", transplantUsageText())
+	opts := transplantOptions{
+		apply:  *apply,
+		dryRun: *dryRun,
+		selection: transplantSelection{
+			minDepth: *minDepth,
+		},
+		fromSession: strings.TrimSpace(*fromSession),
+		toSession:   strings.TrimSpace(*toSession),
+		sourceDB:    strings.TrimSpace(*sourceDB),
+		targetDB:    strings.TrimSpace(*targetDB),
+	}
+
+	positionals := fs.Args()
+	if opts.fromSession == "" {
+		if len(positionals) == 0 {
+			return transplantOptions{}, fmt.Errorf("a source conversation ID or --from-session is required\n%s", transplantUsageText())
+		}
+		id, err := strconv.ParseInt(positionals[0], 10, 64)
+		if err != nil {
+			return transplantOptions{}, fmt.Errorf("parse source conversation ID %q: %w", positionals[0], err)
+		}
+		opts.sourceConversationID = id
+		positionals = positionals[1:]
 	}
-
-	sourceConversationID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
-	if err != nil {
-		return transplantOptions{}, 0, 0, fmt.Errorf("parse source conversation ID %q: %w", fs.Arg(0), err)
+	targets := len(positionals)
+	if opts.toSession != "" {
+		targets++
 	}
-	targetConversationID, err := strconv.ParseInt(fs.Arg(1), 10, 64)
-	if err != nil {
-		return transplantOptions{}, 0, 0, fmt.Errorf("parse target conversation ID %q: %w", fs.Arg(1), err)
+	if *to != "" {
+		targets++
+	}
+	if targets != 1 || len(positionals) > 1 {
+		return transplantOptions{}, fmt.Errorf("exactly one target is required: a conversation ID, --to-session or --to\n%s", transplantUsageText())
+	}
+	target := strings.TrimSpace(*to)
+	if len(positionals) == 1 {
+		target = positionals[0]
+	}
+	switch {
+	case target == "latest":
+		opts.toLatest = true
+	case target != "":
+		id, err := strconv.ParseInt(target, 10, 64)
+		if err != nil {
+			return transplantOptions{}, fmt.Errorf("parse target conversation ID %q: %w", target, err)
+		}
+		opts.targetConversationID = id
 	}
 
-	opts := transplantOptions{
-		apply:  *apply,
-		dryRun: *dryRun,
+	for _, id := range strings.Split(*summaryIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			opts.selection.summaryIDs = append(opts.selection.summaryIDs, id)
+		}
+	}
+	if *minDepth < 0 {
+		return transplantOptions{}, fmt.Errorf("--min-depth must be >= 0\n%s", transplantUsageText())
+	}
+	if *maxTokens != "" {
+		limit, err := parseTokenLimit(*maxTokens)
+		if err != nil {
+			return transplantOptions{}, fmt.Errorf("--max-tokens: %w\n%s", err, transplantUsageText())
+		}
+		opts.selection.maxTokens = limit
 	}
 	if opts.apply {
 		opts.dryRun = false
@@ -150,19 +302,26 @@ func parseTransplantArgs(args []string) (transplantOptions, int64, int64, error)
 	if !opts.apply {
 		opts.dryRun = true
 	}
-	return opts, sourceConversationID, targetConversationID, nil
+	return opts, nil
 }
 
 func normalizeTransplantArgs(args []string) ([]string, error) {
 	flags := make([]string, 0, len(args))
 	positionals := make([]string, 0, 2)
 
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
 		switch arg {
 		case "--apply", "--dry-run":
 			flags = append(flags, arg)
 		case "--help", "-h":
 			flags = append(flags, arg)
+		case "--summary-id", "--min-depth", "--max-tokens", "--from-session", "--to-session", "--to", "--source-db", "--target-db":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("missing value for %s", arg)
+			}
+			flags = append(flags, arg, args[i+1])
+			i++
 		default:
 			if strings.HasPrefix(arg, "--") {
 				flags = append(flags, arg)
@@ -179,17 +338,47 @@ func transplantUsageText() string {
 Usage:
   lcm-tui transplant <source_conversation_id> <target_conversation_id> [--dry-run]
   lcm-tui transplant <source_conversation_id> <target_conversation_id> --apply
+  lcm-tui transplant --from-session <session_id> --to-session <session_id> [--apply]
+  lcm-tui transplant <source_conversation_id>|--from-session <id> --to latest [--apply]
+
+--to latest targets the most recently updated other conversation whose
+session belongs to the same agent as the source.
+
+Copies the source's context summaries, with their full DAG, to the front of
+the target's context. To carry only part of the memory forward:
+  --summary-id a,b,c   only these source context summaries
+  --min-depth N        only context summaries at depth N or higher
+  --max-tokens N       the subset covering the most source messages whose
+                       context tokens fit in N (accepts 20000, 20k)
+The filters combine; --max-tokens picks from what the others leave.
+
+To transplant between two lcm.db files:
+  --source-db PATH     database holding the source conversation
+  --target-db PATH     database holding the target conversation
+Either defaults to ~/.openclaw/lcm.db. Across files the messages the
+summaries cover are copied too (with their message_parts, under new IDs);
+messages the target conversation already holds with the same role and
+content are linked instead. large_files rows are not copied. The snapshot
+and the undo journal belong to the target database: manage them with
+lcm-tui backups --db and lcm-tui undo --db. --from-session resolves in the
+source database; --to-session and --to latest in the target.
 `)
 }
 
 // buildTransplantPlan gathers source context summaries, recursively resolves the
 // full parent DAG, and computes a deterministic copy order (d0 -> dN).
-func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID, targetConversationID int64) (transplantPlan, error) {
+func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID, targetConversationID int64, selection transplantSelection) (transplantPlan, error) {
 	if sourceConversationID == targetConversationID {
 		return transplantPlan{}, errors.New("source and target conversation IDs must be different")
 	}
+	return buildTransplantPlanAcross(ctx, q, q, sourceConversationID, targetConversationID, selection)
+}
 
-	sourceExists, err := conversationExists(ctx, q, sourceConversationID)
+// buildTransplantPlanAcross reads the source side from source and the target
+// side from target, which are the same handle unless the transplant crosses
+// databases.
+func buildTransplantPlanAcross(ctx context.Context, source, target sqlQueryer, sourceConversationID, targetConversationID int64, selection transplantSelection) (transplantPlan, error) {
+	sourceExists, err := conversationExists(ctx, source, sourceConversationID)
 	if err != nil {
 		return transplantPlan{}, err
 	}
@@ -197,7 +386,7 @@ func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID
 		return transplantPlan{}, fmt.Errorf("source conversation %d not found", sourceConversationID)
 	}
 
-	targetExists, err := conversationExists(ctx, q, targetConversationID)
+	targetExists, err := conversationExists(ctx, target, targetConversationID)
 	if err != nil {
 		return transplantPlan{}, err
 	}
@@ -205,7 +394,11 @@ func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID
 		return transplantPlan{}, fmt.Errorf("target conversation %d not found", targetConversationID)
 	}
 
-	sourceContext, err := loadSourceContextSummaries(ctx, q, sourceConversationID)
+	allContext, err := loadSourceContextSummaries(ctx, source, sourceConversationID)
+	if err != nil {
+		return transplantPlan{}, err
+	}
+	sourceContext, skipped, coverage, err := selectTransplantContext(ctx, source, sourceConversationID, allContext, selection)
 	if err != nil {
 		return transplantPlan{}, err
 	}
@@ -213,6 +406,8 @@ func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID
 		return transplantPlan{
 			sourceConversationID: sourceConversationID,
 			targetConversationID: targetConversationID,
+			selection:            selection,
+			skippedContext:       skipped,
 		}, nil
 	}
 
@@ -223,11 +418,11 @@ func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID
 		contextTokenOverhead += item.tokenCount
 	}
 
-	allSummaryIDs, err := collectSummaryDAGIDs(ctx, q, rootIDs)
+	allSummaryIDs, err := collectSummaryDAGIDs(ctx, source, rootIDs)
 	if err != nil {
 		return transplantPlan{}, err
 	}
-	allSummaries, err := loadSummariesByIDs(ctx, q, allSummaryIDs)
+	allSummaries, err := loadSummariesByIDs(ctx, source, allSummaryIDs)
 	if err != nil {
 		return transplantPlan{}, err
 	}
@@ -256,12 +451,12 @@ func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID
 		depthCounts[summary.depth]++
 	}
 
-	targetContext, err := loadContextStats(ctx, q, targetConversationID)
+	targetContext, err := loadContextStats(ctx, target, targetConversationID)
 	if err != nil {
 		return transplantPlan{}, err
 	}
 
-	duplicates, err := detectSummaryContentDuplicates(ctx, q, targetConversationID, ordered)
+	duplicates, err := detectSummaryContentDuplicates(ctx, target, targetConversationID, ordered)
 	if err != nil {
 		return transplantPlan{}, err
 	}
@@ -275,9 +470,237 @@ func buildTransplantPlan(ctx context.Context, q sqlQueryer, sourceConversationID
 		targetContext:        targetContext,
 		contextTokenOverhead: contextTokenOverhead,
 		duplicates:           duplicates,
+		selection:            selection,
+		skippedContext:       skipped,
+		coverage:             coverage,
 	}, nil
 }
 
+// selectTransplantContext applies the selection to the source context
+// summaries, keeping ordinal order. With a token budget it also returns how
+// many source messages each candidate covers, which is the value it
+// maximizes.
+func selectTransplantContext(ctx context.Context, q sqlQueryer, sourceConversationID int64, items []transplantContextSummary, selection transplantSelection) ([]transplantContextSummary, []transplantContextSummary, map[string]int, error) {
+	if !selection.active() {
+		return items, nil, nil, nil
+	}
+
+	wanted := make(map[string]bool, len(selection.summaryIDs))
+	for _, id := range selection.summaryIDs {
+		wanted[id] = true
+	}
+	inContext := make(map[string]bool, len(items))
+	for _, item := range items {
+		inContext[item.summaryID] = true
+	}
+	for _, id := range selection.summaryIDs {
+		if !inContext[id] {
+			return nil, nil, nil, fmt.Errorf("summary %s is not a context summary of conversation %d", id, sourceConversationID)
+		}
+	}
+
+	var candidates, skipped []transplantContextSummary
+	for _, item := range items {
+		if (len(wanted) > 0 && !wanted[item.summaryID]) || item.depth < selection.minDepth {
+			skipped = append(skipped, item)
+			continue
+		}
+		candidates = append(candidates, item)
+	}
+	if selection.maxTokens <= 0 {
+		return candidates, skipped, nil, nil
+	}
+
+	coverage := make(map[string]int, len(candidates))
+	weights := make([]int, len(candidates))
+	values := make([]int, len(candidates))
+	for i, item := range candidates {
+		covered, err := countCoveredMessages(ctx, q, item.summaryID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		coverage[item.summaryID] = covered
+		weights[i] = item.tokenCount
+		// Every summary carries some memory, even one whose message links
+		// are missing.
+		values[i] = max(covered, 1)
+	}
+
+	keep := pickTransplantBudget(weights, values, selection.maxTokens)
+	var selected []transplantContextSummary
+	for i, item := range candidates {
+		if keep[i] {
+			selected = append(selected, item)
+		} else {
+			skipped = append(skipped, item)
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].ordinal < skipped[j].ordinal })
+	return selected, skipped, coverage, nil
+}
+
+// countCoveredMessages returns how many distinct source messages a summary's
+// DAG summarizes.
+func countCoveredMessages(ctx context.Context, q sqlQueryer, summaryID string) (int, error) {
+	ids, err := collectSummaryDAGIDs(ctx, q, []string{summaryID})
+	if err != nil {
+		return 0, err
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	var count int
+	if err := q.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(DISTINCT message_id)
+		FROM summary_messages
+		WHERE summary_id IN (%s)
+	`, strings.TrimRight(strings.Repeat("?,", len(ids)), ",")), args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count messages covered by %s: %w", summaryID, err)
+	}
+	return count, nil
+}
+
+// pickTransplantBudget solves the 0/1 knapsack: the subset with the highest
+// total value whose weights fit the budget. Large budgets are solved in
+// coarser token units, rounding weights up so the result still fits.
+func pickTransplantBudget(weights, values []int, budget int) []bool {
+	const maxCells = 20000
+	unit := max(1, (budget+maxCells-1)/maxCells)
+	capacity := budget / unit
+
+	scaled := make([]int, len(weights))
+	for i, w := range weights {
+		scaled[i] = (w + unit - 1) / unit
+	}
+
+	best := make([]int, capacity+1)
+	taken := make([][]bool, len(weights))
+	for i := range weights {
+		taken[i] = make([]bool, capacity+1)
+		for c := capacity; c >= scaled[i]; c-- {
+			if candidate := best[c-scaled[i]] + values[i]; candidate > best[c] {
+				best[c] = candidate
+				taken[i][c] = true
+			}
+		}
+	}
+
+	keep := make([]bool, len(weights))
+	c := capacity
+	for i := len(weights) - 1; i >= 0; i-- {
+		if taken[i][c] {
+			keep[i] = true
+			c -= scaled[i]
+		}
+	}
+	return keep
+}
+
+// resolveTransplantEndpoints turns session IDs and --to latest into
+// conversation IDs. The source side resolves against source and the target
+// side against target, which are the same handle unless --source-db or
+// --target-db names another file.
+func resolveTransplantEndpoints(ctx context.Context, source, target *sql.DB, paths appDataPaths, opts *transplantOptions) error {
+	if opts.fromSession != "" {
+		id, err := lookupConversationID(ctx, source, opts.fromSession)
+		if err != nil {
+			return fmt.Errorf("resolve --from-session: %w", err)
+		}
+		opts.sourceConversationID = id
+	}
+	if opts.toSession != "" {
+		id, err := lookupConversationID(ctx, target, opts.toSession)
+		if err != nil {
+			return fmt.Errorf("resolve --to-session: %w", err)
+		}
+		opts.targetConversationID = id
+	}
+	if opts.toLatest {
+		sourceSession := opts.fromSession
+		if sourceSession == "" {
+			session, err := lookupConversationSession(ctx, source, opts.sourceConversationID)
+			if err != nil {
+				return err
+			}
+			sourceSession = session
+		}
+		// Conversation IDs only collide within one database.
+		exclude := opts.sourceConversationID
+		if source != target {
+			exclude = 0
+		}
+		id, session, err := latestAgentConversation(ctx, target, paths.agentsDir, sourceSession, exclude)
+		if err != nil {
+			return err
+		}
+		opts.targetConversationID = id
+		fmt.Printf("--to latest: conversation %d (session %s)\n\n", id, session)
+	}
+	return nil
+}
+
+func lookupConversationSession(ctx context.Context, q sqlQueryer, conversationID int64) (string, error) {
+	var sessionID string
+	err := q.QueryRowContext(ctx, `
+		SELECT session_id FROM conversations WHERE conversation_id = ?
+	`, conversationID).Scan(&sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("conversation %d not found", conversationID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("lookup session for conversation %d: %w", conversationID, err)
+	}
+	return sessionID, nil
+}
+
+// latestAgentConversation finds the agent whose sessions directory holds
+// sessionID and returns its most recently updated conversation other than
+// excludeConversationID.
+func latestAgentConversation(ctx context.Context, q sqlQueryer, agentsDir, sessionID string, excludeConversationID int64) (int64, string, error) {
+	agents, err := loadAgents(agentsDir)
+	if err != nil {
+		return 0, "", err
+	}
+	idx, ok := findAgentForSession(agents, sessionID)
+	if !ok {
+		return 0, "", fmt.Errorf("session %s not found under any agent in %s", sessionID, agentsDir)
+	}
+	files, err := discoverSessionFiles(agents[idx])
+	if err != nil {
+		return 0, "", err
+	}
+	agentSessions := make(map[string]bool, len(files))
+	for _, file := range files {
+		agentSessions[strings.TrimSuffix(file.filename, ".jsonl")] = true
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT conversation_id, session_id
+		FROM conversations
+		WHERE conversation_id != ?
+		ORDER BY updated_at DESC, conversation_id DESC
+	`, excludeConversationID)
+	if err != nil {
+		return 0, "", fmt.Errorf("query conversations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var session string
+		if err := rows.Scan(&id, &session); err != nil {
+			return 0, "", fmt.Errorf("scan conversation: %w", err)
+		}
+		if agentSessions[session] {
+			return id, session, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("iterate conversations: %w", err)
+	}
+	return 0, "", fmt.Errorf("agent %s has no other conversation to transplant into", agents[idx].name)
+}
+
 func conversationExists(ctx context.Context, q sqlQueryer, conversationID int64) (bool, error) {
 	var count int
 	if err := q.QueryRowContext(ctx, `
@@ -552,17 +975,46 @@ func contentSHA256(content string) string {
 }
 
 func printTransplantDryRunReport(plan transplantPlan) {
-	fmt.Printf("Transplant: conversation %d -> conversation %d\n\n", plan.sourceConversationID, plan.targetConversationID)
+	for _, line := range transplantReportLines(plan) {
+		fmt.Println(line)
+	}
+	if len(plan.duplicates) > 0 {
+		fmt.Println("Aborting apply to avoid duplicate transplants.")
+		return
+	}
+	fmt.Println()
+	fmt.Println("Run with --apply to execute.")
+}
+
+// transplantReportLines renders the dry-run report shared by the CLI and
+// the TUI confirmation panel.
+func transplantReportLines(plan transplantPlan) []string {
+	lines := []string{fmt.Sprintf("Transplant: conversation %d -> conversation %d", plan.sourceConversationID, plan.targetConversationID)}
+	if plan.crossDB != nil {
+		lines = append(lines,
+			fmt.Sprintf("  from %s", plan.crossDB.sourcePath),
+			fmt.Sprintf("  into %s", plan.crossDB.targetPath))
+	}
+	lines = append(lines, "")
 
-	fmt.Printf("Source context summaries (%d):\n", len(plan.sourceContext))
+	if plan.selection.active() {
+		lines = append(lines, fmt.Sprintf("Selection (%s): %d of %d context summaries",
+			plan.selection.describe(), len(plan.sourceContext), len(plan.sourceContext)+len(plan.skippedContext)))
+	}
+	lines = append(lines, fmt.Sprintf("Source context summaries (%d):", len(plan.sourceContext)))
 	for _, item := range plan.sourceContext {
-		preview := previewForLog(item.content, 56)
-		fmt.Printf("  %s  %-9s d%d  %dt  %q\n", item.summaryID, item.kind, item.depth, item.tokenCount, preview)
+		lines = append(lines, formatTransplantContextLine(item, plan.coverage))
 	}
-	fmt.Println()
+	if len(plan.skippedContext) > 0 {
+		lines = append(lines, fmt.Sprintf("Left behind (%d):", len(plan.skippedContext)))
+		for _, item := range plan.skippedContext {
+			lines = append(lines, formatTransplantContextLine(item, plan.coverage))
+		}
+	}
+	lines = append(lines, "")
 
 	ancestorCount := len(plan.ordered) - len(plan.sourceContext)
-	fmt.Printf("Full DAG to copy: %d summaries (%d context + %d ancestors)\n", len(plan.ordered), len(plan.sourceContext), ancestorCount)
+	lines = append(lines, fmt.Sprintf("Full DAG to copy: %d summaries (%d context + %d ancestors)", len(plan.ordered), len(plan.sourceContext), ancestorCount))
 	depths := make([]int, 0, len(plan.depthCounts))
 	for depth := range plan.depthCounts {
 		depths = append(depths, depth)
@@ -573,45 +1025,57 @@ func printTransplantDryRunReport(plan transplantPlan) {
 		if depth == 0 {
 			label = "leaves"
 		}
-		fmt.Printf("  d%d: %d %s\n", depth, plan.depthCounts[depth], label)
+		lines = append(lines, fmt.Sprintf("  d%d: %d %s", depth, plan.depthCounts[depth], label))
+	}
+	lines = append(lines, "")
+
+	lines = append(lines,
+		fmt.Sprintf("Target current context (%d items):", plan.targetContext.total),
+		fmt.Sprintf("  %d summaries + %d messages", plan.targetContext.summaries, plan.targetContext.messages),
+		"",
+		"After transplant:",
+		fmt.Sprintf("  %d new context items prepended", len(plan.sourceContext)),
+		fmt.Sprintf("  %d summaries copied (new IDs, owned by conversation %d)", len(plan.ordered), plan.targetConversationID),
+		fmt.Sprintf("  Estimated token overhead in context: ~%d tokens", plan.contextTokenOverhead),
+	)
+	if cross := plan.crossDB; cross != nil {
+		lines = append(lines,
+			fmt.Sprintf("  %d messages copied with %d message parts (new IDs, after the target's last message)", len(cross.messages), len(cross.parts)),
+			fmt.Sprintf("  %d messages already in the target by content hash (linked, not copied)", len(cross.reused)),
+		)
 	}
-	fmt.Println()
-
-	fmt.Printf("Target current context (%d items):\n", plan.targetContext.total)
-	fmt.Printf("  %d summaries + %d messages\n\n", plan.targetContext.summaries, plan.targetContext.messages)
-
-	fmt.Println("After transplant:")
-	fmt.Printf("  %d new context items prepended\n", len(plan.sourceContext))
-	fmt.Printf("  %d summaries copied (new IDs, owned by conversation %d)\n", len(plan.ordered), plan.targetConversationID)
-	fmt.Printf("  Estimated token overhead in context: ~%d tokens\n", plan.contextTokenOverhead)
 
 	if len(plan.duplicates) > 0 {
-		fmt.Println()
-		fmt.Printf("Warning: found %d source summaries with content already present in target conversation.\n", len(plan.duplicates))
+		lines = append(lines, "", fmt.Sprintf("Warning: found %d source summaries with content already present in target conversation.", len(plan.duplicates)))
 		limit := len(plan.duplicates)
 		if limit > 5 {
 			limit = 5
 		}
 		for _, duplicate := range plan.duplicates[:limit] {
-			fmt.Printf("  %s  hash=%s  matches_in_target=%d\n", duplicate.summaryID, duplicate.contentHash, duplicate.targetCount)
+			lines = append(lines, fmt.Sprintf("  %s  hash=%s  matches_in_target=%d", duplicate.summaryID, duplicate.contentHash, duplicate.targetCount))
 		}
 		if len(plan.duplicates) > limit {
-			fmt.Printf("  ... and %d more\n", len(plan.duplicates)-limit)
+			lines = append(lines, fmt.Sprintf("  ... and %d more", len(plan.duplicates)-limit))
 		}
-		fmt.Println("Aborting apply to avoid duplicate transplants.")
-		return
 	}
+	return lines
+}
 
-	fmt.Println()
-	fmt.Println("Run with --apply to execute.")
+func formatTransplantContextLine(item transplantContextSummary, coverage map[string]int) string {
+	line := fmt.Sprintf("  %s  %-9s d%d  %dt", item.summaryID, item.kind, item.depth, item.tokenCount)
+	if covered, ok := coverage[item.summaryID]; ok {
+		line += fmt.Sprintf("  %d msgs", covered)
+	}
+	return line + fmt.Sprintf("  %q", previewForLog(item.content, 56))
 }
 
 // applyTransplant copies summaries, edges, and context items in a single
-// transaction. New summaries are owned by the target conversation.
-func applyTransplant(ctx context.Context, db *sql.DB, plan transplantPlan) (int, error) {
+// transaction, reporting each copied summary to out. New summaries are owned
+// by the target conversation.
+func applyTransplant(ctx context.Context, db *sql.DB, plan transplantPlan, out io.Writer) (int, int64, error) {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, fmt.Errorf("begin transplant transaction: %w", err)
+		return 0, 0, fmt.Errorf("begin transplant transaction: %w", err)
 	}
 
 	rollbackNeeded := true
@@ -621,18 +1085,28 @@ func applyTransplant(ctx context.Context, db *sql.DB, plan transplantPlan) (int,
 		}
 	}()
 
+	description := fmt.Sprintf("transplant %d summaries from conversation %d", len(plan.ordered), plan.sourceConversationID)
+	j, err := startJournalOp(ctx, tx, "transplant", plan.targetConversationID, description)
+	if err != nil {
+		return 0, 0, err
+	}
+	contextBefore, err := captureContext(ctx, tx, plan.targetConversationID)
+	if err != nil {
+		return 0, 0, err
+	}
+
 	oldToNew := make(map[string]string, len(plan.ordered))
 	for i, source := range plan.ordered {
 		newSummaryID, err := generateSummaryID(ctx, tx)
 		if err != nil {
-			return i, err
+			return i, 0, err
 		}
 
 		if _, err := tx.ExecContext(ctx, `
 			INSERT INTO summaries (summary_id, conversation_id, kind, content, token_count, created_at, file_ids, depth)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		`, newSummaryID, plan.targetConversationID, source.kind, source.content, source.tokenCount, source.createdAt, source.fileIDs, source.depth); err != nil {
-			return i, fmt.Errorf("insert summary %s (from %s): %w", newSummaryID, source.summaryID, err)
+			return i, 0, fmt.Errorf("insert summary %s (from %s): %w", newSummaryID, source.summaryID, err)
 		}
 
 		if _, err := tx.ExecContext(ctx, `
@@ -642,26 +1116,35 @@ func applyTransplant(ctx context.Context, db *sql.DB, plan transplantPlan) (int,
 			WHERE summary_id = ?
 			ORDER BY ordinal ASC
 		`, newSummaryID, source.summaryID); err != nil {
-			return i, fmt.Errorf("copy summary_messages for %s: %w", source.summaryID, err)
+			return i, 0, fmt.Errorf("copy summary_messages for %s: %w", source.summaryID, err)
 		}
 
 		if err := copyRemappedParentEdges(ctx, tx, source.summaryID, newSummaryID, oldToNew); err != nil {
-			return i, err
+			return i, 0, err
+		}
+
+		for _, table := range []string{"summaries", "summary_messages", "summary_parents"} {
+			if err := j.recordInsert(ctx, table, "summary_id = ?", newSummaryID); err != nil {
+				return i, 0, err
+			}
 		}
 
 		oldToNew[source.summaryID] = newSummaryID
-		fmt.Printf("[%d/%d] %s -> %s (%s, d%d)\n", i+1, len(plan.ordered), source.summaryID, newSummaryID, source.kind, source.depth)
+		fmt.Fprintf(out, "[%d/%d] %s -> %s (%s, d%d)\n", i+1, len(plan.ordered), source.summaryID, newSummaryID, source.kind, source.depth)
 	}
 
 	if err := prependTransplantedContextItems(ctx, tx, plan.targetConversationID, plan.sourceContext, oldToNew); err != nil {
-		return len(plan.ordered), err
+		return len(plan.ordered), 0, err
+	}
+	if err := j.recordContext(ctx, plan.targetConversationID, contextBefore); err != nil {
+		return len(plan.ordered), 0, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return len(plan.ordered), fmt.Errorf("commit transplant transaction: %w", err)
+		return len(plan.ordered), 0, fmt.Errorf("commit transplant transaction: %w", err)
 	}
 	rollbackNeeded = false
-	return len(plan.ordered), nil
+	return len(plan.ordered), j.opID, nil
 }
 
 func copyRemappedParentEdges(ctx context.Context, q sqlQueryer, oldSummaryID, newSummaryID string, oldToNew map[string]string) error {
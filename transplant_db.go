@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// transplantCrossDB is what a transplant between two lcm.db files needs on
+// top of the summaries. summary_messages cannot point across files, so the
+// source messages (and their parts) are copied into the target conversation
+// with new IDs; messages the target already holds, by role and contentSHA256,
+// are linked instead of copied.
+type transplantCrossDB struct {
+	sourcePath      string
+	targetPath      string
+	targetSessionID string
+	messages        []journalRow // source rows to copy, in source order
+	parts           []journalRow
+	reused          map[int64]int64 // source message ID -> matching target message ID
+	links           map[string][]transplantLink
+	edges           map[string][]transplantEdge
+}
+
+// transplantLink is one source summary_messages row.
+type transplantLink struct {
+	messageID int64
+	ordinal   int64
+}
+
+// transplantEdge is one source summary_parents row.
+type transplantEdge struct {
+	parentSummaryID string
+	ordinal         int64
+}
+
+// sameDBFile reports whether two paths name the same database file.
+func sameDBFile(a, b string) (bool, error) {
+	left, err := os.Stat(a)
+	if err != nil {
+		return false, fmt.Errorf("stat %s: %w", a, err)
+	}
+	right, err := os.Stat(b)
+	if err != nil {
+		return false, fmt.Errorf("stat %s: %w", b, err)
+	}
+	return os.SameFile(left, right), nil
+}
+
+// buildCrossDBTransplantPlan builds the usual plan across two handles and
+// gathers the source rows the copy needs.
+func buildCrossDBTransplantPlan(ctx context.Context, source, target *sql.DB, sourcePath, targetPath string, sourceConversationID, targetConversationID int64, selection transplantSelection) (transplantPlan, error) {
+	plan, err := buildTransplantPlanAcross(ctx, source, target, sourceConversationID, targetConversationID, selection)
+	if err != nil {
+		return transplantPlan{}, err
+	}
+	cross := &transplantCrossDB{
+		sourcePath: sourcePath,
+		targetPath: targetPath,
+		reused:     make(map[int64]int64),
+		links:      make(map[string][]transplantLink),
+		edges:      make(map[string][]transplantEdge),
+	}
+	plan.crossDB = cross
+	if len(plan.ordered) == 0 {
+		return plan, nil
+	}
+
+	cross.targetSessionID, err = lookupConversationSession(ctx, target, targetConversationID)
+	if err != nil {
+		return transplantPlan{}, err
+	}
+
+	var messageIDs []int64
+	seenMessages := make(map[int64]bool)
+	for _, summary := range plan.ordered {
+		links, err := loadTransplantLinks(ctx, source, summary.summaryID)
+		if err != nil {
+			return transplantPlan{}, err
+		}
+		cross.links[summary.summaryID] = links
+		for _, link := range links {
+			if !seenMessages[link.messageID] {
+				seenMessages[link.messageID] = true
+				messageIDs = append(messageIDs, link.messageID)
+			}
+		}
+		edges, err := loadTransplantEdges(ctx, source, summary.summaryID)
+		if err != nil {
+			return transplantPlan{}, err
+		}
+		cross.edges[summary.summaryID] = edges
+	}
+	sort.Slice(messageIDs, func(i, j int) bool { return messageIDs[i] < messageIDs[j] })
+
+	messages, err := captureRowsByIDs(ctx, source, "messages", "message_id", messageIDs)
+	if err != nil {
+		return transplantPlan{}, err
+	}
+	if len(messages) != len(messageIDs) {
+		return transplantPlan{}, fmt.Errorf("source summaries link %d messages but only %d exist; run lcm-tui doctor --fix=links on the source first", len(messageIDs), len(messages))
+	}
+
+	existing, err := loadMessageHashes(ctx, target, targetConversationID)
+	if err != nil {
+		return transplantPlan{}, err
+	}
+	var copyIDs []int64
+	for _, row := range messages {
+		id := rowInt64(row["message_id"])
+		if targetID, ok := existing[messageHashKey(fmt.Sprint(row["role"]), fmt.Sprint(row["content"]))]; ok {
+			cross.reused[id] = targetID
+			continue
+		}
+		cross.messages = append(cross.messages, row)
+		copyIDs = append(copyIDs, id)
+	}
+	cross.parts, err = captureRowsByIDs(ctx, source, "message_parts", "message_id", copyIDs)
+	if err != nil {
+		return transplantPlan{}, err
+	}
+	return plan, nil
+}
+
+func loadTransplantLinks(ctx context.Context, q sqlQueryer, summaryID string) ([]transplantLink, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT message_id, ordinal FROM summary_messages
+		WHERE summary_id = ?
+		ORDER BY ordinal ASC
+	`, summaryID)
+	if err != nil {
+		return nil, fmt.Errorf("query summary_messages for %s: %w", summaryID, err)
+	}
+	defer rows.Close()
+
+	var links []transplantLink
+	for rows.Next() {
+		var link transplantLink
+		if err := rows.Scan(&link.messageID, &link.ordinal); err != nil {
+			return nil, fmt.Errorf("scan summary_messages for %s: %w", summaryID, err)
+		}
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate summary_messages for %s: %w", summaryID, err)
+	}
+	return links, nil
+}
+
+func loadTransplantEdges(ctx context.Context, q sqlQueryer, summaryID string) ([]transplantEdge, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT parent_summary_id, ordinal FROM summary_parents
+		WHERE summary_id = ?
+		ORDER BY ordinal ASC
+	`, summaryID)
+	if err != nil {
+		return nil, fmt.Errorf("query parent edges for %s: %w", summaryID, err)
+	}
+	defer rows.Close()
+
+	var edges []transplantEdge
+	for rows.Next() {
+		var edge transplantEdge
+		if err := rows.Scan(&edge.parentSummaryID, &edge.ordinal); err != nil {
+			return nil, fmt.Errorf("scan parent edge for %s: %w", summaryID, err)
+		}
+		edges = append(edges, edge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate parent edges for %s: %w", summaryID, err)
+	}
+	return edges, nil
+}
+
+// captureRowsByIDs captures full rows whose key column is in ids, in
+// batches, ordered by the key.
+func captureRowsByIDs(ctx context.Context, q sqlQueryer, table, column string, ids []int64) ([]journalRow, error) {
+	const batchSize = 200
+	var result []journalRow
+	for start := 0; start < len(ids); start += batchSize {
+		batch := ids[start:min(len(ids), start+batchSize)]
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		where := fmt.Sprintf("%s IN (%s) ORDER BY %s ASC", column, strings.TrimRight(strings.Repeat("?,", len(batch)), ","), column)
+		rows, err := captureRows(ctx, q, table, where, args...)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rows...)
+	}
+	return result, nil
+}
+
+func messageHashKey(role, content string) string {
+	return role + ":" + contentSHA256(content)
+}
+
+// loadMessageHashes maps role and content hash to message ID for one
+// conversation.
+func loadMessageHashes(ctx context.Context, q sqlQueryer, conversationID int64) (map[string]int64, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT message_id, role, content FROM messages
+		WHERE conversation_id = ?
+		ORDER BY message_id ASC
+	`, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query messages for conversation %d: %w", conversationID, err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]int64)
+	for rows.Next() {
+		var id int64
+		var role, content string
+		if err := rows.Scan(&id, &role, &content); err != nil {
+			return nil, fmt.Errorf("scan message: %w", err)
+		}
+		if _, ok := hashes[messageHashKey(role, content)]; !ok {
+			hashes[messageHashKey(role, content)] = id
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages for conversation %d: %w", conversationID, err)
+	}
+	return hashes, nil
+}
+
+func rowInt64(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// tableColumns returns the column names of a table, so rows copied from a
+// database with a different schema version keep only what the target has.
+func tableColumns(ctx context.Context, q sqlQueryer, table string) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("read %s columns: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scan %s column: %w", table, err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate %s columns: %w", table, err)
+	}
+	return columns, nil
+}
+
+// insertCopiedRow inserts a row image, dropping columns the target table
+// does not have.
+func insertCopiedRow(ctx context.Context, q sqlQueryer, table string, columns map[string]bool, row journalRow) error {
+	filtered := make(journalRow, len(row))
+	for column, value := range row {
+		if columns[column] {
+			filtered[column] = value
+		}
+	}
+	names := make([]string, 0, len(filtered))
+	for column := range filtered {
+		names = append(names, column)
+	}
+	sort.Strings(names)
+	args := make([]any, len(names))
+	for i, column := range names {
+		args[i] = filtered[column]
+	}
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO `+table+` (`+strings.Join(names, ", ")+`)
+		VALUES (`+strings.TrimRight(strings.Repeat("?, ", len(names)), ", ")+`)
+	`, args...); err != nil {
+		return fmt.Errorf("insert copied %s row: %w", table, err)
+	}
+	return nil
+}
+
+// applyCrossDBTransplant copies messages, parts, summaries, edges and
+// context items into the target database in one journaled transaction.
+// Copied messages are numbered after the target's last message, so existing
+// seq values stay untouched and none goes negative; into an empty
+// conversation they are numbered from 0.
+func applyCrossDBTransplant(ctx context.Context, db *sql.DB, plan transplantPlan, out io.Writer) (int, int64, error) {
+	cross := plan.crossDB
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("begin transplant transaction: %w", err)
+	}
+	rollback := true
+	defer func() {
+		if rollback {
+			_ = tx.Rollback()
+		}
+	}()
+
+	description := fmt.Sprintf("transplant %d summaries from %s conversation %d", len(plan.ordered), cross.sourcePath, plan.sourceConversationID)
+	j, err := startJournalOp(ctx, tx, "transplant", plan.targetConversationID, description)
+	if err != nil {
+		return 0, 0, err
+	}
+	contextBefore, err := captureContext(ctx, tx, plan.targetConversationID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	oldToNew, copied, err := copyCrossDBSummaries(ctx, tx, j, plan, out)
+	if err != nil {
+		return copied, 0, err
+	}
+	if err := prependTransplantedContextItems(ctx, tx, plan.targetConversationID, plan.sourceContext, oldToNew); err != nil {
+		return copied, 0, err
+	}
+	if err := j.recordContext(ctx, plan.targetConversationID, contextBefore); err != nil {
+		return copied, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return copied, 0, fmt.Errorf("commit transplant transaction: %w", err)
+	}
+	rollback = false
+	return copied, j.opID, nil
+}
+
+// copyCrossDBSummaries copies the plan's messages, then its summaries in
+// depth order with their links and edges remapped, journaling every row. It
+// returns the old -> new summary ID map and how many summaries were copied.
+func copyCrossDBSummaries(ctx context.Context, tx *sql.Tx, j *journal, plan transplantPlan, out io.Writer) (map[string]string, int, error) {
+	cross := plan.crossDB
+	messageIDs, err := copyTransplantMessages(ctx, tx, j, plan)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	oldToNew := make(map[string]string, len(plan.ordered))
+	for i, source := range plan.ordered {
+		newSummaryID, err := generateSummaryID(ctx, tx)
+		if err != nil {
+			return nil, i, err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO summaries (summary_id, conversation_id, kind, content, token_count, created_at, file_ids, depth)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, newSummaryID, plan.targetConversationID, source.kind, source.content, source.tokenCount, source.createdAt, source.fileIDs, source.depth); err != nil {
+			return nil, i, fmt.Errorf("insert summary %s (from %s): %w", newSummaryID, source.summaryID, err)
+		}
+		for _, link := range cross.links[source.summaryID] {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO summary_messages (summary_id, message_id, ordinal)
+				VALUES (?, ?, ?)
+			`, newSummaryID, messageIDs[link.messageID], link.ordinal); err != nil {
+				return nil, i, fmt.Errorf("link %s to message #%d: %w", newSummaryID, messageIDs[link.messageID], err)
+			}
+		}
+		for _, edge := range cross.edges[source.summaryID] {
+			parentID, ok := oldToNew[edge.parentSummaryID]
+			if !ok {
+				return nil, i, fmt.Errorf("missing remapped parent for %s -> %s", source.summaryID, edge.parentSummaryID)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO summary_parents (summary_id, parent_summary_id, ordinal)
+				VALUES (?, ?, ?)
+			`, newSummaryID, parentID, edge.ordinal); err != nil {
+				return nil, i, fmt.Errorf("insert parent edge for %s: %w", source.summaryID, err)
+			}
+		}
+		for _, table := range []string{"summaries", "summary_messages", "summary_parents"} {
+			if err := j.recordInsert(ctx, table, "summary_id = ?", newSummaryID); err != nil {
+				return nil, i, err
+			}
+		}
+
+		oldToNew[source.summaryID] = newSummaryID
+		fmt.Fprintf(out, "[%d/%d] %s -> %s (%s, d%d)\n", i+1, len(plan.ordered), source.summaryID, newSummaryID, source.kind, source.depth)
+	}
+	return oldToNew, len(plan.ordered), nil
+}
+
+// copyTransplantMessages inserts the source messages and parts with new IDs
+// and returns the source -> target message ID map, including reused
+// messages.
+func copyTransplantMessages(ctx context.Context, tx *sql.Tx, j *journal, plan transplantPlan) (map[int64]int64, error) {
+	cross := plan.crossDB
+	messageIDs := make(map[int64]int64, len(cross.reused)+len(cross.messages))
+	for sourceID, targetID := range cross.reused {
+		messageIDs[sourceID] = targetID
+	}
+	if len(cross.messages) == 0 {
+		return messageIDs, nil
+	}
+
+	messageColumns, err := tableColumns(ctx, tx, "messages")
+	if err != nil {
+		return nil, err
+	}
+	var nextID, nextSeq int64
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(message_id), 0) + 1 FROM messages`).Scan(&nextID); err != nil {
+		return nil, fmt.Errorf("allocate message IDs: %w", err)
+	}
+	if messageColumns["seq"] {
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(MAX(seq), -1) + 1 FROM messages WHERE conversation_id = ?
+		`, plan.targetConversationID).Scan(&nextSeq); err != nil {
+			return nil, fmt.Errorf("allocate message seq values: %w", err)
+		}
+	}
+
+	for i, source := range cross.messages {
+		row := make(journalRow, len(source))
+		for column, value := range source {
+			row[column] = value
+		}
+		sourceID := rowInt64(source["message_id"])
+		row["message_id"] = nextID
+		row["conversation_id"] = plan.targetConversationID
+		if _, ok := row["seq"]; ok {
+			row["seq"] = nextSeq + int64(i)
+		}
+		if err := insertCopiedRow(ctx, tx, "messages", messageColumns, row); err != nil {
+			return nil, err
+		}
+		if err := j.recordInsert(ctx, "messages", "message_id = ?", nextID); err != nil {
+			return nil, err
+		}
+		messageIDs[sourceID] = nextID
+		nextID++
+	}
+
+	if len(cross.parts) == 0 {
+		return messageIDs, nil
+	}
+	partColumns, err := tableColumns(ctx, tx, "message_parts")
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range cross.parts {
+		row := make(journalRow, len(source))
+		for column, value := range source {
+			row[column] = value
+		}
+		row["message_id"] = messageIDs[rowInt64(source["message_id"])]
+		if _, ok := row["session_id"]; ok {
+			row["session_id"] = cross.targetSessionID
+		}
+		partID, err := freePartID(ctx, tx, fmt.Sprint(source["part_id"]))
+		if err != nil {
+			return nil, err
+		}
+		row["part_id"] = partID
+		if err := insertCopiedRow(ctx, tx, "message_parts", partColumns, row); err != nil {
+			return nil, err
+		}
+		if err := j.recordInsert(ctx, "message_parts", "part_id = ?", partID); err != nil {
+			return nil, err
+		}
+	}
+	return messageIDs, nil
+}
+
+// freePartID keeps a part's ID unless the target already uses it, in which
+// case it gets a random suffix.
+func freePartID(ctx context.Context, q sqlQueryer, partID string) (string, error) {
+	candidate := partID
+	for attempt := 0; attempt < 32; attempt++ {
+		var count int
+		if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM message_parts WHERE part_id = ?`, candidate).Scan(&count); err != nil {
+			return "", fmt.Errorf("check part ID %s: %w", candidate, err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		var raw [4]byte
+		if _, err := rand.Read(raw[:]); err != nil {
+			return "", fmt.Errorf("generate part ID suffix: %w", err)
+		}
+		candidate = partID + "-" + hex.EncodeToString(raw[:])
+	}
+	return "", fmt.Errorf("unable to find a free part ID for %s", partID)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// crossDBTargetFixture is a target conversation that already holds a copy of
+// the source's message 3, a message at seq 5 so copies must number after
+// it, and a part whose ID collides with the source's part_1.
+const crossDBTargetFixture = `
+INSERT INTO conversations (conversation_id, session_id) VALUES (1, 'sess-target');
+INSERT INTO messages (message_id, conversation_id, seq, role, content, token_count) VALUES
+	(10, 1, 0, 'user', 'promote to production', 4),
+	(11, 1, 5, 'assistant', 'release notes drafted', 4);
+INSERT INTO message_parts (part_id, message_id, session_id, part_type, ordinal, text_content) VALUES
+	('part_1', 10, 'sess-target', 'text', 0, 'promote to production');
+INSERT INTO context_items (conversation_id, ordinal, item_type, message_id) VALUES (1, 0, 'message', 11);
+`
+
+func openLCMTestDBAt(t *testing.T, path, fixture string) *sql.DB {
+	t.Helper()
+	create, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := create.Exec(repairTestSchema + fixture); err != nil {
+		t.Fatal(err)
+	}
+	if err := create.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := openLCMDB(path, dbReadWrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCrossDBTransplantRemapsAndUndoes(t *testing.T) {
+	ctx := context.Background()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "source.db")
+	targetPath := filepath.Join(dir, "target.db")
+	source := openLCMTestDBAt(t, sourcePath, journalTestFixture)
+	target := openLCMTestDBAt(t, targetPath, crossDBTargetFixture)
+
+	if same, err := sameDBFile(sourcePath, targetPath); err != nil || same {
+		t.Fatalf("sameDBFile(source, target) = %v, %v; want false", same, err)
+	}
+	before := snapshotJournalTables(t, target)
+
+	plan, err := buildCrossDBTransplantPlan(ctx, source, target, sourcePath, targetPath, 1, 1, transplantSelection{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.ordered) != 3 {
+		t.Fatalf("plan copies %d summaries, want 3", len(plan.ordered))
+	}
+	if got := plan.crossDB.reused; !reflect.DeepEqual(got, map[int64]int64{3: 10}) {
+		t.Fatalf("reused messages = %v, want source 3 -> target 10", got)
+	}
+	copied, opID, err := applyCrossDBTransplant(ctx, target, plan, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != 3 {
+		t.Fatalf("copied %d summaries, want 3", copied)
+	}
+
+	// Source messages 1 and 2 get fresh IDs after the target's highest and
+	// seq values after its last message; message 3 is reused.
+	rows, err := target.Query(`SELECT message_id, seq, content FROM messages WHERE conversation_id = 1 ORDER BY seq`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for rows.Next() {
+		var id, seq int64
+		var content string
+		if err := rows.Scan(&id, &seq, &content); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, strconv.FormatInt(id, 10)+"@"+strconv.FormatInt(seq, 10)+" "+content)
+	}
+	rows.Close()
+	want := []string{
+		"10@0 promote to production",
+		"11@5 release notes drafted",
+		"12@6 deploy the pipeline",
+		"13@7 deployed to staging",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("target messages:\n  got  %v\n  want %v", got, want)
+	}
+
+	links := map[string]string{}
+	rows, err = target.Query(`
+		SELECT s.content, GROUP_CONCAT(sm.message_id, ',')
+		FROM summary_messages sm JOIN summaries s ON s.summary_id = sm.summary_id
+		GROUP BY sm.summary_id ORDER BY s.content`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var content, ids string
+		if err := rows.Scan(&content, &ids); err != nil {
+			t.Fatal(err)
+		}
+		links[content] = ids
+	}
+	rows.Close()
+	wantLinks := map[string]string{
+		"Deployed the pipeline to staging.": "12,13",
+		"Promoted to production.":           "10",
+	}
+	if !reflect.DeepEqual(links, wantLinks) {
+		t.Fatalf("summary links = %v, want %v", links, wantLinks)
+	}
+
+	// The copied part for message 12 collided with the target's part_1.
+	var partID, sessionID string
+	if err := target.QueryRow(`SELECT part_id, session_id FROM message_parts WHERE message_id = 12`).Scan(&partID, &sessionID); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(partID, "part_1-") || sessionID != "sess-target" {
+		t.Fatalf("copied part = %s in %s, want part_1-<suffix> in sess-target", partID, sessionID)
+	}
+	var kept int64
+	if err := target.QueryRow(`SELECT message_id FROM message_parts WHERE part_id = 'part_1'`).Scan(&kept); err != nil || kept != 10 {
+		t.Fatalf("target part_1 now belongs to message %d (%v), want 10", kept, err)
+	}
+
+	var contextItems int
+	if err := target.QueryRow(`SELECT COUNT(*) FROM context_items WHERE conversation_id = 1`).Scan(&contextItems); err != nil {
+		t.Fatal(err)
+	}
+	if contextItems != 2 {
+		t.Fatalf("target context has %d items, want the condensed summary prepended to 1", contextItems)
+	}
+
+	stdout := os.Stdout
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = devnull
+	err = runUndoCommand([]string{strconv.FormatInt(opID, 10), "--db", targetPath, "--apply"})
+	os.Stdout = stdout
+	devnull.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := filepath.Glob(filepath.Join(home, ".openclaw", "lcm-backups", "db-target-*", "*.db"))
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("undo --db backups = %v (%v), want one in the target's backup directory", backups, err)
+	}
+	after := snapshotJournalTables(t, target)
+	for _, table := range journalTestTables {
+		if !reflect.DeepEqual(after[table], before[table]) {
+			t.Errorf("%s after undo --db:\n  got  %v\n  want %v", table, after[table], before[table])
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// transplantPickerStage is the step the transplant picker is on.
+type transplantPickerStage int
+
+const (
+	transplantPickSummaries transplantPickerStage = iota
+	transplantPickTarget
+	transplantPickConfirm
+)
+
+// transplantPicker walks through choosing source summaries, a target
+// conversation and confirming the plan. From the context view it starts by
+// picking summaries; from the session list it transplants all context
+// summaries and starts at the target.
+type transplantPicker struct {
+	stage                transplantPickerStage
+	fromSessions         bool
+	sourceConversationID int64
+	items                []contextItemEntry // summary items of the source context
+	selected             []bool
+	cursor               int
+	targets              []transplantTarget
+	targetCursor         int
+	plan                 transplantPlan
+}
+
+// transplantTarget is one conversation offered as a transplant target.
+type transplantTarget struct {
+	conversationID int64
+	sessionID      string
+	updatedAt      string
+	contextItems   int
+}
+
+func (p *transplantPicker) selection() transplantSelection {
+	var ids []string
+	for i, item := range p.items {
+		if p.selected[i] {
+			ids = append(ids, item.summaryID)
+		}
+	}
+	return transplantSelection{summaryIDs: ids}
+}
+
+func (p *transplantPicker) selectedTokens() (int, int) {
+	count, tokens := 0, 0
+	for i, item := range p.items {
+		if p.selected[i] {
+			count++
+			tokens += item.tokenCount
+		}
+	}
+	return count, tokens
+}
+
+// loadTransplantTargets lists every other conversation, most recently
+// updated first.
+func loadTransplantTargets(ctx context.Context, q sqlQueryer, excludeConversationID int64) ([]transplantTarget, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.conversation_id, c.session_id, c.updated_at, COUNT(ci.ordinal)
+		FROM conversations c
+		LEFT JOIN context_items ci ON ci.conversation_id = c.conversation_id
+		WHERE c.conversation_id != ?
+		GROUP BY c.conversation_id
+		ORDER BY c.updated_at DESC, c.conversation_id DESC
+	`, excludeConversationID)
+	if err != nil {
+		return nil, fmt.Errorf("query transplant targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []transplantTarget
+	for rows.Next() {
+		var target transplantTarget
+		if err := rows.Scan(&target.conversationID, &target.sessionID, &target.updatedAt, &target.contextItems); err != nil {
+			return nil, fmt.Errorf("scan transplant target: %w", err)
+		}
+		targets = append(targets, target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate transplant targets: %w", err)
+	}
+	return targets, nil
+}
+
+// startTransplantPicker opens the picker on the current context's summary
+// items, preselecting the marked range or, with no mark, all of them.
+func (m *model) startTransplantPicker() {
+	conversationID, ok := m.currentConversationID()
+	if !ok {
+		m.status = "Missing conversation ID for current session"
+		return
+	}
+	if m.db == nil {
+		m.status = "Error: " + errLCMDBUnavailable.Error()
+		return
+	}
+	lo, hi := 0, len(m.contextItems)-1
+	if m.contextMark >= 0 {
+		lo, hi = m.contextMarkRange()
+	}
+	picker := &transplantPicker{sourceConversationID: conversationID}
+	for i, item := range m.contextItems {
+		if item.itemType != "summary" {
+			continue
+		}
+		picker.items = append(picker.items, item)
+		picker.selected = append(picker.selected, i >= lo && i <= hi)
+	}
+	if len(picker.items) == 0 {
+		m.status = "No summary context items to transplant"
+		return
+	}
+	m.transplantPick = picker
+	m.status = "Pick summaries to transplant: space toggles, enter chooses the target"
+}
+
+// startSessionTransplant opens the target list for the session under the
+// cursor, offering the agent's other loaded sessions that have an LCM
+// conversation.
+func (m *model) startSessionTransplant() {
+	source, ok := m.currentSession()
+	if !ok {
+		m.status = "No session selected"
+		return
+	}
+	if source.conversationID <= 0 {
+		m.status = "Session " + source.id + " has no LCM conversation"
+		return
+	}
+	if m.db == nil {
+		m.status = "Error: " + errLCMDBUnavailable.Error()
+		return
+	}
+	all, err := loadTransplantTargets(context.Background(), m.db, source.conversationID)
+	if err != nil {
+		m.status = "Error: " + err.Error()
+		return
+	}
+	byConversation := make(map[int64]transplantTarget, len(all))
+	for _, target := range all {
+		byConversation[target.conversationID] = target
+	}
+	picker := &transplantPicker{
+		stage:                transplantPickTarget,
+		fromSessions:         true,
+		sourceConversationID: source.conversationID,
+	}
+	for _, session := range m.sessions {
+		if target, ok := byConversation[session.conversationID]; ok && session.id != source.id {
+			target.updatedAt = formatTimeForList(session.updatedAt)
+			picker.targets = append(picker.targets, target)
+		}
+	}
+	if len(picker.targets) == 0 {
+		m.status = "No other loaded session has an LCM conversation to transplant into"
+		return
+	}
+	m.transplantPick = picker
+	m.status = fmt.Sprintf("Transplant %s (conv %d): choose the target session", source.id, source.conversationID)
+}
+
+func (m model) handleTransplantPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	picker := m.transplantPick
+	switch picker.stage {
+	case transplantPickSummaries:
+		switch msg.String() {
+		case "up", "k":
+			picker.cursor = clamp(picker.cursor-1, 0, len(picker.items)-1)
+		case "down", "j":
+			picker.cursor = clamp(picker.cursor+1, 0, len(picker.items)-1)
+		case " ", "x":
+			picker.selected[picker.cursor] = !picker.selected[picker.cursor]
+		case "a":
+			count, _ := picker.selectedTokens()
+			for i := range picker.selected {
+				picker.selected[i] = count < len(picker.items)
+			}
+		case "enter":
+			if count, _ := picker.selectedTokens(); count == 0 {
+				m.status = "Select at least one summary"
+				return m, nil
+			}
+			targets, err := loadTransplantTargets(context.Background(), m.db, picker.sourceConversationID)
+			if err != nil {
+				m.status = "Error: " + err.Error()
+				return m, nil
+			}
+			if len(targets) == 0 {
+				m.status = "No other conversations to transplant into"
+				return m, nil
+			}
+			picker.targets = targets
+			picker.stage = transplantPickTarget
+			m.status = "Choose the target conversation"
+		case "esc", "b", "backspace":
+			m.transplantPick = nil
+			m.status = "Transplant canceled; nothing written"
+		}
+	case transplantPickTarget:
+		switch msg.String() {
+		case "up", "k":
+			picker.targetCursor = clamp(picker.targetCursor-1, 0, len(picker.targets)-1)
+		case "down", "j":
+			picker.targetCursor = clamp(picker.targetCursor+1, 0, len(picker.targets)-1)
+		case "enter":
+			target := picker.targets[picker.targetCursor]
+			plan, err := buildTransplantPlan(context.Background(), m.db, picker.sourceConversationID, target.conversationID, picker.selection())
+			if err != nil {
+				m.status = "Error: " + err.Error()
+				return m, nil
+			}
+			if len(plan.sourceContext) == 0 {
+				m.status = fmt.Sprintf("Conversation %d has no summary context items to transplant", picker.sourceConversationID)
+				return m, nil
+			}
+			picker.plan = plan
+			picker.stage = transplantPickConfirm
+			if len(plan.duplicates) > 0 {
+				m.status = fmt.Sprintf("Conversation %d already has %d of these summaries; choose another target", target.conversationID, len(plan.duplicates))
+			} else {
+				m.status = fmt.Sprintf("Transplant %d summaries into conversation %d? y: apply, n: back", len(plan.ordered), target.conversationID)
+			}
+		case "esc", "b", "backspace":
+			if picker.fromSessions {
+				m.transplantPick = nil
+				m.status = "Transplant canceled; nothing written"
+				return m, nil
+			}
+			picker.stage = transplantPickSummaries
+			m.status = "Pick summaries to transplant: space toggles, enter chooses the target"
+		}
+	case transplantPickConfirm:
+		switch msg.String() {
+		case "y", "enter":
+			if len(picker.plan.duplicates) > 0 {
+				m.status = "Refusing to transplant duplicate summaries; choose another target"
+				return m, nil
+			}
+			return m, m.confirmTransplantPicker()
+		case "n", "esc", "b", "backspace":
+			picker.stage = transplantPickTarget
+			m.status = "Choose the target conversation"
+		}
+	}
+	return m, nil
+}
+
+// confirmTransplantPicker closes the picker and, in the background, rebuilds
+// the plan on a read-write handle, refusing if the source changed since the
+// preview, then snapshots and applies it.
+func (m *model) confirmTransplantPicker() tea.Cmd {
+	preview := m.transplantPick.plan
+	m.transplantPick = nil
+	dbPath, backupsDir := m.paths.lcmDBPath, m.paths.backupsDir
+
+	_, seq := m.beginLoad("Transplanting...")
+	return m.startLoad(lcmWriteCmd(seq, "", -1, func(ctx context.Context) (string, error) {
+		db, err := openLCMDB(dbPath, dbReadWrite)
+		if err != nil {
+			return "", err
+		}
+		defer db.Close()
+
+		plan, err := buildTransplantPlan(ctx, db, preview.sourceConversationID, preview.targetConversationID, preview.selection)
+		if err != nil {
+			return "", err
+		}
+		if len(plan.ordered) != len(preview.ordered) || len(plan.duplicates) > 0 {
+			return "", errors.New("source or target changed since preview; reload and try again")
+		}
+		label := fmt.Sprintf("tui-transplant-conv%d-to-conv%d", plan.sourceConversationID, plan.targetConversationID)
+		backup, err := snapshotLCMDB(ctx, db, backupsDir, label)
+		if err != nil {
+			return "", fmt.Errorf("backup before transplant: %w", err)
+		}
+		copied, opID, err := applyTransplant(ctx, db, plan, io.Discard)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Transplanted %d summaries (%d context items, ~%dt) into conversation %d. Backup: %s. Undo with: lcm-tui undo %d",
+			copied, len(plan.sourceContext), plan.contextTokenOverhead, plan.targetConversationID, backup.name, opID), nil
+	}))
+}
+
+func (m model) renderTransplantPicker() string {
+	picker := m.transplantPick
+	available := max(6, m.height-4)
+	var lines []string
+	switch picker.stage {
+	case transplantPickSummaries:
+		count, tokens := picker.selectedTokens()
+		lines = append(lines,
+			fmt.Sprintf("Transplant from conversation %d: %d of %d summaries selected, %dt", picker.sourceConversationID, count, len(picker.items), tokens),
+			"Their full DAG is copied and the selected summaries are prepended to the target's context.",
+			"",
+		)
+		listHeight := max(3, available-len(lines))
+		offset := listOffset(picker.cursor, len(picker.items), listHeight)
+		for i := offset; i < min(len(picker.items), offset+listHeight); i++ {
+			check := "[ ]"
+			if picker.selected[i] {
+				check = "[x]"
+			}
+			line := check + strings.TrimPrefix(m.formatContextItemLine(picker.items[i]), " ")
+			if i == picker.cursor {
+				line = selectedStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+	case transplantPickTarget:
+		if picker.fromSessions {
+			lines = append(lines, fmt.Sprintf("Transplant every context summary of conversation %d into:", picker.sourceConversationID), "")
+		} else {
+			count, tokens := picker.selectedTokens()
+			lines = append(lines, fmt.Sprintf("Transplant %d summaries (%dt) from conversation %d into:", count, tokens, picker.sourceConversationID), "")
+		}
+		listHeight := max(3, available-len(lines))
+		offset := listOffset(picker.targetCursor, len(picker.targets), listHeight)
+		for i := offset; i < min(len(picker.targets), offset+listHeight); i++ {
+			target := picker.targets[i]
+			line := fmt.Sprintf("  conv %-6d %-40s %4d context items  updated %s", target.conversationID, truncateString(target.sessionID, 40), target.contextItems, target.updatedAt)
+			if i == picker.targetCursor {
+				line = selectedStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+	case transplantPickConfirm:
+		lines = transplantReportLines(picker.plan)
+		lines = append(lines, "")
+		if len(picker.plan.duplicates) > 0 {
+			lines = append(lines, "Refusing to apply: the target already holds some of this content. Press b to choose another target.")
+		} else {
+			lines = append(lines, "Press y or Enter to transplant (snapshots lcm.db first, undoable), n or Esc to go back.")
+		}
+		if len(lines) > available {
+			lines = append(lines[:available-1], lines[len(lines)-1])
+		}
+	}
+	return strings.Join(lines, "\n")
+}